@@ -25,6 +25,9 @@ if [ -f "$DB_FILE" ]; then
         if [ $? -eq 0 ]; then
             echo "✅ 数据库文件已成功删除！"
             echo "🔄 下次启动应用时将重新设置主密码。"
+            if [ -d "$DB_DIR/backups" ]; then
+                echo "💾 自动备份保留在 $DB_DIR/backups，需要时可将其中一份复制为 $DB_FILE，再用备份时的主密码登录。"
+            fi
         else
             echo "❌ 删除失败！请检查文件权限。"
             exit 1
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"hank.com/password_tool/database"
+)
+
+// runAudit 校验并输出审计日志
+// 用法：password_tool audit [--action 操作] [--client 客户端] [--search 文本] [--verify]
+func runAudit(args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	var filter database.AuditFilter
+	flags.StringVar(&filter.Action, "action", "", "只显示指定操作（unlock、view、copy、create、update、delete、restore、purge、sign）")
+	flags.StringVar(&filter.Client, "client", "", "只显示指定客户端（GUI、CLI、agent）")
+	flags.StringVar(&filter.Text, "search", "", "只显示标题或详情包含该文本的记录")
+	verifyOnly := flags.Bool("verify", false, "只校验日志完整性")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openVault()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.VerifyAuditLog(); err != nil {
+		return fmt.Errorf("审计日志校验失败，日志可能已被篡改: %v", err)
+	}
+	if *verifyOnly {
+		fmt.Println("审计日志完整")
+		return nil
+	}
+
+	records, err := db.GetAuditLog(filter)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		fmt.Printf("%s  %-6s  %-7s  %s  %s\n", record.Time.Format("2006-01-02 15:04:05"),
+			record.Client, record.Action, record.Subject, record.Detail)
+	}
+	return nil
+}
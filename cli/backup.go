@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"hank.com/password_tool/database"
+)
+
+// runBackup 创建一次备份并清理旧备份，备份文件是加密的，无需主密码，可放入 cron 定时执行
+// 用法：password_tool backup [--list]
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	list := flags.Bool("list", false, "只列出现有备份")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := database.NewDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if !*list {
+		backup, err := db.CreateBackup()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已创建备份 %s\n", backup.Path)
+	}
+
+	backups, err := db.ListBackups()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		fmt.Printf("%s  %8.1f KB  %s\n", backup.CreatedAt.Format("2006-01-02 15:04:05"), float64(backup.Size)/1024, backup.Name)
+	}
+	return nil
+}
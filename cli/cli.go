@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"golang.org/x/term"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/factor"
+	"hank.com/password_tool/models"
+)
+
+// 可通过环境变量提供主密码，供无法交互输入的脚本使用
+const masterPasswordEnv = "PASSWORD_TOOL_MASTER_PASSWORD"
+
+// 密码库启用密钥文件或软件令牌时，通过环境变量指定文件路径
+const (
+	keyFileEnv     = "PASSWORD_TOOL_KEY_FILE"
+	factorTokenEnv = "PASSWORD_TOOL_FACTOR_TOKEN"
+)
+
+// command 表示一个命令行子命令
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+// commands 所有可用的子命令
+var commands = map[string]command{
+	"audit": {
+		usage: "audit [--action 操作] [--client 客户端] [--search 文本] [--verify]",
+		run:   runAudit,
+	},
+	"backup": {
+		usage: "backup [--list]",
+		run:   runBackup,
+	},
+	"git-credential": {
+		usage: "git-credential [--category 分类] get|store|erase",
+		run:   runGitCredential,
+	},
+	"import": {
+		usage: "import [--folder 文件夹] KeePass导出的XML文件",
+		run:   runImport,
+	},
+	"inject": {
+		usage: "inject -i 模板文件 -o 输出文件 [-f]",
+		run:   runInject,
+	},
+	"list": {
+		usage: "list [--folder 文件夹] [--tag 标签 ...] [--expiring 天数] [查询]",
+		run:   runList,
+	},
+	"serve": {
+		usage: "serve [--addr 127.0.0.1:8787] [--data 目录]",
+		run:   runServe,
+	},
+	"sync": {
+		usage: "sync [--folder 目录] [--server 地址 --user 用户名] [--webdav 地址 --webdav-user 用户名] [--backup]",
+		run:   runSync,
+	},
+	"tags": {
+		usage: "tags",
+		run:   runTags,
+	},
+	"run": {
+		usage: "run --env NAME=entry:[<文件夹>/]<标题>/<字段> [--env ...] -- 命令 [参数...]",
+		run:   runRun,
+	},
+}
+
+// IsCommand 检查参数是否为已知的子命令
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run 执行子命令，返回进程退出码
+func Run(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n", args[0])
+		printUsage()
+		return 2
+	}
+
+	if err := cmd.run(args[1:]); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			return exitErr.code
+		}
+		fmt.Fprintf(os.Stderr, "password_tool %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// printUsage 打印命令用法
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "用法:")
+	fmt.Fprintln(os.Stderr, "  password_tool              启动图形界面")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  password_tool %s\n", commands[name].usage)
+	}
+}
+
+// openVault 打开数据库并使用主密码解锁
+func openVault() (*database.DB, error) {
+	db, err := database.NewDB()
+	if err != nil {
+		var tooNew *database.SchemaTooNewError
+		if errors.As(err, &tooNew) {
+			return nil, fmt.Errorf("密码库由更新版本的程序创建（结构版本 %d，当前程序支持 %d），请升级 password_tool", tooNew.Version, tooNew.Supported)
+		}
+		return nil, err
+	}
+	db.SetAuditClient(models.AuditClientCLI)
+
+	if err := unlockVault(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// 提醒上次解锁以来失败的解锁尝试
+	if attempts := db.FailedLoginsBeforeUnlock(); len(attempts) > 0 {
+		fmt.Fprintf(os.Stderr, "警告: 自上次解锁以来有 %d 次失败的解锁尝试，最近一次在 %s\n",
+			len(attempts), attempts[len(attempts)-1].Format("2006-01-02 15:04:05"))
+	}
+
+	return db, nil
+}
+
+// unlockVault 读取密钥文件、第二因素和主密码并解锁密码库
+func unlockVault(db database.VaultStore) error {
+	hasMasterPassword, err := db.HasMasterPassword()
+	if err != nil {
+		return err
+	}
+	if !hasMasterPassword {
+		return fmt.Errorf("尚未设置主密码，请先启动图形界面完成设置")
+	}
+
+	// 先读取密钥文件和第二因素，缺少时无需再输入主密码
+	keyFile, err := readKeyFile(db)
+	if err != nil {
+		return err
+	}
+	responder, err := loadFactor(db)
+	if err != nil {
+		return err
+	}
+
+	password, err := readMasterPassword()
+	if err != nil {
+		return err
+	}
+
+	credentials := database.Credentials{Password: password, KeyFile: keyFile, Factor: responder}
+
+	valid, err := db.Unlock(credentials)
+	if err != nil {
+		var lockout *database.LockoutError
+		switch {
+		case errors.As(err, &lockout):
+			return fmt.Errorf("尝试次数过多，请在 %s 后重试", lockout.Wait.Round(time.Second))
+		case errors.Is(err, database.ErrVaultWiped):
+			return fmt.Errorf("连续输错主密码的次数达到上限，密码库已被清除")
+		}
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("密码错误")
+	}
+	return nil
+}
+
+// readKeyFile 密码库需要密钥文件时从环境变量指定的路径读取
+func readKeyFile(db database.VaultStore) ([]byte, error) {
+	required, err := db.KeyFileRequired()
+	if err != nil || !required {
+		return nil, err
+	}
+
+	path := os.Getenv(keyFileEnv)
+	if path == "" {
+		return nil, fmt.Errorf("密码库需要密钥文件，请通过环境变量 %s 指定", keyFileEnv)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.ParseKeyFile(data)
+}
+
+// loadFactor 密码库启用第二因素时从环境变量指定的路径加载软件令牌
+func loadFactor(db database.VaultStore) (factor.Responder, error) {
+	name, err := db.FactorRequired()
+	if err != nil || name == "" {
+		return nil, err
+	}
+
+	path := os.Getenv(factorTokenEnv)
+	if path == "" {
+		return nil, fmt.Errorf("密码库需要第二因素（%s），请通过环境变量 %s 指定软件令牌文件", name, factorTokenEnv)
+	}
+	return factor.LoadSoftwareToken(path)
+}
+
+// readMasterPassword 从环境变量或终端读取主密码
+// 标准输入可能被 git 等调用方占用，因此直接从 /dev/tty 读取
+func readMasterPassword() (string, error) {
+	if password, ok := os.LookupEnv(masterPasswordEnv); ok {
+		return password, nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("无法打开终端输入主密码，可设置环境变量 %s: %v", masterPasswordEnv, err)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, "主密码: ")
+	password, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", err
+	}
+
+	return string(password), nil
+}
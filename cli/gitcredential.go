@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// 新凭据默认存入的分类
+const defaultGitCategory = "git"
+
+// gitCredential 表示 git credential 协议中的一组属性
+type gitCredential struct {
+	Protocol string
+	Host     string
+	Path     string
+	Username string
+	Password string
+}
+
+// runGitCredential 实现 git credential helper 协议
+// 用法：git config --global credential.helper "/path/to/password_tool git-credential"
+func runGitCredential(args []string) error {
+	flags := flag.NewFlagSet("git-credential", flag.ContinueOnError)
+	category := flags.String("category", defaultGitCategory, "新凭据存入的分类")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("用法: password_tool git-credential [--category 分类] get|store|erase")
+	}
+
+	cred, err := readGitCredential(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	action := flags.Arg(0)
+	switch action {
+	case "get", "store", "erase":
+	default:
+		// 按协议约定，忽略未知的操作
+		return nil
+	}
+
+	db, err := openVault()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "get":
+		return gitCredentialGet(db, cred, os.Stdout)
+	case "store":
+		return gitCredentialStore(db, cred, *category)
+	default:
+		return gitCredentialErase(db, cred)
+	}
+}
+
+// readGitCredential 读取 key=value 格式的输入，直到空行或输入结束
+func readGitCredential(r io.Reader) (*gitCredential, error) {
+	cred := &gitCredential{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "protocol":
+			cred.Protocol = value
+		case "host":
+			cred.Host = value
+		case "path":
+			cred.Path = value
+		case "username":
+			cred.Username = value
+		case "password":
+			cred.Password = value
+		case "url":
+			if u, err := url.Parse(value); err == nil {
+				cred.Protocol = u.Scheme
+				cred.Host = u.Host
+				cred.Path = strings.TrimPrefix(u.Path, "/")
+				if u.User != nil {
+					cred.Username = u.User.Username()
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if cred.Host == "" {
+		return nil, fmt.Errorf("缺少 host 属性")
+	}
+	return cred, nil
+}
+
+// URL 返回凭据对应的网址
+func (c *gitCredential) URL() string {
+	u := url.URL{Scheme: c.Protocol, Host: c.Host}
+	if c.Protocol == "" {
+		u.Scheme = "https"
+	}
+	if c.Path != "" {
+		u.Path = "/" + c.Path
+	}
+	return u.String()
+}
+
+// matchScore 计算条目与凭据的匹配程度，返回 -1 表示不匹配
+// 主机必须相同，条目路径为空时匹配所有路径，否则需为请求路径的前缀，路径越长越优先
+func (c *gitCredential) matchScore(entry *models.PasswordEntry) int {
+	// 不带协议的网址（如 github.com/org）解析后主机为空，补上协议再解析，此时不比较协议
+	rawURL := strings.TrimSpace(entry.URL)
+	hasScheme := strings.Contains(rawURL, "://")
+	if !hasScheme {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return -1
+	}
+	if !strings.EqualFold(u.Host, c.Host) {
+		return -1
+	}
+	if c.Protocol != "" && hasScheme && u.Scheme != c.Protocol {
+		return -1
+	}
+	if c.Username != "" && entry.Username != c.Username {
+		return -1
+	}
+
+	entryPath := strings.Trim(u.Path, "/")
+	requestPath := strings.Trim(strings.TrimSuffix(c.Path, ".git"), "/")
+	if entryPath == "" || requestPath == "" {
+		// 未开启 credential.useHttpPath 时 git 不发送路径，按主机匹配
+		return 0
+	}
+
+	entryPath = strings.TrimSuffix(entryPath, ".git")
+	if requestPath == entryPath || strings.HasPrefix(requestPath, entryPath+"/") {
+		return len(entryPath)
+	}
+	return -1
+}
+
+// findGitEntry 查找最匹配的条目
+func findGitEntry(db database.VaultStore, cred *gitCredential) (*models.PasswordEntry, error) {
+	entries, err := db.GetPasswordEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var best *models.PasswordEntry
+	bestScore := -1
+	for _, entry := range entries {
+		if score := cred.matchScore(entry); score > bestScore {
+			best = entry
+			bestScore = score
+		}
+	}
+	return best, nil
+}
+
+// gitCredentialGet 输出匹配条目的用户名和密码，未找到时不输出任何内容
+func gitCredentialGet(db database.VaultStore, cred *gitCredential, w io.Writer) error {
+	entry, err := findGitEntry(db, cred)
+	if err != nil || entry == nil {
+		return err
+	}
+	if err := auditRead(db, entry, "git-credential get"); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "username=%s\npassword=%s\n", entry.Username, entry.Password)
+	return err
+}
+
+// gitCredentialStore 保存凭据，已存在匹配条目时更新密码
+func gitCredentialStore(db database.VaultStore, cred *gitCredential, category string) error {
+	if cred.Username == "" || cred.Password == "" {
+		return nil
+	}
+
+	entry, err := findGitEntry(db, cred)
+	if err != nil {
+		return err
+	}
+
+	if entry != nil && entry.Username == cred.Username {
+		if entry.Password == cred.Password {
+			return nil
+		}
+		entry.Password = cred.Password
+		return db.UpdatePasswordEntry(entry)
+	}
+
+	title := cred.Host
+	if cred.Path != "" {
+		title = cred.Host + "/" + strings.TrimSuffix(cred.Path, ".git")
+	}
+
+	return db.AddPasswordEntry(&models.PasswordEntry{
+		Title:    title,
+		Username: cred.Username,
+		Password: cred.Password,
+		URL:      cred.URL(),
+		Notes:    "由 git credential helper 保存",
+		Category: category,
+	})
+}
+
+// gitCredentialErase 删除匹配的条目，密码不一致时不删除
+func gitCredentialErase(db database.VaultStore, cred *gitCredential) error {
+	entry, err := findGitEntry(db, cred)
+	if err != nil || entry == nil {
+		return err
+	}
+	if cred.Password != "" && entry.Password != cred.Password {
+		return nil
+	}
+	return db.DeletePasswordEntry(entry.ID)
+}
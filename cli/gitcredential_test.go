@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"hank.com/password_tool/database/memstore"
+	"hank.com/password_tool/models"
+)
+
+// newTestStore 创建已解锁的内存密码库
+func newTestStore(t *testing.T) *memstore.Store {
+	t.Helper()
+	store := memstore.New()
+	store.SetAuditClient(models.AuditClientCLI)
+	if err := store.SetMasterPassword("pw"); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestReadGitCredential(t *testing.T) {
+	cred, err := readGitCredential(strings.NewReader("protocol=https\nhost=github.com\npath=org/repo.git\nusername=octocat\n\nignored=1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Protocol != "https" || cred.Host != "github.com" || cred.Path != "org/repo.git" || cred.Username != "octocat" {
+		t.Errorf("cred = %+v", cred)
+	}
+	if _, err := readGitCredential(strings.NewReader("protocol=https\n")); err == nil {
+		t.Error("readGitCredential() without host succeeded")
+	}
+}
+
+func TestGitCredentialStoreGetErase(t *testing.T) {
+	store := newTestStore(t)
+	repo := &gitCredential{Protocol: "https", Host: "github.com", Path: "org/repo.git", Username: "octocat", Password: "t1"}
+	if err := gitCredentialStore(store, repo, defaultGitCategory); err != nil {
+		t.Fatal(err)
+	}
+	// 按主机保存的条目匹配所有路径，较长的路径优先
+	if err := store.AddPasswordEntry(&models.PasswordEntry{Title: "github", Username: "octocat", Password: "host", URL: "github.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(cred *gitCredential) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := gitCredentialGet(store, cred, &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+	if got := get(&gitCredential{Protocol: "https", Host: "github.com", Path: "org/repo"}); got != "username=octocat\npassword=t1\n" {
+		t.Errorf("get repo = %q", got)
+	}
+	if got := get(&gitCredential{Protocol: "https", Host: "github.com", Path: "other/repo"}); got != "username=octocat\npassword=host\n" {
+		t.Errorf("get other repo = %q", got)
+	}
+	if got := get(&gitCredential{Protocol: "https", Host: "gitlab.com"}); got != "" {
+		t.Errorf("get unknown host = %q", got)
+	}
+
+	// 再次保存同一用户的凭据时更新密码，不新建条目
+	repo.Password = "t2"
+	if err := gitCredentialStore(store, repo, defaultGitCategory); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := store.GetPasswordEntries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("GetPasswordEntries() = %d entries, %v", len(entries), err)
+	}
+	for _, entry := range entries {
+		if entry.Title == "github.com/org/repo" && (entry.Password != "t2" || entry.Category != defaultGitCategory) {
+			t.Errorf("stored entry = %+v", entry)
+		}
+	}
+
+	// 读取凭据写入审计日志
+	var reads int
+	for _, record := range store.AuditLog() {
+		if record.Action == models.AuditView && record.Client == models.AuditClientCLI {
+			reads++
+		}
+	}
+	if reads != 2 {
+		t.Errorf("audit reads = %d, want 2", reads)
+	}
+
+	// 密码不一致时不删除
+	if err := gitCredentialErase(store, &gitCredential{Host: "github.com", Path: "org/repo", Password: "stale"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(&gitCredential{Host: "github.com", Path: "org/repo"}); got != "username=octocat\npassword=t2\n" {
+		t.Errorf("get after erase with stale password = %q", got)
+	}
+	if err := gitCredentialErase(store, &gitCredential{Host: "github.com", Path: "org/repo", Password: "t2"}); err != nil {
+		t.Fatal(err)
+	}
+	if trash, err := store.GetDeletedPasswordEntries(); err != nil || len(trash) != 1 {
+		t.Errorf("trash after erase = %d, %v", len(trash), err)
+	}
+}
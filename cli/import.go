@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"hank.com/password_tool/importer"
+)
+
+// runImport 导入 KeePass 2 导出的 XML 文件，KeePass 的组导入为文件夹
+// 用法：password_tool import [--folder 文件夹] keepass.xml
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	folder := flags.String("folder", "", "导入到的文件夹，如 work/keepass，默认按 KeePass 的组放在顶层")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("用法: password_tool import [--folder 文件夹] KeePass导出的XML文件")
+	}
+
+	// 先解析文件，格式错误时无需解锁密码库
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	entries, err := importer.ParseKeePassXML(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	db, err := openVault()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	imported, skipped, err := importer.Import(db, entries, *folder)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已导入 %d 个条目，跳过 %d 个已存在的条目\n", imported, skipped)
+	return nil
+}
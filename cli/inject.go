@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// runInject 渲染模板中的密码库引用并写入配置文件
+// 用法：password_tool inject -i config.tpl -o config.yaml
+// 模板中使用 {{ vault "标题" "password" }} 或 {{ vault "文件夹/标题" "password" }} 引用条目字段
+func runInject(args []string) error {
+	flags := flag.NewFlagSet("inject", flag.ContinueOnError)
+	input := flags.String("i", "", "模板文件")
+	output := flags.String("o", "", "输出文件")
+	force := flags.Bool("f", false, "允许覆盖不是由本工具生成或已被修改的文件")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" || *output == "" || flags.NArg() != 0 {
+		return fmt.Errorf("用法: password_tool inject -i 模板文件 -o 输出文件 [-f]")
+	}
+
+	outputPath, err := filepath.Abs(*output)
+	if err != nil {
+		return err
+	}
+
+	text, err := os.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+
+	// 先解析模板，语法错误时无需解锁密码库
+	var db *database.DB
+	var entries []*models.PasswordEntry
+	tmpl, err := template.New(filepath.Base(*input)).Option("missingkey=error").Funcs(template.FuncMap{
+		"vault": func(title, field string) (string, error) {
+			entry, err := findEntry(entries, title)
+			if err != nil {
+				return "", err
+			}
+			value, err := entryField(entry, field)
+			if err != nil {
+				return "", err
+			}
+			return value, auditRead(db, entry, "inject "+field)
+		},
+	}).Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	db, err = openVault()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if !*force {
+		if err := checkOverwrite(db, outputPath); err != nil {
+			return err
+		}
+	}
+
+	entries, err = db.GetPasswordEntries()
+	if err != nil {
+		return err
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, nil); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(outputPath, rendered.Bytes()); err != nil {
+		return err
+	}
+
+	return db.SetRenderedFile(outputPath, rendered.Bytes())
+}
+
+// checkOverwrite 检查输出文件是否可以覆盖
+// 只允许覆盖由本工具生成且生成后未被修改的文件
+func checkOverwrite(db *database.DB, path string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	generated, unchanged, err := db.CheckRenderedFile(path, existing)
+	if err != nil {
+		return err
+	}
+	if !generated {
+		return fmt.Errorf("%s 已存在且不是由 password_tool 生成，拒绝覆盖（使用 -f 强制覆盖）", path)
+	}
+	if !unchanged {
+		return fmt.Errorf("%s 在上次生成后已被修改，拒绝覆盖（使用 -f 强制覆盖）", path)
+	}
+	return nil
+}
+
+// writeFileAtomic 写入临时文件后重命名，避免留下不完整的文件
+// os.CreateTemp 创建的文件权限为 0600
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
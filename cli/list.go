@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+	"hank.com/password_tool/search"
+)
+
+// tagFlags 可重复指定的 --tag 参数，也可以用逗号分隔多个标签
+type tagFlags []string
+
+func (t *tagFlags) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *tagFlags) Set(value string) error {
+	*t = append(*t, strings.Split(value, ",")...)
+	return nil
+}
+
+// runList 列出条目的路径、用户名和标签，不输出密码，指定查询时按相关度排列
+// 用法：password_tool list [--folder 文件夹] [--tag 标签 ...] [--expiring 天数] [查询]
+func runList(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	folder := flags.String("folder", "", "只列出该文件夹及其子文件夹中的条目")
+	var tags tagFlags
+	flags.Var(&tags, "tag", "只列出带有该标签的条目，可重复，指定多个标签时条目需带有全部标签")
+	expiring := flags.Int("expiring", -1, "只列出该天数内过期（包括已过期）的条目")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openVault()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return listEntries(db, os.Stdout, *folder, tags, *expiring, strings.Join(flags.Args(), " "))
+}
+
+// listEntries 输出符合条件的条目，expiring 小于 0 时不限过期时间
+func listEntries(db database.VaultStore, w io.Writer, folder string, tags []string, expiring int, query string) error {
+	entries, err := db.GetPasswordEntries()
+	if err != nil {
+		return err
+	}
+	entries = filterEntries(entries, database.NormalizeCategoryPath(folder), database.NormalizeTags(tags))
+	if expiring >= 0 {
+		entries = expiringEntries(entries, time.Now().AddDate(0, 0, expiring))
+	}
+	for _, entry := range search.Search(entries, query) {
+		path := entry.Title
+		if entry.Category != "" {
+			path = entry.Category + models.CategorySeparator + entry.Title
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", path, entry.Username, strings.Join(entry.Tags, ","))
+	}
+	return nil
+}
+
+// filterEntries 返回属于文件夹 folder（包括子文件夹）且带有全部标签的条目，folder 为空时不限文件夹
+func filterEntries(entries []*models.PasswordEntry, folder string, tags []string) []*models.PasswordEntry {
+	var matched []*models.PasswordEntry
+	for _, entry := range entries {
+		if folder != "" && entry.Category != folder && !strings.HasPrefix(entry.Category, folder+models.CategorySeparator) {
+			continue
+		}
+		hasAll := true
+		for _, tag := range tags {
+			if !entry.HasTag(tag) {
+				hasAll = false
+				break
+			}
+		}
+		if hasAll {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// expiringEntries 返回在 before 之前（含）过期的条目
+func expiringEntries(entries []*models.PasswordEntry, before time.Time) []*models.PasswordEntry {
+	var matched []*models.PasswordEntry
+	for _, entry := range entries {
+		if entry.ExpiresBefore(before) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// runTags 列出所有标签及使用该标签的条目数
+// 用法：password_tool tags
+func runTags(args []string) error {
+	flags := flag.NewFlagSet("tags", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openVault()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return listTags(db, os.Stdout)
+}
+
+// listTags 输出所有标签及使用该标签的条目数
+func listTags(db database.VaultStore, w io.Writer) error {
+	tags, err := db.GetTags()
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		fmt.Fprintf(w, "%s\t%d\n", tag.Name, tag.EntryCount)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/database/memstore"
+	"hank.com/password_tool/models"
+)
+
+func TestListEntries(t *testing.T) {
+	store := newTestStore(t)
+	for _, entry := range []*models.PasswordEntry{
+		{Title: "aws-prod", Username: "admin", Category: "工作/aws", Tags: []string{"prod", "cloud"}},
+		{Title: "aws-dev", Username: "dev", Category: "工作/aws", Tags: []string{"cloud"}, ExpiresAt: time.Now().AddDate(0, 0, 3)},
+		{Title: "github", Username: "octocat", Category: "工作"},
+		{Title: "wifi", Category: ""},
+	} {
+		entry.Password = "secret"
+		if err := store.AddPasswordEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(folder string, tags []string, expiring int, query string) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := listEntries(store, &out, folder, tags, expiring, query); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+	if got := list("工作/aws", []string{"Cloud", "prod"}, -1, ""); got != "工作/aws/aws-prod\tadmin\tcloud,prod\n" {
+		t.Errorf("list by folder and tags = %q", got)
+	}
+	if got := list(" 工作 ", nil, -1, "octo"); got != "工作/github\toctocat\t\n" {
+		t.Errorf("list by query = %q", got)
+	}
+	if got := list("", nil, 7, ""); got != "工作/aws/aws-dev\tdev\tcloud\n" {
+		t.Errorf("list expiring = %q", got)
+	}
+	// 不输出密码
+	if got := list("", nil, -1, ""); strings.Count(got, "\n") != 4 || strings.Contains(got, "secret") {
+		t.Errorf("list all = %q", got)
+	}
+
+	var out bytes.Buffer
+	if err := listTags(store, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "cloud\t2\nprod\t1\n" {
+		t.Errorf("listTags() = %q", got)
+	}
+}
+
+func TestUnlockVault(t *testing.T) {
+	store := newTestStore(t)
+	store.SetMasterKey(nil)
+
+	t.Setenv(masterPasswordEnv, "wrong")
+	if err := unlockVault(store); err == nil || err.Error() != "密码错误" {
+		t.Errorf("unlockVault() with wrong password = %v", err)
+	}
+	t.Setenv(masterPasswordEnv, "pw")
+	if err := unlockVault(store); err != nil {
+		t.Fatalf("unlockVault() = %v", err)
+	}
+
+	// 启用密钥文件后需要通过环境变量指定
+	keyFile := filepath.Join(t.TempDir(), "vault.key")
+	if err := os.WriteFile(keyFile, []byte("key file contents"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(keyFileEnv, keyFile)
+	keyData, err := readKeyFile(store)
+	if err != nil || keyData != nil {
+		t.Fatalf("readKeyFile() before enabling = %v, %v", keyData, err)
+	}
+	parsed, err := crypto.ParseKeyFile([]byte("key file contents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ChangeMasterPassword(database.Credentials{Password: "pw", KeyFile: parsed}); err != nil {
+		t.Fatal(err)
+	}
+	store.SetMasterKey(nil)
+
+	t.Setenv(keyFileEnv, "")
+	if err := unlockVault(store); err == nil || !strings.Contains(err.Error(), keyFileEnv) {
+		t.Errorf("unlockVault() without key file = %v", err)
+	}
+	t.Setenv(keyFileEnv, keyFile)
+	if err := unlockVault(store); err != nil {
+		t.Errorf("unlockVault() with key file = %v", err)
+	}
+
+	if err := unlockVault(memstore.New()); err == nil {
+		t.Error("unlockVault() without a master password succeeded")
+	}
+}
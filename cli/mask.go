@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+// 替换输出中密钥的掩码
+var secretMask = []byte("******")
+
+// maskWriter 在写入前将输出中出现的密钥替换为掩码
+// 密钥可能被拆分在多次写入之间，因此会暂存可能是密钥前缀的尾部数据
+type maskWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets [][]byte
+	pending []byte
+}
+
+// newMaskWriter 创建掩码输出，空值会被忽略
+func newMaskWriter(w io.Writer, secrets []string) *maskWriter {
+	m := &maskWriter{w: w}
+	for _, secret := range secrets {
+		if secret != "" {
+			m.secrets = append(m.secrets, []byte(secret))
+		}
+	}
+
+	// 同一位置优先匹配较长的密钥
+	sort.Slice(m.secrets, func(i, j int) bool {
+		return len(m.secrets[i]) > len(m.secrets[j])
+	})
+	return m
+}
+
+// Write 实现 io.Writer
+func (m *maskWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending = append(m.pending, p...)
+	for {
+		idx, secret := m.nextSecret()
+		if idx < 0 {
+			break
+		}
+		if _, err := m.w.Write(m.pending[:idx]); err != nil {
+			return 0, err
+		}
+		if _, err := m.w.Write(secretMask); err != nil {
+			return 0, err
+		}
+		m.pending = m.pending[idx+len(secret):]
+	}
+
+	// 保留可能是密钥开头的尾部，其余部分直接输出
+	keep := m.partialSuffix()
+	flushLen := len(m.pending) - keep
+	if flushLen > 0 {
+		if _, err := m.w.Write(m.pending[:flushLen]); err != nil {
+			return 0, err
+		}
+	}
+	m.pending = append([]byte{}, m.pending[flushLen:]...)
+
+	return len(p), nil
+}
+
+// Flush 输出暂存的剩余数据
+func (m *maskWriter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.pending) == 0 {
+		return nil
+	}
+	_, err := m.w.Write(m.pending)
+	m.pending = nil
+	return err
+}
+
+// nextSecret 返回暂存数据中最早出现的密钥位置
+func (m *maskWriter) nextSecret() (int, []byte) {
+	first, match := -1, []byte(nil)
+	for _, secret := range m.secrets {
+		idx := bytes.Index(m.pending, secret)
+		if idx >= 0 && (first < 0 || idx < first) {
+			first, match = idx, secret
+		}
+	}
+	return first, match
+}
+
+// partialSuffix 返回暂存数据末尾与某个密钥前缀相同的最长长度
+func (m *maskWriter) partialSuffix() int {
+	longest := 0
+	for _, secret := range m.secrets {
+		n := len(secret) - 1
+		if n > len(m.pending) {
+			n = len(m.pending)
+		}
+		for ; n > longest; n-- {
+			if bytes.HasSuffix(m.pending, secret[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
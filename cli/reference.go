@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// 条目引用的前缀，格式为 entry:[<文件夹>/]<标题>/<字段>
+const referencePrefix = "entry:"
+
+// resolveReference 解析形如 entry:prod-db/password 或 entry:work/aws/prod/password 的引用，并在审计日志中记录由哪个命令读取
+func resolveReference(db database.VaultStore, entries []*models.PasswordEntry, ref, command string) (string, error) {
+	if !strings.HasPrefix(ref, referencePrefix) {
+		return "", fmt.Errorf("无效的引用 %q，应为 entry:[<文件夹>/]<标题>/<字段>", ref)
+	}
+
+	// 标题中可能包含 /，以最后一个 / 分隔字段
+	path := strings.TrimPrefix(ref, referencePrefix)
+	idx := strings.LastIndex(path, "/")
+	if idx <= 0 || idx == len(path)-1 {
+		return "", fmt.Errorf("无效的引用 %q，应为 entry:[<文件夹>/]<标题>/<字段>", ref)
+	}
+
+	entry, err := findEntry(entries, path[:idx])
+	if err != nil {
+		return "", err
+	}
+	value, err := entryField(entry, path[idx+1:])
+	if err != nil {
+		return "", err
+	}
+	return value, auditRead(db, entry, command+" "+path[idx+1:])
+}
+
+// auditRead 在审计日志中记录命令读取了条目，并更新条目的使用统计
+func auditRead(db database.VaultStore, entry *models.PasswordEntry, detail string) error {
+	if err := db.AppendAudit(&models.AuditRecord{
+		Action:  models.AuditView,
+		EntryID: entry.ID,
+		Subject: entry.Title,
+		Detail:  detail,
+	}); err != nil {
+		return err
+	}
+	return db.RecordEntryUse(entry.ID)
+}
+
+// findEntry 按标题查找唯一的条目，没有该标题的条目时按 <文件夹>/<标题> 路径查找，如 work/aws/prod
+func findEntry(entries []*models.PasswordEntry, name string) (*models.PasswordEntry, error) {
+	var found *models.PasswordEntry
+	for _, entry := range entries {
+		if entry.Title != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("存在多个标题为 %q 的条目", name)
+		}
+		found = entry
+	}
+	if found != nil {
+		return found, nil
+	}
+
+	// 文件夹和标题中都可能包含 /，依次尝试每个分隔位置
+	for i := 0; i < len(name); i++ {
+		if name[i] != '/' {
+			continue
+		}
+		folder := database.NormalizeCategoryPath(name[:i])
+		for _, entry := range entries {
+			if entry.Category != folder || entry.Title != name[i+1:] {
+				continue
+			}
+			if found != nil {
+				return nil, fmt.Errorf("存在多个路径为 %q 的条目", name)
+			}
+			found = entry
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("未找到标题或路径为 %q 的条目", name)
+	}
+	return found, nil
+}
+
+// entryField 获取条目的指定字段
+func entryField(entry *models.PasswordEntry, field string) (string, error) {
+	switch strings.ToLower(field) {
+	case "password":
+		return entry.Password, nil
+	case "username":
+		return entry.Username, nil
+	case "url":
+		return entry.URL, nil
+	case "notes":
+		return entry.Notes, nil
+	case "title":
+		return entry.Title, nil
+	case "category":
+		return entry.Category, nil
+	default:
+		return "", fmt.Errorf("未知字段 %q，可用字段: password, username, url, notes, title, category", field)
+	}
+}
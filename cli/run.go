@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// envFlags 可重复指定的 --env 参数
+type envFlags []string
+
+func (e *envFlags) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *envFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("无效的 --env 参数 %q，应为 NAME=entry:[<文件夹>/]<标题>/<字段>", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
+// exitCodeError 子进程的退出码，用于原样传递给调用方
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// secretEnvs 用于解锁密码库和同步的环境变量，不传给子进程
+var secretEnvs = []string{masterPasswordEnv, keyFileEnv, factorTokenEnv, webdavPasswordEnv}
+
+// childEnv 返回去掉 secretEnvs 后的环境变量
+func childEnv(environ []string) []string {
+	var env []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		secret := false
+		for _, s := range secretEnvs {
+			if name == s {
+				secret = true
+				break
+			}
+		}
+		if !secret {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// runRun 解析条目引用，注入到子进程环境变量后运行命令
+// 用法：password_tool run --env DB_PASS=entry:prod-db/password -- ./server
+func runRun(args []string) error {
+	var envs envFlags
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.Var(&envs, "env", "注入的环境变量，格式 NAME=entry:[<文件夹>/]<标题>/<字段>，可重复")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("用法: password_tool run --env NAME=entry:[<文件夹>/]<标题>/<字段> -- 命令 [参数...]")
+	}
+
+	injected, secrets, err := resolveEnv(envs)
+	if err != nil {
+		return err
+	}
+
+	stdout := newMaskWriter(os.Stdout, secrets)
+	stderr := newMaskWriter(os.Stderr, secrets)
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	cmd := exec.Command(flags.Arg(0), flags.Args()[1:]...)
+	cmd.Env = append(childEnv(os.Environ()), injected...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// 终端的 Ctrl+C 会同时发送给子进程，这里只需忽略；SIGTERM 转发给子进程
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGTERM {
+				cmd.Process.Signal(sig)
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			// 子进程被信号终止
+			code = 1
+		}
+		return &exitCodeError{code: code}
+	}
+	return err
+}
+
+// resolveEnv 打开密码库解析所有引用，返回环境变量和需要掩码的值
+// 解析完成后立即关闭数据库，子进程运行期间不持有主密钥
+func resolveEnv(envs []string) ([]string, []string, error) {
+	if len(envs) == 0 {
+		return nil, nil, nil
+	}
+
+	db, err := openVault()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	entries, err := db.GetPasswordEntries()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var injected, secrets []string
+	for _, env := range envs {
+		name, ref, _ := strings.Cut(env, "=")
+		value, err := resolveReference(db, entries, ref, "run")
+		if err != nil {
+			return nil, nil, err
+		}
+		injected = append(injected, name+"="+value)
+		secrets = append(secrets, value)
+	}
+
+	return injected, secrets, nil
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMaskWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		writes  []string
+		want    string
+	}{
+		{"no secrets", nil, []string{"hello"}, "hello"},
+		{"single write", []string{"s3cret"}, []string{"pw=s3cret\n"}, "pw=******\n"},
+		{"repeated", []string{"ab"}, []string{"ab-ab-ab"}, "******-******-******"},
+		{"split across writes", []string{"s3cret"}, []string{"pw=s3", "cr", "et!"}, "pw=******!"},
+		{"byte by byte", []string{"xyz"}, strings.Split("1xyz2", ""), "1******2"},
+		{"longest first", []string{"abc", "abcdef"}, []string{"abcdef abc"}, "****** ******"},
+		{"prefix not secret", []string{"secret"}, []string{"sec", "ond"}, "second"},
+		{"pending flushed", []string{"secret"}, []string{"end sec"}, "end sec"},
+		{"empty secret ignored", []string{""}, []string{"text"}, "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			m := newMaskWriter(&out, tt.secrets)
+			for _, w := range tt.writes {
+				n, err := m.Write([]byte(w))
+				if err != nil || n != len(w) {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if err := m.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChildEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		masterPasswordEnv + "=pw",
+		keyFileEnv + "=/tmp/key",
+		factorTokenEnv + "=/tmp/token",
+		webdavPasswordEnv + "=dav",
+		"PASSWORD_TOOL_OTHER=kept",
+	}
+	got := strings.Join(childEnv(environ), " ")
+	if want := "PATH=/usr/bin PASSWORD_TOOL_OTHER=kept"; got != want {
+		t.Errorf("childEnv = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"hank.com/password_tool/storage"
+	"hank.com/password_tool/syncserver"
+	"hank.com/password_tool/vaultsync"
+)
+
+// webdavPasswordEnv WebDAV 密码的环境变量
+const webdavPasswordEnv = "PASSWORD_TOOL_WEBDAV_PASSWORD"
+
+// runServe 启动零知识同步服务器，未指定邀请码时只允许已注册的用户同步
+// 用法：password_tool serve --addr 127.0.0.1:8787 --data ~/.password_tool/server [--invite 邀请码]
+func runServe(args []string) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:8787", "监听地址")
+	dataDir := flags.String("data", filepath.Join(homeDir, ".password_tool", "server"), "服务器数据目录")
+	invite := flags.String("invite", "", "注册新用户所需的邀请码，为空时不接受注册")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	server, err := syncserver.Open(*dataDir)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+	server.SetInvite(*invite)
+
+	log.Printf("同步服务器监听 %s，数据目录 %s", *addr, *dataDir)
+	if *invite == "" {
+		log.Printf("未设置邀请码，不接受新用户注册")
+	}
+	return server.ListenAndServe(*addr)
+}
+
+// runSync 与共享文件夹、同步服务器或 WebDAV 同步一次，未指定参数时使用已保存的设置
+// 用法：password_tool sync [--folder 目录] [--server 地址 --user 用户名 [--invite 邀请码]] [--webdav 地址 [--webdav-user 用户名]] [--backup]
+// WebDAV 密码从环境变量 PASSWORD_TOOL_WEBDAV_PASSWORD 读取
+func runSync(args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	folder := flags.String("folder", "", "共享同步文件夹")
+	serverURL := flags.String("server", "", "同步服务器地址，如 http://127.0.0.1:8787")
+	user := flags.String("user", "", "同步服务器用户名")
+	invite := flags.String("invite", "", "首次注册同步服务器时使用的邀请码")
+	webdavURL := flags.String("webdav", "", "WebDAV 目录地址，如 https://nas.local/dav/password_tool")
+	webdavUser := flags.String("webdav-user", "", "WebDAV 用户名")
+	backup := flags.Bool("backup", false, "同步后将加密的密码库备份到 WebDAV")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if (*serverURL == "") != (*user == "") {
+		return fmt.Errorf("--server 和 --user 需要同时指定")
+	}
+
+	db, err := openVault()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var remotes []vaultsync.Remote
+	if *folder != "" {
+		remotes = append(remotes, vaultsync.NewFolder(*folder))
+	}
+	if *serverURL != "" {
+		server, err := vaultsync.NewServerRemote(*serverURL, *user, *invite, db.GetKey())
+		if err != nil {
+			return err
+		}
+		remotes = append(remotes, server)
+	}
+	var webdav *storage.WebDAV
+	if *webdavURL != "" {
+		webdav, err = storage.NewWebDAV(*webdavURL, *webdavUser, os.Getenv(webdavPasswordEnv))
+		if err != nil {
+			return err
+		}
+		remotes = append(remotes, vaultsync.NewBackendRemote(webdav))
+	}
+	if len(remotes) == 0 {
+		if remotes, err = vaultsync.ConfiguredRemotes(db); err != nil {
+			return err
+		}
+	}
+	if len(remotes) == 0 {
+		return fmt.Errorf("未配置同步位置，请使用 --folder、--server/--user 或 --webdav 指定")
+	}
+
+	result, err := vaultsync.SyncAll(db, remotes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("同步完成：上传 %d 条变更，合并 %d 条变更，冲突 %d 个\n", result.Pushed, result.Applied, result.Conflicts)
+	if result.Undecryptable > 0 {
+		fmt.Printf("%d 条记录无法解密，来自使用其他密码库密钥的设备，需要在设备间重新复制 passwords.db\n", result.Undecryptable)
+	}
+
+	if *backup {
+		if webdav == nil {
+			if webdav, err = vaultsync.ConfiguredWebDAV(db); err != nil {
+				return err
+			}
+		}
+		if webdav == nil {
+			return fmt.Errorf("未配置 WebDAV，无法备份")
+		}
+		if err := vaultsync.UploadBackup(db, webdav); err != nil {
+			return err
+		}
+		fmt.Println("已备份密码库到 WebDAV")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+)
+
+// DeriveAuditKey 从密码库密钥派生审计日志的认证密钥
+func DeriveAuditKey(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("password_tool audit log v1"))
+	return mac.Sum(nil)
+}
+
+// AuditMAC 计算审计日志记录的链式认证码，覆盖上一条记录的认证码、序号和记录内容
+func AuditMAC(auditKey []byte, previous string, seq int, record string) string {
+	mac := hmac.New(sha256.New, auditKey)
+	mac.Write([]byte(previous))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.Itoa(seq)))
+	mac.Write([]byte{0})
+	mac.Write([]byte(record))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
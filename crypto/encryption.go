@@ -3,12 +3,14 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"io"
 
+	"golang.org/x/crypto/hkdf"
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -93,4 +95,46 @@ func Decrypt(ciphertext string, key []byte) ([]byte, error) {
 func VerifyMasterPassword(inputPassword, storedHash string, salt []byte) bool {
 	inputHash := HashMasterPassword(inputPassword, salt)
 	return inputHash == storedHash
+}
+
+// DeriveSyncToken 为指定的同步服务器和用户派生认证令牌
+// 令牌经过与加密相互独立的 HKDF 路径，并绑定服务器地址和用户名，一台服务器无法在其他服务器上重放令牌
+func DeriveSyncToken(key []byte, server, user string) (string, error) {
+	token := make([]byte, 32)
+	kdf := hkdf.New(sha256.New, key, []byte(server+"\x00"+user), []byte("password_tool sync auth v2"))
+	if _, err := io.ReadFull(kdf, token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// RenderedFileMAC 计算模板渲染生成的文件的认证码
+// 文件包含密钥，使用由密码库密钥派生的 HMAC 而不是普通哈希，数据库泄露时无法用于猜测文件内容
+func RenderedFileMAC(key, content []byte) string {
+	fileKey := hmac.New(sha256.New, key)
+	fileKey.Write([]byte("password_tool rendered file v1"))
+	mac := hmac.New(sha256.New, fileKey.Sum(nil))
+	mac.Write(content)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateKey 生成随机的密码库密钥
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	_, err := rand.Read(key)
+	return key, err
+}
+
+// DeriveWrappingKey 从主密码派生用于加密密码库密钥的密钥
+// 与 HashMasterPassword 使用不同的盐值，数据库中保存的哈希无法推出该密钥
+func DeriveWrappingKey(masterPassword string, salt []byte) []byte {
+	wrapSalt := sha256.Sum256(append([]byte("password_tool key wrap v1"), salt...))
+	return pbkdf2.Key([]byte(masterPassword), wrapSalt[:], 100000, keySize, sha256.New)
+}
+
+// KeyCheck 返回密钥的校验值，用于确认恢复出的密钥是否正确，不泄露密钥本身
+func KeyCheck(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("password_tool key check v1"))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
\ No newline at end of file
@@ -0,0 +1,109 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestGenerateKeyFile(t *testing.T) {
+	data, err := GenerateKeyFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKeyFile(data)
+	if err != nil || len(key) != keySize {
+		t.Fatalf("ParseKeyFile() = %x, %v", key, err)
+	}
+	if again, err := ParseKeyFile(data); err != nil || !bytes.Equal(again, key) {
+		t.Error("parsing the same key file gave a different key")
+	}
+	other, err := GenerateKeyFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherKey, _ := ParseKeyFile(other); bytes.Equal(otherKey, key) {
+		t.Error("two generated key files have the same key")
+	}
+
+	// 修改密钥数据后校验值不符
+	text := string(data)
+	start := strings.Index(text, "\t\t\t") + 3
+	digit := "0"
+	if text[start] == '0' {
+		digit = "1"
+	}
+	tampered := text[:start] + digit + text[start+1:]
+	if _, err := ParseKeyFile([]byte(tampered)); err == nil {
+		t.Error("ParseKeyFile() of a tampered key file succeeded")
+	}
+}
+
+func TestParseKeyFileFormats(t *testing.T) {
+	raw := bytes.Repeat([]byte{0xab}, keySize)
+	hexKey := hex.EncodeToString(raw)
+	hashed := func(data string) []byte {
+		sum := sha256.Sum256([]byte(data))
+		return sum[:]
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []byte
+	}{
+		{"raw 32 bytes", string(raw), raw},
+		{"hex", hexKey, raw},
+		{"other file", "any file", hashed("any file")},
+		{"64 non-hex characters", strings.Repeat("z", 64), hashed(strings.Repeat("z", 64))},
+		{"xml 1.0", "<?xml version=\"1.0\"?><KeyFile><Meta><Version>1.00</Version></Meta><Key><Data>" +
+			base64.StdEncoding.EncodeToString(raw) + "</Data></Key></KeyFile>", raw},
+		{"xml 2.0 with BOM", "\xef\xbb\xbf<KeyFile><Meta><Version>2.0</Version></Meta><Key><Data>\n" +
+			strings.ToUpper(hexKey[:32]) + "\n " + hexKey[32:] + "</Data></Key></KeyFile>", raw},
+		{"html is hashed", "<html></html>", hashed("<html></html>")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyFile([]byte(tt.data))
+			if err != nil || !bytes.Equal(got, tt.want) {
+				t.Errorf("ParseKeyFile() = %x, %v, want %x", got, err, tt.want)
+			}
+		})
+	}
+
+	for name, data := range map[string]string{
+		"empty":           "",
+		"unknown version": "<KeyFile><Meta><Version>3.0</Version></Meta><Key><Data>00</Data></Key></KeyFile>",
+		"bad hex":         "<KeyFile><Meta><Version>2.0</Version></Meta><Key><Data>xyz</Data></Key></KeyFile>",
+		"wrong hash":      "<KeyFile><Meta><Version>2.0</Version></Meta><Key><Data Hash=\"00000000\">" + hexKey + "</Data></Key></KeyFile>",
+	} {
+		if _, err := ParseKeyFile([]byte(data)); err == nil {
+			t.Errorf("ParseKeyFile(%s) succeeded", name)
+		}
+	}
+}
+
+func TestCompositeKey(t *testing.T) {
+	if got := CompositeKey("pw", nil, nil); got != "pw" {
+		t.Errorf("CompositeKey() with only a password = %q", got)
+	}
+
+	keyFile := bytes.Repeat([]byte{1}, keySize)
+	withKeyFile := CompositeKey("pw", keyFile, nil)
+	if withKeyFile == "pw" || withKeyFile != CompositeKey("pw", keyFile, nil) {
+		t.Errorf("CompositeKey() with a key file = %q", withKeyFile)
+	}
+	// 每个因素都会改变结果
+	for name, got := range map[string]string{
+		"password":        CompositeKey("other", keyFile, nil),
+		"key file":        CompositeKey("pw", bytes.Repeat([]byte{2}, keySize), nil),
+		"factor response": CompositeKey("pw", keyFile, []byte("response")),
+	} {
+		if got == withKeyFile {
+			t.Errorf("changing the %s did not change the composite key", name)
+		}
+	}
+}
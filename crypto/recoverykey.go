@@ -0,0 +1,51 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+)
+
+// 恢复密钥的随机字节数及显示时每组的字符数
+const (
+	recoveryKeySize  = 20
+	recoveryKeyGroup = 4
+)
+
+// GenerateRecoveryKey 生成随机恢复密钥，以 Base32 分组显示，如 ABCD-EFGH-...
+func GenerateRecoveryKey() (string, error) {
+	raw := make([]byte, recoveryKeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	var groups []string
+	for i := 0; i < len(encoded); i += recoveryKeyGroup {
+		end := i + recoveryKeyGroup
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		groups = append(groups, encoded[i:end])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// DeriveRecoveryWrappingKey 从恢复密钥派生用于加密密码库密钥的密钥
+// 恢复密钥本身是高熵随机数，无需 PBKDF2 等慢速派生
+func DeriveRecoveryWrappingKey(recoveryKey string) ([]byte, error) {
+	normalized := strings.ToUpper(recoveryKey)
+	normalized = strings.NewReplacer("-", "", " ", "", "\t", "", "\n", "").Replace(normalized)
+
+	raw, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil || len(raw) != recoveryKeySize {
+		return nil, errors.New("invalid recovery key format")
+	}
+
+	mac := hmac.New(sha256.New, raw)
+	mac.Write([]byte("password_tool recovery key v1"))
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,145 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// GF(256) 的指数表和对数表，使用 AES 的既约多项式 x^8+x^4+x^3+x+1，生成元为 3
+var (
+	gfExp [510]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		x = gfMulNoTable(x, 3)
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+// gfMulNoTable 不依赖查表的乘法，仅用于生成表
+func gfMulNoTable(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// gfMul GF(256) 乘法
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// gfDiv GF(256) 除法，b 不能为 0
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// Share 秘密的一个份额，X 为 1~255 的横坐标，Y 与秘密等长
+type Share struct {
+	X         byte
+	Threshold byte
+	Y         []byte
+}
+
+// SplitSecret 使用 Shamir 门限方案将秘密拆分为 n 个份额，任意 k 个份额可以恢复秘密
+func SplitSecret(secret []byte, n, k int) ([]Share, error) {
+	if k < 2 || k > n || n > 255 {
+		return nil, fmt.Errorf("invalid threshold %d of %d", k, n)
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{X: byte(i + 1), Threshold: byte(k), Y: make([]byte, len(secret))}
+	}
+
+	// 每个字节使用一个独立的 k-1 次随机多项式，常数项为秘密字节
+	coefficients := make([]byte, k)
+	for b, s := range secret {
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+		coefficients[0] = s
+
+		for i := range shares {
+			// Horner 法求多项式的值
+			var y byte
+			for c := k - 1; c >= 0; c-- {
+				y = gfMul(y, shares[i].X) ^ coefficients[c]
+			}
+			shares[i].Y[b] = y
+		}
+	}
+
+	for i := range coefficients {
+		coefficients[i] = 0
+	}
+	return shares, nil
+}
+
+// CombineShares 使用拉格朗日插值从至少 k 个份额恢复秘密
+func CombineShares(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares")
+	}
+	threshold := int(shares[0].Threshold)
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("need %d shares, got %d", threshold, len(shares))
+	}
+
+	size := len(shares[0].Y)
+	seen := map[byte]bool{}
+	for _, share := range shares {
+		if share.X == 0 || len(share.Y) != size || int(share.Threshold) != threshold {
+			return nil, errors.New("shares do not belong to the same secret")
+		}
+		if seen[share.X] {
+			return nil, fmt.Errorf("duplicate share %d", share.X)
+		}
+		seen[share.X] = true
+	}
+
+	// 只需要 k 个份额
+	shares = shares[:threshold]
+
+	secret := make([]byte, size)
+	for i, si := range shares {
+		// 拉格朗日基函数在 x=0 处的值，GF(256) 中减法即异或
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = gfMul(basis, gfDiv(sj.X, sj.X^si.X))
+		}
+		for b := range secret {
+			secret[b] ^= gfMul(basis, si.Y[b])
+		}
+	}
+	return secret, nil
+}
@@ -0,0 +1,98 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGFTables(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			if got, want := gfMul(byte(a), byte(b)), gfMulNoTable(byte(a), byte(b)); got != want {
+				t.Fatalf("gfMul(%d, %d) = %d, want %d", a, b, got, want)
+			}
+			if b != 0 && gfDiv(gfMul(byte(a), byte(b)), byte(b)) != byte(a) {
+				t.Fatalf("gfDiv(gfMul(%d, %d), %d) != %d", a, b, b, a)
+			}
+		}
+	}
+}
+
+func TestSplitCombineEverySubset(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	const n, k = 5, 3
+	shares, err := SplitSecret(secret, n, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, share := range shares {
+		if bytes.Equal(share.Y, secret) {
+			t.Fatalf("share %d equals the secret", share.X)
+		}
+	}
+
+	// 任意 k 个及以上的份额（按任意顺序）都能恢复秘密
+	for mask := 0; mask < 1<<n; mask++ {
+		var subset []Share
+		for i := n - 1; i >= 0; i-- {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, shares[i])
+			}
+		}
+		got, err := CombineShares(subset)
+		if len(subset) < k {
+			if err == nil {
+				t.Errorf("CombineShares() with %d shares succeeded", len(subset))
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(got, secret) {
+			t.Errorf("CombineShares(mask %05b) = %x, %v", mask, got, err)
+		}
+	}
+}
+
+func TestSplitSecretRandomized(t *testing.T) {
+	secret := []byte{0, 1, 2, 255}
+	a, err := SplitSecret(secret, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SplitSecret(secret, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a[0].Y, b[0].Y) && bytes.Equal(a[1].Y, b[1].Y) {
+		t.Error("two splits produced identical shares")
+	}
+}
+
+func TestSplitCombineErrors(t *testing.T) {
+	for _, tt := range []struct{ n, k int }{{3, 1}, {2, 3}, {256, 2}} {
+		if _, err := SplitSecret([]byte("s"), tt.n, tt.k); err == nil {
+			t.Errorf("SplitSecret(n=%d, k=%d) succeeded", tt.n, tt.k)
+		}
+	}
+	if _, err := SplitSecret(nil, 3, 2); err == nil {
+		t.Error("SplitSecret() of an empty secret succeeded")
+	}
+
+	shares, err := SplitSecret([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := SplitSecret([]byte("longer secret"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, subset := range map[string][]Share{
+		"none":       nil,
+		"duplicate":  {shares[0], shares[0]},
+		"mismatched": {shares[0], other[1]},
+		"zero x":     {{X: 0, Threshold: 2, Y: shares[0].Y}, shares[1]},
+	} {
+		if _, err := CombineShares(subset); err == nil {
+			t.Errorf("CombineShares(%s) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 份额编码为拼音风格的音节，每个字节对应一个音节：高 4 位选声母，低 4 位选韵母
+// 每个音节都以声母开头、韵母以元音开头，因此连写时可以唯一地切分
+var (
+	shareInitials = []string{"b", "d", "f", "g", "h", "j", "k", "l", "m", "n", "p", "r", "s", "t", "w", "z"}
+	shareFinals   = []string{"a", "e", "i", "o", "u", "ai", "ao", "an", "ei", "en", "in", "ou", "un", "ia", "ie", "uo"}
+)
+
+// 每个单词包含的音节数
+const syllablesPerWord = 2
+
+// 份额末尾的校验和长度，用于发现抄写错误
+const shareChecksumSize = 2
+
+// EncodeShareWords 将份额编码为便于抄写的单词
+func EncodeShareWords(share Share) string {
+	payload := append([]byte{share.X, share.Threshold}, share.Y...)
+	sum := sha256.Sum256(payload)
+	payload = append(payload, sum[:shareChecksumSize]...)
+
+	var words []string
+	var word strings.Builder
+	for i, b := range payload {
+		word.WriteString(shareInitials[b>>4])
+		word.WriteString(shareFinals[b&0x0f])
+		if (i+1)%syllablesPerWord == 0 || i == len(payload)-1 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// DecodeShareWords 解析 EncodeShareWords 生成的单词，忽略大小写和多余的空白
+func DecodeShareWords(text string) (Share, error) {
+	var payload []byte
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		decoded, err := decodeShareWord(word)
+		if err != nil {
+			return Share{}, err
+		}
+		payload = append(payload, decoded...)
+	}
+
+	if len(payload) < 2+1+shareChecksumSize {
+		return Share{}, errors.New("share is too short")
+	}
+
+	body := payload[:len(payload)-shareChecksumSize]
+	sum := sha256.Sum256(body)
+	if !bytes.Equal(sum[:shareChecksumSize], payload[len(body):]) {
+		return Share{}, errors.New("share checksum mismatch, please check the words")
+	}
+	if body[0] == 0 || body[1] < 2 {
+		return Share{}, errors.New("invalid share")
+	}
+
+	return Share{X: body[0], Threshold: body[1], Y: body[2:]}, nil
+}
+
+// decodeShareWord 将一个单词切分为音节并还原字节
+func decodeShareWord(word string) ([]byte, error) {
+	var decoded []byte
+	for pos := 0; pos < len(word); {
+		high := indexOf(shareInitials, word[pos:pos+1])
+		if high < 0 {
+			return nil, fmt.Errorf("invalid word %q", word)
+		}
+		pos++
+
+		// 选择能让剩余部分以“声母+元音”开头（或恰好结束）的最长韵母
+		low := -1
+		length := 0
+		for i, final := range shareFinals {
+			if !strings.HasPrefix(word[pos:], final) || len(final) <= length {
+				continue
+			}
+			rest := word[pos+len(final):]
+			if rest == "" || (len(rest) >= 2 && indexOf(shareInitials, rest[:1]) >= 0 && strings.ContainsRune("aeiou", rune(rest[1]))) {
+				low = i
+				length = len(final)
+			}
+		}
+		if low < 0 {
+			return nil, fmt.Errorf("invalid word %q", word)
+		}
+		pos += length
+
+		decoded = append(decoded, byte(high<<4|low))
+	}
+	return decoded, nil
+}
+
+// indexOf 返回字符串在列表中的位置，不存在时返回 -1
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
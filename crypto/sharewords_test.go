@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestShareWordsRoundTrip(t *testing.T) {
+	// 覆盖所有字节值，确保每个音节都能唯一切分
+	y := make([]byte, 256)
+	for i := range y {
+		y[i] = byte(i)
+	}
+	share := Share{X: 7, Threshold: 3, Y: y}
+
+	words := EncodeShareWords(share)
+	got, err := DecodeShareWords(words)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.X != share.X || got.Threshold != share.Threshold || !bytes.Equal(got.Y, share.Y) {
+		t.Fatalf("DecodeShareWords() = %+v", got)
+	}
+
+	// 忽略大小写和多余的空白
+	messy := "  " + strings.ToUpper(strings.ReplaceAll(words, " ", " \n\t ")) + "\n"
+	if got, err := DecodeShareWords(messy); err != nil || !bytes.Equal(got.Y, share.Y) {
+		t.Errorf("DecodeShareWords(messy) = %v", err)
+	}
+}
+
+func TestShareWordsSyllablesSplitUniquely(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			word := shareInitials[a>>4] + shareFinals[a&0x0f] + shareInitials[b>>4] + shareFinals[b&0x0f]
+			got, err := decodeShareWord(word)
+			if err != nil || len(got) != 2 || got[0] != byte(a) || got[1] != byte(b) {
+				t.Fatalf("decodeShareWord(%q) = %v, %v, want [%d %d]", word, got, err, a, b)
+			}
+		}
+	}
+}
+
+func TestShareWordsDetectTypos(t *testing.T) {
+	// 使用固定的份额，校验和只有 2 字节，随机份额偶尔会碰巧通过校验
+	share := Share{X: 1, Threshold: 2, Y: []byte("0123456789abcdef0123456789abcdef")}
+	words := strings.Fields(EncodeShareWords(share))
+
+	// 替换任意一个单词都会被发现
+	for i := range words {
+		changed := append([]string(nil), words...)
+		if changed[i] == "baba" {
+			changed[i] = "babe"
+		} else {
+			changed[i] = "baba"
+		}
+		if _, err := DecodeShareWords(strings.Join(changed, " ")); err == nil {
+			t.Errorf("changing word %d was not detected", i)
+		}
+	}
+	// 漏抄或多抄单词
+	if _, err := DecodeShareWords(strings.Join(words[:len(words)-1], " ")); err == nil {
+		t.Error("missing word was not detected")
+	}
+	if _, err := DecodeShareWords(strings.Join(append(words, "baba"), " ")); err == nil {
+		t.Error("extra word was not detected")
+	}
+
+	for _, text := range []string{"", "baba", "xyz", "bax baba baba"} {
+		if _, err := DecodeShareWords(text); err == nil {
+			t.Errorf("DecodeShareWords(%q) succeeded", text)
+		}
+	}
+}
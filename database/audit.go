@@ -0,0 +1,226 @@
+package database
+
+import (
+	"crypto/hmac"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/models"
+)
+
+// 审计日志只追加不修改：每条记录加密保存，并带有覆盖上一条记录认证码的链式认证码，
+// 链尾的序号和认证码另外保存在设置中，删除中间或末尾的记录、修改记录内容都会导致校验失败。
+
+// auditHeadSetting 审计日志链尾，格式为 "序号:认证码:链尾认证码"
+const auditHeadSetting = "audit_head"
+
+// AuditFilter 审计日志的筛选条件，为空的字段不参与筛选
+type AuditFilter struct {
+	Action string
+	Client string
+	Text   string // 匹配条目标题或详情，不区分大小写
+}
+
+// Match 检查记录是否满足筛选条件
+func (f AuditFilter) Match(record *models.AuditRecord) bool {
+	if f.Action != "" && record.Action != f.Action {
+		return false
+	}
+	if f.Client != "" && record.Client != f.Client {
+		return false
+	}
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		if !strings.Contains(strings.ToLower(record.Subject), text) && !strings.Contains(strings.ToLower(record.Detail), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetAuditClient 设置写入审计日志时记录的客户端
+func (db *DB) SetAuditClient(client string) {
+	db.auditClient = client
+}
+
+// AppendAudit 追加一条审计日志，未指定客户端时使用 SetAuditClient 设置的客户端
+func (db *DB) AppendAudit(record *models.AuditRecord) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := db.appendAudit(tx, record); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appendAudit 在事务中追加审计日志，与数据修改在同一事务中提交，修改和日志要么都写入要么都不写入
+// 连接使用 BEGIN IMMEDIATE 开始事务，读取最后一条记录时已持有写锁，并发追加不会使用相同的序号
+func (db *DB) appendAudit(tx *sql.Tx, record *models.AuditRecord) error {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return fmt.Errorf("master key not set")
+	}
+	if record.Client == "" {
+		record.Client = db.auditClient
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	encrypted, err := crypto.Encrypt(data, vaultKey)
+	if err != nil {
+		return err
+	}
+
+	// 接在最后一条记录之后，而不是设置中的链尾，链尾被篡改时校验仍能发现
+	var lastID int
+	var lastMAC string
+	err = tx.QueryRow("SELECT id, mac FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&lastID, &lastMAC)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	auditKey := crypto.DeriveAuditKey(vaultKey)
+	id := lastID + 1
+	mac := crypto.AuditMAC(auditKey, lastMAC, id, encrypted)
+	if _, err := tx.Exec("INSERT INTO audit_log (id, record, mac) VALUES (?, ?, ?)", id, encrypted, mac); err != nil {
+		return err
+	}
+
+	head := fmt.Sprintf("%d:%s:%s", id, mac, crypto.AuditMAC(auditKey, mac, id, "head"))
+	if _, err := tx.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", auditHeadSetting, head); err != nil {
+		return err
+	}
+
+	record.ID = id
+	return nil
+}
+
+// audit 在修改条目的事务中记录对条目的操作
+func (db *DB) audit(tx *sql.Tx, action string, entryID int, subject string) error {
+	return db.appendAudit(tx, &models.AuditRecord{Action: action, EntryID: entryID, Subject: subject})
+}
+
+// GetAuditLog 返回满足筛选条件的审计日志，最新的在前
+func (db *DB) GetAuditLog(filter AuditFilter) ([]*models.AuditRecord, error) {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return nil, fmt.Errorf("master key not set")
+	}
+
+	rows, err := db.conn.Query("SELECT id, record FROM audit_log ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*models.AuditRecord
+	for rows.Next() {
+		var id int
+		var encrypted string
+		if err := rows.Scan(&id, &encrypted); err != nil {
+			return nil, err
+		}
+
+		record, err := db.decryptAuditRecord(encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("audit record %d: %v", id, err)
+		}
+		record.ID = id
+		if filter.Match(record) {
+			records = append(records, record)
+		}
+	}
+	return records, rows.Err()
+}
+
+// decryptAuditRecord 解密审计日志记录
+func (db *DB) decryptAuditRecord(encrypted string) (*models.AuditRecord, error) {
+	vaultKey := db.currentKey()
+	data, err := crypto.Decrypt(encrypted, vaultKey)
+	if err != nil {
+		return nil, err
+	}
+	record := &models.AuditRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// VerifyAuditLog 校验审计日志的完整性，返回发现的第一处篡改
+func (db *DB) VerifyAuditLog() error {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return fmt.Errorf("master key not set")
+	}
+
+	rows, err := db.conn.Query("SELECT id, record, mac FROM audit_log ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	auditKey := crypto.DeriveAuditKey(vaultKey)
+	lastID, lastMAC := 0, ""
+	for rows.Next() {
+		var id int
+		var encrypted, mac string
+		if err := rows.Scan(&id, &encrypted, &mac); err != nil {
+			return err
+		}
+		if id != lastID+1 {
+			return fmt.Errorf("audit records %d to %d are missing", lastID+1, id-1)
+		}
+		if !hmac.Equal([]byte(mac), []byte(crypto.AuditMAC(auditKey, lastMAC, id, encrypted))) {
+			return fmt.Errorf("audit record %d has been modified", id)
+		}
+		if _, err := db.decryptAuditRecord(encrypted); err != nil {
+			return fmt.Errorf("audit record %d is unreadable: %v", id, err)
+		}
+		lastID, lastMAC = id, mac
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	head, err := db.GetSetting(auditHeadSetting)
+	if err != nil {
+		return err
+	}
+	if head == "" {
+		if lastID > 0 {
+			return fmt.Errorf("audit log head is missing")
+		}
+		return nil
+	}
+
+	parts := strings.Split(head, ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("audit log head is malformed")
+	}
+	headID, err := strconv.Atoi(parts[0])
+	if err != nil || !hmac.Equal([]byte(parts[2]), []byte(crypto.AuditMAC(auditKey, parts[1], headID, "head"))) {
+		return fmt.Errorf("audit log head has been modified")
+	}
+	if headID > lastID {
+		return fmt.Errorf("audit records %d to %d have been removed", lastID+1, headID)
+	}
+	if headID != lastID || parts[1] != lastMAC {
+		return fmt.Errorf("audit log head does not match record %d", lastID)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"sync"
+	"testing"
+
+	"hank.com/password_tool/models"
+)
+
+// newUnlockedDB 创建已解锁的最新结构密码库
+func newUnlockedDB(t *testing.T) *DB {
+	t.Helper()
+	db := newFixtureDB(t, LatestSchemaVersion())
+	if ok, err := db.Unlock(Credentials{Password: fixturePassword}); err != nil || !ok {
+		t.Fatalf("Unlock() = %v, %v", ok, err)
+	}
+	return db
+}
+
+func TestAppendAuditConcurrent(t *testing.T) {
+	db := newUnlockedDB(t)
+
+	// SSH agent 和界面在不同的 goroutine 中写审计日志
+	const writers, perWriter = 8, 25
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				if err := db.AppendAudit(&models.AuditRecord{Action: models.AuditCopy, Client: models.AuditClientAgent}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := db.VerifyAuditLog(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := db.GetAuditLog(AuditFilter{Action: models.AuditCopy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != writers*perWriter {
+		t.Errorf("got %d copy records, want %d", len(records), writers*perWriter)
+	}
+}
+
+func TestAuditInSameTransaction(t *testing.T) {
+	db := newUnlockedDB(t)
+
+	entry := &models.PasswordEntry{Title: "mail", Password: "p"}
+	if err := db.AddPasswordEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	records, err := db.GetAuditLog(AuditFilter{Action: models.AuditCreate})
+	if err != nil || len(records) != 1 || records[0].EntryID != entry.ID {
+		t.Fatalf("create records = %v, %v", records, err)
+	}
+
+	// 审计日志无法写入时条目修改也不会保存
+	db.SetMasterKey(nil)
+	if err := db.DeletePasswordEntry(entry.ID); err == nil {
+		t.Fatal("DeletePasswordEntry() succeeded without audit log")
+	}
+	var deleted int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM password_entries WHERE id=? AND deleted_at IS NOT NULL", entry.ID).Scan(&deleted); err != nil || deleted != 0 {
+		t.Errorf("entry deleted without audit record (%d, %v)", deleted, err)
+	}
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"hank.com/password_tool/models"
+)
+
+// 备份文件名格式：passwords-20060102-150405.000000000.db
+// 时间精确到纳秒，避免同一秒内的多次备份（如解锁备份和定时备份）使用相同的文件名；
+// 解析时秒后面的小数可以省略，兼容早期精确到秒的文件名
+const (
+	backupPrefix     = "passwords-"
+	backupSuffix     = ".db"
+	backupTimeLayout = "20060102-150405"
+	backupNameLayout = backupTimeLayout + ".000000000"
+)
+
+// BackupRetention 备份保留策略：每天、每周、每月各保留最新的一份
+type BackupRetention struct {
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+// DefaultBackupRetention 默认保留最近 7 天、4 周和 12 个月的备份
+var DefaultBackupRetention = BackupRetention{Daily: 7, Weekly: 4, Monthly: 12}
+
+// BackupDir 返回备份目录
+func (db *DB) BackupDir() string {
+	return filepath.Join(db.dir, "backups")
+}
+
+// CreateBackup 创建一致的在线备份并校验完整性，然后按默认策略清理旧备份
+// 可以在后台 goroutine 中调用，与 RestoreBackup 互斥
+func (db *DB) CreateBackup() (*models.Backup, error) {
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+	return db.createBackup()
+}
+
+// createBackup 创建备份，调用方需持有 backupMu，或像 NewDB 中的迁移那样不会与其他备份并发
+func (db *DB) createBackup() (*models.Backup, error) {
+	backupDir := db.BackupDir()
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	name := backupPrefix + now.Format(backupNameLayout) + backupSuffix
+	path := filepath.Join(backupDir, name)
+
+	// 先写入临时文件，校验通过后再重命名，避免留下损坏的备份
+	tmpPath := path + ".tmp"
+	if err := db.snapshot(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to create backup: %v", err)
+	}
+	if err := VerifyBackup(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	if err := db.RotateBackups(DefaultBackupRetention); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Backup{Name: name, Path: path, Size: info.Size(), CreatedAt: now}, nil
+}
+
+// VerifyBackup 使用 PRAGMA integrity_check 校验备份文件
+func VerifyBackup(path string) error {
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("backup %s is corrupt: %v", filepath.Base(path), err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup %s is corrupt: %s", filepath.Base(path), result)
+	}
+
+	// 确认是密码库文件
+	var count int
+	err = conn.QueryRow("SELECT COUNT(*) FROM master_password").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("backup %s is not a password vault: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// ListBackups 列出所有备份，按时间从新到旧排列
+func (db *DB) ListBackups() ([]*models.Backup, error) {
+	files, err := os.ReadDir(db.BackupDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var backups []*models.Backup
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		createdAt, err := time.ParseInLocation(backupTimeLayout,
+			strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix), time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, &models.Backup{
+			Name:      name,
+			Path:      filepath.Join(db.BackupDir(), name),
+			Size:      info.Size(),
+			CreatedAt: createdAt,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// RotateBackups 按保留策略删除多余的备份，最新的备份始终保留
+func (db *DB) RotateBackups(retention BackupRetention) error {
+	backups, err := db.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	months := map[string]bool{}
+	for i, backup := range backups {
+		day := backup.CreatedAt.Format("2006-01-02")
+		year, week := backup.CreatedAt.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+		month := backup.CreatedAt.Format("2006-01")
+
+		// 备份按从新到旧排列，每个周期内第一个遇到的就是该周期最新的备份
+		keep := i == 0
+		if !days[day] && len(days) < retention.Daily {
+			days[day] = true
+			keep = true
+		}
+		if !weeks[weekKey] && len(weeks) < retention.Weekly {
+			weeks[weekKey] = true
+			keep = true
+		}
+		if !months[month] && len(months) < retention.Monthly {
+			months[month] = true
+			keep = true
+		}
+
+		if !keep {
+			if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LatestBackupTime 返回最近一次备份的时间，没有备份时返回零值
+func (db *DB) LatestBackupTime() (time.Time, error) {
+	backups, err := db.ListBackups()
+	if err != nil || len(backups) == 0 {
+		return time.Time{}, err
+	}
+	return backups[0].CreatedAt, nil
+}
+
+// RestoreBackup 用备份替换当前密码库，替换前会先备份当前密码库
+// 恢复后主密钥被清除，需要使用备份时的主密码重新登录
+func (db *DB) RestoreBackup(path string) error {
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+
+	if err := VerifyBackup(path); err != nil {
+		return err
+	}
+
+	// 先复制备份，避免下面的轮换删除要恢复的备份
+	dbPath := db.Path()
+	tmpPath := dbPath + ".restore"
+	if err := copyFile(path, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// 当前密码库可能已损坏，备份失败时仍允许恢复
+	db.createBackup()
+	failedLogins, _ := db.FailedLoginAttempts()
+
+	if err := db.conn.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		os.Remove(tmpPath)
+		// 替换失败时当前密码库没有变化，重新打开连接后可以继续使用
+		conn, openErr := openConn(dbPath)
+		if openErr != nil {
+			return fmt.Errorf("%v (failed to reopen vault: %v)", err, openErr)
+		}
+		db.conn = conn
+		return err
+	}
+	os.Remove(dbPath + "-journal")
+
+	conn, err := openConn(dbPath)
+	if err != nil {
+		return err
+	}
+	db.conn = conn
+	db.setKey(nil)
+	if err := db.migrate(); err != nil {
+		return err
+	}
+	return db.restoreFailedLogins(failedLogins)
+}
+
+// copyFile 复制文件，目标文件权限为 0600
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
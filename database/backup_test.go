@@ -0,0 +1,83 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCreateBackupUniqueNames(t *testing.T) {
+	db := newFixtureDB(t, LatestSchemaVersion())
+	if err := os.RemoveAll(db.BackupDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 早期精确到秒的文件名仍然可以识别
+	if err := os.MkdirAll(db.BackupDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	legacy := filepath.Join(db.BackupDir(), "passwords-20200101-120000.db")
+	if err := os.WriteFile(legacy, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+	var last string
+	for i := 0; i < 3; i++ {
+		backup, err := db.CreateBackup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if names[backup.Name] {
+			t.Fatalf("duplicate backup name %s", backup.Name)
+		}
+		names[backup.Name] = true
+		last = backup.Name
+	}
+
+	// 同一天只保留最新的备份
+	backups, err := db.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || backups[0].Name != last || backups[1].Path != legacy {
+		for _, b := range backups {
+			t.Log(b.Name)
+		}
+		t.Fatalf("ListBackups() returned %d backups, want %s and %s", len(backups), last, filepath.Base(legacy))
+	}
+}
+
+func TestRestoreBackupWhileBackingUp(t *testing.T) {
+	db := newFixtureDB(t, LatestSchemaVersion())
+	backup, err := db.CreateBackup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, db.conn, "DELETE FROM password_entries")
+
+	// 后台备份与恢复并发执行，恢复期间不会使用已关闭的连接
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := db.CreateBackup(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	if err := db.RestoreBackup(backup.Path); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM password_entries").Scan(&count); err != nil || count != 3 {
+		t.Errorf("entries after restore = %d, %v, want 3", count, err)
+	}
+	if db.GetKey() != nil {
+		t.Error("vault key not cleared after restore")
+	}
+}
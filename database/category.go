@@ -0,0 +1,509 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hank.com/password_tool/models"
+)
+
+// 条目通过 category_id 引用分类，读取条目时关联得到分类名称。
+// 分类可以嵌套，作为树形的文件夹使用：名称保存完整路径（例如 work/aws/prod），parent_id 指向上级文件夹。
+// 同步记录中只有分类路径（不同设备上的分类 ID 不同），写入条目时按路径查找文件夹，不存在时连同上级一起创建。
+// 重命名、移动、合并或删除文件夹会改变条目的分类路径，因此同时为受影响的条目追加修订，使变更同步到其他设备；
+// 条目还记录原来的文件夹路径（moved_from），其他设备合并后删除因此变空的旧文件夹，与本机的文件夹结构保持一致。
+
+// queryer 连接和事务共有的查询方法
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// NormalizeCategoryPath 规范化文件夹路径：去除每一级首尾的空白并忽略空的层级，例如 " work / aws/" 变为 "work/aws"
+func NormalizeCategoryPath(path string) string {
+	var parts []string
+	for _, part := range strings.Split(path, models.CategorySeparator) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, models.CategorySeparator)
+}
+
+// parentCategoryPath 返回上级文件夹的路径，顶层文件夹返回空字符串
+func parentCategoryPath(path string) string {
+	idx := strings.LastIndex(path, models.CategorySeparator)
+	if idx < 0 {
+		return ""
+	}
+	return path[:idx]
+}
+
+// joinCategoryPath 拼接上级文件夹路径和名称
+func joinCategoryPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + models.CategorySeparator + name
+}
+
+// SortCategories 按树的先序排列文件夹，每个文件夹紧跟在其上级之后
+func SortCategories(categories []*models.Category) {
+	sort.Slice(categories, func(i, j int) bool {
+		a := strings.Split(categories[i].Name, models.CategorySeparator)
+		b := strings.Split(categories[j].Name, models.CategorySeparator)
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+// categoryName 返回分类的路径，分类不存在时返回错误
+func categoryName(q queryer, id int) (string, error) {
+	var name string
+	err := q.QueryRow("SELECT name FROM categories WHERE id=?", id).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("category %d does not exist", id)
+	}
+	return name, err
+}
+
+// ensureCategoryPath 返回路径对应的文件夹 ID，不存在时连同上级一起创建，路径需已规范化
+func ensureCategoryPath(q queryer, path string) (int64, error) {
+	var id int64
+	err := q.QueryRow("SELECT id FROM categories WHERE name=?", path).Scan(&id)
+	if err != sql.ErrNoRows {
+		return id, err
+	}
+
+	var parent sql.NullInt64
+	if parentPath := parentCategoryPath(path); parentPath != "" {
+		if parent.Int64, err = ensureCategoryPath(q, parentPath); err != nil {
+			return 0, err
+		}
+		parent.Valid = true
+	}
+	result, err := q.Exec("INSERT INTO categories (name, parent_id) VALUES (?, ?)", path, parent)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// resolveCategory 返回条目写入 category_id 的值，并把条目的分类 ID 和路径更新为实际保存的值
+// CategoryID 不为 0 时优先使用，否则按 Category 路径查找或创建文件夹，两者都为空表示未分类
+func resolveCategory(q queryer, entry *models.PasswordEntry) (sql.NullInt64, error) {
+	if entry.CategoryID != 0 {
+		name, err := categoryName(q, entry.CategoryID)
+		if err != nil {
+			return sql.NullInt64{}, err
+		}
+		entry.Category = name
+		return sql.NullInt64{Int64: int64(entry.CategoryID), Valid: true}, nil
+	}
+
+	path := NormalizeCategoryPath(entry.Category)
+	if path == "" {
+		entry.Category = ""
+		return sql.NullInt64{}, nil
+	}
+
+	id, err := ensureCategoryPath(q, path)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	entry.CategoryID, entry.Category = int(id), path
+	return sql.NullInt64{Int64: id, Valid: true}, nil
+}
+
+// GetCategories 获取所有文件夹及其直接包含的条目数，按树的先序排列
+func (db *DB) GetCategories() ([]*models.Category, error) {
+	rows, err := db.conn.Query(`
+		SELECT c.id, c.name, COALESCE(c.parent_id, 0), COUNT(e.id)
+		FROM categories c LEFT JOIN password_entries e ON e.category_id = c.id AND e.deleted_at IS NULL
+		GROUP BY c.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.Category
+	for rows.Next() {
+		category := &models.Category{}
+		err := rows.Scan(&category.ID, &category.Name, &category.ParentID, &category.EntryCount)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	SortCategories(categories)
+	return categories, nil
+}
+
+// AddCategory 添加文件夹，名称可以是路径（例如 work/aws），缺少的上级文件夹会一起创建
+func (db *DB) AddCategory(name string) error {
+	path := NormalizeCategoryPath(name)
+	if path == "" {
+		return fmt.Errorf("category name is empty")
+	}
+
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM categories WHERE name=?", path).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("category %q already exists", path)
+	}
+
+	_, err := ensureCategoryPath(db.conn, path)
+	return err
+}
+
+// RenameCategory 重命名文件夹（只修改最后一级名称），子文件夹的路径随之改变
+// 同一上级下已有同名文件夹时应改为合并
+func (db *DB) RenameCategory(id int, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("category name is empty")
+	}
+	if strings.Contains(name, models.CategorySeparator) {
+		return fmt.Errorf("category name cannot contain %q", models.CategorySeparator)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	oldPath, err := categoryName(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := relocateCategory(tx, id, joinCategoryPath(parentCategoryPath(oldPath), name)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MoveCategory 将文件夹连同其中的条目和子文件夹移到 parentID 下，parentID 为 0 时移到顶层
+func (db *DB) MoveCategory(id, parentID int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	oldPath, err := categoryName(tx, id)
+	if err != nil {
+		return err
+	}
+	parentPath := ""
+	if parentID != 0 {
+		if parentPath, err = categoryName(tx, parentID); err != nil {
+			return err
+		}
+	}
+
+	newPath := joinCategoryPath(parentPath, oldPath[strings.LastIndex(oldPath, models.CategorySeparator)+1:])
+	if err := relocateCategory(tx, id, newPath); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// relocateCategory 将文件夹改为新的路径，子文件夹的路径随之改变
+func relocateCategory(tx *sql.Tx, id int, newPath string) error {
+	oldPath, err := categoryName(tx, id)
+	if err != nil {
+		return err
+	}
+	if oldPath == newPath {
+		return nil
+	}
+	if strings.HasPrefix(newPath, oldPath+models.CategorySeparator) {
+		return fmt.Errorf("cannot move category %q into itself", oldPath)
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM categories WHERE name=?", newPath).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("category %q already exists", newPath)
+	}
+
+	var parent sql.NullInt64
+	if parentPath := parentCategoryPath(newPath); parentPath != "" {
+		if parent.Int64, err = ensureCategoryPath(tx, parentPath); err != nil {
+			return err
+		}
+		parent.Valid = true
+	}
+
+	subtree, err := categorySubtree(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := touchCategoryEntries(tx, subtree, oldPath); err != nil {
+		return err
+	}
+
+	// substr 和 length 按字符计算，不能使用 Go 中按字节计算的长度
+	prefix := oldPath + models.CategorySeparator
+	_, err = tx.Exec("UPDATE categories SET name = ? || substr(name, length(?) + 1) WHERE substr(name, 1, length(?)) = ?",
+		newPath, oldPath, prefix, prefix)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE categories SET name=?, parent_id=? WHERE id=?", newPath, parent, id)
+	return err
+}
+
+// categorySubtree 返回文件夹及其所有子文件夹的 ID
+func categorySubtree(tx *sql.Tx, id int) ([]int, error) {
+	path, err := categoryName(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := path + models.CategorySeparator
+	rows, err := tx.Query("SELECT id FROM categories WHERE id=? OR substr(name, 1, length(?)) = ?", id, prefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var subID int
+		if err := rows.Scan(&subID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, subID)
+	}
+	return ids, rows.Err()
+}
+
+// MergeCategories 将 sourceID 文件夹合并到 targetID 文件夹：条目（包括回收站中的条目）移入目标文件夹，
+// 子文件夹移到目标文件夹下（目标中已有同名子文件夹时继续合并），然后删除 sourceID 文件夹
+func (db *DB) MergeCategories(sourceID, targetID int) error {
+	if sourceID == targetID {
+		return fmt.Errorf("cannot merge category %d into itself", sourceID)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sourcePath, err := categoryName(tx, sourceID)
+	if err != nil {
+		return err
+	}
+	targetPath, err := categoryName(tx, targetID)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(targetPath, sourcePath+models.CategorySeparator) {
+		return fmt.Errorf("cannot merge category %q into its subcategory %q", sourcePath, targetPath)
+	}
+
+	if err := mergeCategory(tx, sourceID, targetID, targetPath); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// mergeCategory 递归地将文件夹合并到目标文件夹
+func mergeCategory(tx *sql.Tx, sourceID, targetID int, targetPath string) error {
+	rows, err := tx.Query("SELECT id, name FROM categories WHERE parent_id=?", sourceID)
+	if err != nil {
+		return err
+	}
+	children := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		children[id] = name
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for childID, childPath := range children {
+		newPath := joinCategoryPath(targetPath, childPath[strings.LastIndex(childPath, models.CategorySeparator)+1:])
+		var existingID int
+		err := tx.QueryRow("SELECT id FROM categories WHERE name=?", newPath).Scan(&existingID)
+		switch {
+		case err == sql.ErrNoRows:
+			err = relocateCategory(tx, childID, newPath)
+		case err == nil:
+			err = mergeCategory(tx, childID, existingID, newPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	sourcePath, err := categoryName(tx, sourceID)
+	if err != nil {
+		return err
+	}
+	if err := touchCategoryEntries(tx, []int{sourceID}, sourcePath); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE password_entries SET category_id=? WHERE category_id=?", targetID, sourceID); err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM categories WHERE id=?", sourceID)
+	return err
+}
+
+// DeleteCategory 删除文件夹及其子文件夹，其中的条目移入 reassignTo 文件夹，reassignTo 为 0 时条目变为未分类
+func (db *DB) DeleteCategory(id, reassignTo int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	path, err := categoryName(tx, id)
+	if err != nil {
+		return err
+	}
+	subtree, err := categorySubtree(tx, id)
+	if err != nil {
+		return err
+	}
+
+	var target sql.NullInt64
+	if reassignTo != 0 {
+		for _, subID := range subtree {
+			if subID == reassignTo {
+				return fmt.Errorf("cannot move entries into a category being deleted")
+			}
+		}
+		if _, err := categoryName(tx, reassignTo); err != nil {
+			return err
+		}
+		target = sql.NullInt64{Int64: int64(reassignTo), Valid: true}
+	}
+
+	if err := touchCategoryEntries(tx, subtree, path); err != nil {
+		return err
+	}
+	for _, subID := range subtree {
+		if _, err := tx.Exec("UPDATE password_entries SET category_id=? WHERE category_id=?", target, subID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM categories WHERE id=?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// touchCategoryEntries 为文件夹中回收站以外的条目追加修订，分类变更后这些条目需要重新同步
+// movedFrom 为被移动、合并或删除的文件夹路径，导出前多次移动时保留最早的路径，即其他设备上的路径
+func touchCategoryEntries(tx *sql.Tx, categoryIDs []int, movedFrom string) error {
+	revisions := make(map[int]string)
+	for _, categoryID := range categoryIDs {
+		rows, err := tx.Query("SELECT id, revisions FROM password_entries WHERE category_id=? AND deleted_at IS NULL", categoryID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id int
+			var rev string
+			if err := rows.Scan(&id, &rev); err != nil {
+				rows.Close()
+				return err
+			}
+			revisions[id] = rev
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for id, rev := range revisions {
+		_, err := tx.Exec("UPDATE password_entries SET revisions=?, updated_at=?, moved_from=COALESCE(moved_from, ?) WHERE id=?",
+			appendRevision(rev, newSyncID()), now, movedFrom, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneMovedCategories 删除在其他设备上被移动、合并或删除后已经变空的文件夹，从最深的开始处理
+// 文件夹及其子文件夹中还有条目（包括回收站中的条目）时保留，不会删除只在本机使用的文件夹
+func (db *DB) PruneMovedCategories(paths []string) error {
+	paths = append([]string(nil), paths...)
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.Count(paths[i], models.CategorySeparator) > strings.Count(paths[j], models.CategorySeparator)
+	})
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, path := range paths {
+		var id int
+		err := tx.QueryRow("SELECT id FROM categories WHERE name=?", path).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		subtree, err := categorySubtree(tx, id)
+		if err != nil {
+			return err
+		}
+		used := false
+		for _, subID := range subtree {
+			var count int
+			if err := tx.QueryRow("SELECT COUNT(*) FROM password_entries WHERE category_id=?", subID).Scan(&count); err != nil {
+				return err
+			}
+			if count > 0 {
+				used = true
+				break
+			}
+		}
+		if used {
+			continue
+		}
+
+		// 子文件夹通过 parent_id 级联删除
+		if _, err := tx.Exec("DELETE FROM categories WHERE id=?", id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
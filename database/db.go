@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"hank.com/password_tool/crypto"
@@ -15,12 +16,34 @@ import (
 )
 
 type DB struct {
-	conn *sql.DB
-	key  []byte
+	conn         *sql.DB
+	key          []byte       // 只通过 setKey 和 currentKey 访问
+	keyMu        sync.RWMutex // 保护 key
+	dir          string
+	failedLogins []time.Time // 本次解锁前的失败尝试
+	auditClient  string      // 写入审计日志时记录的客户端
+	backupMu     sync.Mutex  // 后台备份与恢复互斥，恢复时会替换 conn 和 key
+
+	// 重新生成密码库密钥前调用，见 SetRekeyHook
+	rekeyHook func(oldKey, newKey []byte) error
 }
 
 // NewDB 创建新的数据库连接
 func NewDB() (*DB, error) {
+	db, err := OpenForRestore()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.migrate(); err != nil {
+		db.conn.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// OpenForRestore 打开密码库但不检查和升级结构，只用于 NewDB 失败（密码库损坏）时从备份恢复
+func OpenForRestore() (*DB, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
@@ -32,75 +55,64 @@ func NewDB() (*DB, error) {
 	}
 
 	dbPath := filepath.Join(dbDir, "passwords.db")
-	conn, err := sql.Open("sqlite3", dbPath)
+	conn, err := openConn(dbPath)
 	if err != nil {
 		return nil, err
 	}
 
-	db := &DB{conn: conn}
-	if err := db.createTables(); err != nil {
-		return nil, err
-	}
+	return &DB{conn: conn, dir: dbDir}, nil
+}
 
-	return db, nil
+// openConn 打开密码库文件，启用外键约束
+// 外键约束需要在每个连接上单独启用，因此通过连接参数设置；
+// 事务使用 BEGIN IMMEDIATE 在开始时取得写锁，并发的事务（如 SSH agent 和界面同时写审计日志）依次执行，
+// 而不是读取相同的数据后在写入时冲突
+func openConn(path string) (*sql.DB, error) {
+	return sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on&_txlock=immediate")
+}
+
+// Path 返回密码库文件的路径
+func (db *DB) Path() string {
+	return filepath.Join(db.dir, "passwords.db")
 }
 
 // SetMasterKey 设置主密钥
 func (db *DB) SetMasterKey(key []byte) {
-	db.key = key
+	db.setKey(key)
 }
 
 // GetKey 获取主密钥
 func (db *DB) GetKey() []byte {
-	return db.key
+	return db.currentKey()
 }
 
-// createTables 创建数据库表
-func (db *DB) createTables() error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS master_password (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			password_hash TEXT NOT NULL,
-			salt TEXT NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS categories (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS password_entries (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			title TEXT NOT NULL,
-			username TEXT,
-			password TEXT NOT NULL,
-			url TEXT,
-			notes TEXT,
-			category TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-	}
-
-	for _, query := range queries {
-		if _, err := db.conn.Exec(query); err != nil {
-			return err
-		}
-	}
+// setKey 设置主密钥，SSH agent 等后台 goroutine 会同时读取，需持有 keyMu
+func (db *DB) setKey(key []byte) {
+	db.keyMu.Lock()
+	defer db.keyMu.Unlock()
+	db.key = key
+}
 
-	return nil
+// currentKey 返回主密钥，未解锁时为 nil
+// 每个操作开始时读取一次，操作过程中锁定不会导致使用到一半的密钥变为 nil
+func (db *DB) currentKey() []byte {
+	db.keyMu.RLock()
+	defer db.keyMu.RUnlock()
+	return db.key
 }
 
-// SetMasterPassword 设置主密码
+// SetMasterPassword 为新密码库设置主密码，生成随机的密码库密钥并用主密码加密保存
 func (db *DB) SetMasterPassword(password string) error {
-	salt, err := crypto.GenerateSalt()
+	key, err := crypto.GenerateKey()
 	if err != nil {
 		return err
 	}
 
-	hash := crypto.HashMasterPassword(password, salt)
-	saltStr := base64.StdEncoding.EncodeToString(salt)
-
-	_, err = db.conn.Exec("INSERT OR REPLACE INTO master_password (id, password_hash, salt) VALUES (1, ?, ?)", hash, saltStr)
-	return err
+	if err := saveMasterPassword(db.conn, password, key); err != nil {
+		return err
+	}
+	db.setKey(key)
+	return nil
 }
 
 // VerifyMasterPassword 验证主密码
@@ -142,33 +154,72 @@ func (db *DB) HasMasterPassword() (bool, error) {
 
 // AddPasswordEntry 添加密码条目
 func (db *DB) AddPasswordEntry(entry *models.PasswordEntry) error {
-	if db.key == nil {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
 		return fmt.Errorf("master key not set")
 	}
 
-	encryptedPassword, err := crypto.Encrypt([]byte(entry.Password), db.key)
+	encryptedPassword, err := crypto.Encrypt([]byte(entry.Password), vaultKey)
+	if err != nil {
+		return err
+	}
+
+	if entry.UID == "" {
+		entry.UID = newSyncID()
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	categoryID, err := resolveCategory(tx, entry)
 	if err != nil {
 		return err
 	}
+	ApplyRotation(entry, false, time.Time{})
 
 	now := time.Now()
-	_, err = db.conn.Exec(`
-		INSERT INTO password_entries (title, username, password, url, notes, category, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		entry.Title, entry.Username, encryptedPassword, entry.URL, entry.Notes, entry.Category, now, now)
+	result, err := tx.Exec(`
+		INSERT INTO password_entries (uid, revisions, title, username, password, url, notes, category_id, favorite,
+			expires_at, rotation_days, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.UID, newSyncID(), entry.Title, entry.Username, encryptedPassword, entry.URL, entry.Notes, categoryID, entry.Favorite,
+		nullTime(entry.ExpiresAt), entry.RotationDays, now, now)
+	if err != nil {
+		return err
+	}
 
-	return err
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if entry.Tags, err = saveEntryTags(tx, id, entry.Tags); err != nil {
+		return err
+	}
+	if err := db.audit(tx, models.AuditCreate, int(id), entry.Title); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	entry.ID = int(id)
+	return nil
 }
 
 // GetPasswordEntries 获取所有密码条目
 func (db *DB) GetPasswordEntries() ([]*models.PasswordEntry, error) {
-	if db.key == nil {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
 		return nil, fmt.Errorf("master key not set")
 	}
 
 	rows, err := db.conn.Query(`
-		SELECT id, title, username, password, url, notes, category, created_at, updated_at
-		FROM password_entries ORDER BY title`)
+		SELECT e.id, e.uid, e.title, e.username, e.password, e.url, e.notes, COALESCE(e.category_id, 0), COALESCE(c.name, ''),
+			e.favorite, e.last_used_at, e.use_count, e.expires_at, e.rotation_days, e.created_at, e.updated_at
+		FROM password_entries e LEFT JOIN categories c ON c.id = e.category_id
+		WHERE e.deleted_at IS NULL ORDER BY e.title`)
 	if err != nil {
 		return nil, err
 	}
@@ -178,13 +229,17 @@ func (db *DB) GetPasswordEntries() ([]*models.PasswordEntry, error) {
 	for rows.Next() {
 		entry := &models.PasswordEntry{}
 		var encryptedPassword string
-		err := rows.Scan(&entry.ID, &entry.Title, &entry.Username, &encryptedPassword,
-			&entry.URL, &entry.Notes, &entry.Category, &entry.CreatedAt, &entry.UpdatedAt)
+		var lastUsedAt, expiresAt sql.NullTime
+		err := rows.Scan(&entry.ID, &entry.UID, &entry.Title, &entry.Username, &encryptedPassword,
+			&entry.URL, &entry.Notes, &entry.CategoryID, &entry.Category,
+			&entry.Favorite, &lastUsedAt, &entry.UseCount, &expiresAt, &entry.RotationDays, &entry.CreatedAt, &entry.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
+		entry.LastUsedAt = lastUsedAt.Time
+		entry.ExpiresAt = expiresAt.Time
 
-		decryptedPassword, err := crypto.Decrypt(encryptedPassword, db.key)
+		decryptedPassword, err := crypto.Decrypt(encryptedPassword, vaultKey)
 		if err != nil {
 			return nil, err
 		}
@@ -192,61 +247,105 @@ func (db *DB) GetPasswordEntries() ([]*models.PasswordEntry, error) {
 
 		entries = append(entries, entry)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return entries, nil
+	return entries, db.loadEntryTags(entries)
 }
 
-// UpdatePasswordEntry 更新密码条目
+// UpdatePasswordEntry 更新密码条目，收藏标记和使用统计不在此修改，分别由 SetFavorite 和 RecordEntryUse 更新
+// 设置了轮换周期的条目修改密码后，过期时间顺延一个周期
 func (db *DB) UpdatePasswordEntry(entry *models.PasswordEntry) error {
-	if db.key == nil {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
 		return fmt.Errorf("master key not set")
 	}
 
-	encryptedPassword, err := crypto.Encrypt([]byte(entry.Password), db.key)
+	encryptedPassword, err := crypto.Encrypt([]byte(entry.Password), vaultKey)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.conn.Exec(`
-		UPDATE password_entries 
-		SET title=?, username=?, password=?, url=?, notes=?, category=?, updated_at=?
-		WHERE id=?`,
-		entry.Title, entry.Username, encryptedPassword, entry.URL, entry.Notes, entry.Category, time.Now(), entry.ID)
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	return err
-}
+	// 每次修改都产生新的修订，供同步时判断变更先后
+	var revisions, oldPassword string
+	var oldExpiresAt sql.NullTime
+	err = tx.QueryRow("SELECT revisions, password, expires_at FROM password_entries WHERE id=?", entry.ID).
+		Scan(&revisions, &oldPassword, &oldExpiresAt)
+	if err != nil {
+		return err
+	}
+	decryptedOld, err := crypto.Decrypt(oldPassword, vaultKey)
+	if err != nil {
+		return err
+	}
+	ApplyRotation(entry, string(decryptedOld) != entry.Password, oldExpiresAt.Time)
 
-// DeletePasswordEntry 删除密码条目
-func (db *DB) DeletePasswordEntry(id int) error {
-	_, err := db.conn.Exec("DELETE FROM password_entries WHERE id=?", id)
-	return err
-}
+	categoryID, err := resolveCategory(tx, entry)
+	if err != nil {
+		return err
+	}
 
-// GetCategories 获取所有分类
-func (db *DB) GetCategories() ([]*models.Category, error) {
-	rows, err := db.conn.Query("SELECT id, name FROM categories ORDER BY name")
+	_, err = tx.Exec(`
+		UPDATE password_entries 
+		SET title=?, username=?, password=?, url=?, notes=?, category_id=?, expires_at=?, rotation_days=?, updated_at=?, revisions=?
+		WHERE id=?`,
+		entry.Title, entry.Username, encryptedPassword, entry.URL, entry.Notes, categoryID,
+		nullTime(entry.ExpiresAt), entry.RotationDays, time.Now(),
+		appendRevision(revisions, newSyncID()), entry.ID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	if entry.Tags, err = saveEntryTags(tx, int64(entry.ID), entry.Tags); err != nil {
+		return err
+	}
+	if err := db.audit(tx, models.AuditUpdate, entry.ID, entry.Title); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	var categories []*models.Category
-	for rows.Next() {
-		category := &models.Category{}
-		err := rows.Scan(&category.ID, &category.Name)
-		if err != nil {
-			return nil, err
+// DeletePasswordEntry 将密码条目移入回收站，并留下删除记录供同步使用
+func (db *DB) DeletePasswordEntry(id int) error {
+	var uid, revisions, title string
+	err := db.conn.QueryRow("SELECT uid, revisions, title FROM password_entries WHERE id=? AND deleted_at IS NULL", id).
+		Scan(&uid, &revisions, &title)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
 		}
-		categories = append(categories, category)
+		return err
 	}
 
-	return categories, nil
-}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-// AddCategory 添加分类
-func (db *DB) AddCategory(name string) error {
-	_, err := db.conn.Exec("INSERT INTO categories (name) VALUES (?)", name)
-	return err
+	// 其他设备收到删除记录后同样移入回收站，恢复时产生新修订覆盖删除记录
+	now := time.Now().UTC()
+	revisions = appendRevision(revisions, newSyncID())
+	if _, err := tx.Exec("UPDATE password_entries SET deleted_at=?, revisions=? WHERE id=?", now, revisions, id); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO sync_tombstones (uid, revisions, exported, deleted_at)
+		VALUES (?, ?, 0, ?)`,
+		uid, revisions, now)
+	if err != nil {
+		return err
+	}
+	if err := db.audit(tx, models.AuditDelete, id, title); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // Close 关闭数据库连接
@@ -0,0 +1,24 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"hank.com/password_tool/models"
+)
+
+// ApplyRotation 为设置了轮换周期的条目计算过期时间，previousExpiry 为修改前的过期时间，新建条目时为零值：
+// 还没有过期时间，或密码被修改而本次没有另行填写过期时间时，过期时间设为从现在起经过一个轮换周期
+func ApplyRotation(entry *models.PasswordEntry, passwordChanged bool, previousExpiry time.Time) {
+	if entry.RotationDays <= 0 {
+		return
+	}
+	if entry.ExpiresAt.IsZero() || (passwordChanged && entry.ExpiresAt.Equal(previousExpiry)) {
+		entry.ExpiresAt = time.Now().AddDate(0, 0, entry.RotationDays)
+	}
+}
+
+// nullTime 将零值时间保存为 NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"hank.com/password_tool/models"
+)
+
+func TestApplyRotation(t *testing.T) {
+	now := time.Now()
+	previous := now.AddDate(0, 0, 10)
+	typed := now.AddDate(0, 2, 0)
+	rotated := now.AddDate(0, 0, 30)
+
+	tests := []struct {
+		name            string
+		rotationDays    int
+		expiresAt       time.Time
+		passwordChanged bool
+		previousExpiry  time.Time
+		want            time.Time // 零值表示保持 expiresAt 不变
+	}{
+		{name: "no rotation", expiresAt: typed, passwordChanged: true, previousExpiry: previous},
+		{name: "new entry without expiry", rotationDays: 30, want: rotated},
+		{name: "new entry with typed expiry", rotationDays: 30, expiresAt: typed},
+		{name: "password changed", rotationDays: 30, expiresAt: previous, passwordChanged: true, previousExpiry: previous, want: rotated},
+		{name: "password changed with typed expiry", rotationDays: 30, expiresAt: typed, passwordChanged: true, previousExpiry: previous},
+		{name: "password changed and expiry cleared", rotationDays: 30, passwordChanged: true, previousExpiry: previous, want: rotated},
+		{name: "password unchanged", rotationDays: 30, expiresAt: previous, previousExpiry: previous},
+		{name: "expiry edited only", rotationDays: 30, expiresAt: typed, previousExpiry: previous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &models.PasswordEntry{RotationDays: tt.rotationDays, ExpiresAt: tt.expiresAt}
+			ApplyRotation(entry, tt.passwordChanged, tt.previousExpiry)
+
+			want := tt.expiresAt
+			if !tt.want.IsZero() {
+				want = tt.want
+			}
+			// 轮换后的时间以调用时刻为准，允许少量误差
+			if diff := entry.ExpiresAt.Sub(want); diff < -time.Minute || diff > time.Minute {
+				t.Errorf("ExpiresAt = %v, want %v", entry.ExpiresAt, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 连续输错主密码时按指数退避限制重试：前几次不限制，之后每次等待时间翻倍，直到上限
+const (
+	freeLoginAttempts = 3
+	loginBaseDelay    = time.Second
+	loginMaxDelay     = 15 * time.Minute
+)
+
+// wipeAfterFailuresSetting 连续输错多少次后清除密码库，为空或 0 表示不清除
+const wipeAfterFailuresSetting = "wipe_after_failures"
+
+// ErrVaultWiped 连续输错次数达到上限，密码库已被清除
+var ErrVaultWiped = errors.New("too many failed attempts, vault has been wiped")
+
+// LockoutError 输错次数过多，需要等待后才能再次尝试
+type LockoutError struct {
+	Wait time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("too many failed attempts, try again in %s", e.Wait.Round(time.Second))
+}
+
+// loginDelay 返回连续失败 failures 次后需要等待的时间
+func loginDelay(failures int) time.Duration {
+	if failures < freeLoginAttempts {
+		return 0
+	}
+
+	delay := loginBaseDelay
+	for i := freeLoginAttempts; i < failures; i++ {
+		delay *= 2
+		if delay >= loginMaxDelay {
+			return loginMaxDelay
+		}
+	}
+	return delay
+}
+
+// FailedLoginAttempts 返回自上次成功解锁以来失败的解锁尝试时间
+func (db *DB) FailedLoginAttempts() ([]time.Time, error) {
+	rows, err := db.conn.Query("SELECT attempted_at FROM failed_logins ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []time.Time
+	for rows.Next() {
+		var attemptedAt time.Time
+		if err := rows.Scan(&attemptedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attemptedAt.Local())
+	}
+	return attempts, rows.Err()
+}
+
+// LoginWait 返回再次尝试解锁前还需等待的时间
+func (db *DB) LoginWait() (time.Duration, error) {
+	attempts, err := db.FailedLoginAttempts()
+	if err != nil || len(attempts) == 0 {
+		return 0, err
+	}
+
+	delay := loginDelay(len(attempts))
+	wait := time.Until(attempts[len(attempts)-1].Add(delay))
+	// 系统时间被调回时最多等待一个完整的退避周期
+	if wait > delay {
+		wait = delay
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, nil
+}
+
+// WipeAfterFailures 返回连续输错多少次后清除密码库，0 表示不清除
+func (db *DB) WipeAfterFailures() (int, error) {
+	value, err := db.GetSetting(wipeAfterFailuresSetting)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// SetWipeAfterFailures 设置连续输错多少次后清除密码库，0 表示不清除
+func (db *DB) SetWipeAfterFailures(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid failure limit %d", n)
+	}
+	if n == 0 {
+		return db.SetSetting(wipeAfterFailuresSetting, "")
+	}
+	return db.SetSetting(wipeAfterFailuresSetting, strconv.Itoa(n))
+}
+
+// FailuresBeforeWipe 返回再输错多少次将清除密码库，未设置清除上限时返回 0
+// 解锁期间验证凭据失败的记录不计入
+func (db *DB) FailuresBeforeWipe() (int, error) {
+	limit, err := db.WipeAfterFailures()
+	if err != nil || limit == 0 {
+		return 0, err
+	}
+	var failures int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM failed_logins WHERE while_unlocked = 0").Scan(&failures); err != nil {
+		return 0, err
+	}
+	return limit - failures, nil
+}
+
+// recordFailedLogin 记录一次失败的尝试，达到清除上限时清除密码库
+// unlocked 为 true 表示密码库已解锁时验证凭据失败，只参与退避，不计入清除上限，也不会清除密码库
+func (db *DB) recordFailedLogin(unlocked bool) error {
+	_, err := db.conn.Exec("INSERT INTO failed_logins (attempted_at, while_unlocked) VALUES (?, ?)", time.Now().UTC(), unlocked)
+	if err != nil || unlocked {
+		return err
+	}
+
+	limit, err := db.WipeAfterFailures()
+	if err != nil || limit == 0 {
+		return err
+	}
+	remaining, err := db.FailuresBeforeWipe()
+	if err != nil || remaining > 0 {
+		return err
+	}
+
+	if err := db.wipe(); err != nil {
+		return err
+	}
+	return ErrVaultWiped
+}
+
+// takeFailedLogins 在成功解锁后取出并清除失败记录，供解锁后提示用户
+func (db *DB) takeFailedLogins() error {
+	attempts, err := db.FailedLoginAttempts()
+	if err != nil {
+		return err
+	}
+	if _, err := db.conn.Exec("DELETE FROM failed_logins"); err != nil {
+		return err
+	}
+	db.failedLogins = attempts
+	return nil
+}
+
+// FailedLoginsBeforeUnlock 返回本次解锁前的失败尝试，用于解锁后的提醒
+func (db *DB) FailedLoginsBeforeUnlock() []time.Time {
+	return db.failedLogins
+}
+
+// restoreFailedLogins 恢复备份后写回恢复前的失败记录，避免通过恢复旧备份重置计数
+func (db *DB) restoreFailedLogins(attempts []time.Time) error {
+	for _, attemptedAt := range attempts {
+		if _, err := db.conn.Exec("INSERT INTO failed_logins (attempted_at) VALUES (?)", attemptedAt.UTC()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wipe 清除密码库中的全部数据和自动备份
+// 清除所有表（结构版本除外），新增的表无需在这里登记
+func (db *DB) wipe() error {
+	// 持有备份锁，避免后台备份在清除期间复制出清除前的密码库
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+
+	rows, err := db.conn.Query("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_version'")
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, name)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	// 所有表清空后再检查外键，不必按引用关系排列删除顺序
+	if _, err := tx.Exec("PRAGMA defer_foreign_keys = ON"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, table := range tables {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %q", table)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// 回收已删除数据所在的页，避免残留在文件中
+	if _, err := db.conn.Exec("VACUUM"); err != nil {
+		return err
+	}
+	db.setKey(nil)
+
+	// 备份使用清除前的主密码加密，一并删除，清除后无法再恢复
+	return db.removeBackups()
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestWipeAfterFailures(t *testing.T) {
+	db := newFixtureDB(t, LatestSchemaVersion())
+	if err := db.migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := db.Unlock(Credentials{Password: fixturePassword}); err != nil || !ok {
+		t.Fatalf("Unlock() = %v, %v", ok, err)
+	}
+	entries, err := db.GetPasswordEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries[0].Tags = []string{"work"}
+	if err := db.UpdatePasswordEntry(entries[0]); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := db.GetTags(); err != nil || len(tags) != 1 {
+		t.Fatalf("GetTags() = %v, %v", tags, err)
+	}
+	if _, err := db.CreateBackup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetWipeAfterFailures(2); err != nil {
+		t.Fatal(err)
+	}
+	db.SetMasterKey(nil)
+
+	if ok, err := db.Unlock(Credentials{Password: "wrong"}); ok || err != nil {
+		t.Fatalf("first failure: Unlock() = %v, %v", ok, err)
+	}
+	if _, err := db.Unlock(Credentials{Password: "wrong"}); !errors.Is(err, ErrVaultWiped) {
+		t.Fatalf("second failure: Unlock() error = %v, want ErrVaultWiped", err)
+	}
+
+	// 所有表都被清空，包括后来新增的表
+	rows, err := db.conn.Query("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_version'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	for _, table := range tables {
+		var count int
+		if err := db.conn.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil || count != 0 {
+			t.Errorf("%s has %d rows after wipe (%v)", table, count, err)
+		}
+	}
+
+	if _, err := os.Stat(db.BackupDir()); !os.IsNotExist(err) {
+		t.Errorf("backup directory still exists after wipe: %v", err)
+	}
+	if v, err := db.SchemaVersion(); err != nil || v != LatestSchemaVersion() {
+		t.Errorf("SchemaVersion() = %d, %v", v, err)
+	}
+}
+
+func TestVerifyCredentialsNeverWipes(t *testing.T) {
+	db := newFixtureDB(t, LatestSchemaVersion())
+	if err := db.migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := db.Unlock(Credentials{Password: fixturePassword}); err != nil || !ok {
+		t.Fatalf("Unlock() = %v, %v", ok, err)
+	}
+	if err := db.SetWipeAfterFailures(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// 解锁期间输错只退避，不计入清除上限
+	for i := 0; i < 3; i++ {
+		if ok, err := db.VerifyCredentials(Credentials{Password: "wrong"}); ok || err != nil {
+			t.Fatalf("failure %d: VerifyCredentials() = %v, %v", i, ok, err)
+		}
+	}
+	if _, err := db.VerifyCredentials(Credentials{Password: "wrong"}); !errors.As(err, new(*LockoutError)) {
+		t.Fatalf("VerifyCredentials() error = %v, want *LockoutError", err)
+	}
+	if remaining, err := db.FailuresBeforeWipe(); err != nil || remaining != 2 {
+		t.Errorf("FailuresBeforeWipe() = %d, %v, want 2", remaining, err)
+	}
+	if entries, err := db.GetPasswordEntries(); err != nil || len(entries) == 0 {
+		t.Errorf("GetPasswordEntries() after failures = %d, %v", len(entries), err)
+	}
+
+	// 锁定后输错仍按清除上限计数
+	if _, err := db.conn.Exec("DELETE FROM failed_logins"); err != nil {
+		t.Fatal(err)
+	}
+	db.SetMasterKey(nil)
+	if ok, err := db.Unlock(Credentials{Password: "wrong"}); ok || err != nil {
+		t.Fatalf("Unlock() = %v, %v", ok, err)
+	}
+	if remaining, err := db.FailuresBeforeWipe(); err != nil || remaining != 1 {
+		t.Errorf("FailuresBeforeWipe() after locked failure = %d, %v, want 1", remaining, err)
+	}
+}
@@ -0,0 +1,286 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/factor"
+	"hank.com/password_tool/models"
+)
+
+// 密码库密钥与主密码相互独立：密钥用主密码派生的密钥加密后保存在 wrapped_key 中，
+// 修改主密码或通过恢复份额重置主密码时只需重新加密密钥，无需重新加密所有条目。
+// 早期版本创建的密码库没有 wrapped_key，密钥直接由主密码派生，首次修改主密码后转换为新格式。
+
+// 解锁因素相关的设置项
+const (
+	// keyFileSetting 标记解锁时是否需要密钥文件
+	keyFileSetting = "key_file_required"
+	// factorChallengeSetting 第二因素的挑战值（十六进制），为空表示未启用第二因素
+	factorChallengeSetting = "factor_challenge"
+	// factorNameSetting 启用的第二因素名称
+	factorNameSetting = "factor_name"
+)
+
+// 第二因素挑战值的字节数
+const factorChallengeSize = 32
+
+// Credentials 解锁密码库所需的凭据
+type Credentials struct {
+	Password string
+	KeyFile  []byte           // 由 crypto.ParseKeyFile 得到的密钥文件密钥，未使用密钥文件时为空
+	Factor   factor.Responder // 第二因素，未启用时为 nil
+}
+
+// KeyFileRequired 检查解锁时是否需要密钥文件
+func (db *DB) KeyFileRequired() (bool, error) {
+	value, err := db.GetSetting(keyFileSetting)
+	return value != "", err
+}
+
+// FactorRequired 返回解锁所需的第二因素名称，未启用时返回空字符串
+func (db *DB) FactorRequired() (string, error) {
+	challenge, err := db.GetSetting(factorChallengeSetting)
+	if err != nil || challenge == "" {
+		return "", err
+	}
+	return db.GetSetting(factorNameSetting)
+}
+
+// compositeKey 使用保存的挑战值向第二因素取得应答，并与主密码、密钥文件组合
+func (db *DB) compositeKey(credentials Credentials) (string, error) {
+	challengeHex, err := db.GetSetting(factorChallengeSetting)
+	if err != nil {
+		return "", err
+	}
+	if challengeHex == "" {
+		return crypto.CompositeKey(credentials.Password, credentials.KeyFile, nil), nil
+	}
+
+	if credentials.Factor == nil {
+		return "", fmt.Errorf("second factor required")
+	}
+	challenge, err := hex.DecodeString(challengeHex)
+	if err != nil {
+		return "", err
+	}
+	response, err := credentials.Factor.Respond(challenge)
+	if err != nil {
+		return "", fmt.Errorf("second factor: %v", err)
+	}
+	return crypto.CompositeKey(credentials.Password, credentials.KeyFile, response), nil
+}
+
+// checkCredentials 验证凭据，连续输错时需要等待退避时间（返回 *LockoutError），
+// 设置了清除上限时达到上限会清除密码库（返回 ErrVaultWiped），unlocked 为 true 时只退避不清除；
+// 验证通过时返回组合后的主密码
+func (db *DB) checkCredentials(credentials Credentials, unlocked bool) (string, bool, error) {
+	wait, err := db.LoginWait()
+	if err != nil {
+		return "", false, err
+	}
+	if wait > 0 {
+		return "", false, &LockoutError{Wait: wait}
+	}
+
+	password, err := db.compositeKey(credentials)
+	if err != nil {
+		return "", false, err
+	}
+	valid, err := db.VerifyMasterPassword(password)
+	if err != nil {
+		return "", false, err
+	}
+	if !valid {
+		return "", false, db.recordFailedLogin(unlocked)
+	}
+	if err := db.takeFailedLogins(); err != nil {
+		return "", false, err
+	}
+	return password, true, nil
+}
+
+// Unlock 验证凭据并设置密码库密钥
+// 连续输错时需要等待退避时间（返回 *LockoutError），设置了清除上限时达到上限会清除密码库（返回 ErrVaultWiped）
+func (db *DB) Unlock(credentials Credentials) (bool, error) {
+	password, valid, err := db.checkCredentials(credentials, false)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	salt, err := db.GetMasterPasswordSalt()
+	if err != nil {
+		return false, err
+	}
+
+	var wrappedKey sql.NullString
+	err = db.conn.QueryRow("SELECT wrapped_key FROM master_password WHERE id = 1").Scan(&wrappedKey)
+	if err != nil {
+		return false, err
+	}
+
+	if !wrappedKey.Valid || wrappedKey.String == "" {
+		db.setKey(crypto.DeriveKey(password, salt))
+	} else {
+		key, err := crypto.Decrypt(wrappedKey.String, crypto.DeriveWrappingKey(password, salt))
+		if err != nil {
+			return false, fmt.Errorf("failed to unwrap vault key: %v", err)
+		}
+		db.setKey(key)
+	}
+
+	if err := db.AppendAudit(&models.AuditRecord{Action: models.AuditUnlock}); err != nil {
+		return false, err
+	}
+	if _, err := db.PurgeExpiredTrash(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetRekeyHook 设置重新生成密码库密钥时、提交修改前调用的函数，返回错误时取消修改
+// 修改未能提交时以交换后的新旧密钥再调用一次，撤销已做的改动
+func (db *DB) SetRekeyHook(hook func(oldKey, newKey []byte) error) {
+	db.rekeyHook = hook
+}
+
+// ChangeMasterPassword 修改主密码、密钥文件和第二因素，需要先解锁或通过恢复份额取得密码库密钥
+// 启用第二因素时会生成新的挑战值；新添加密钥文件或第二因素时同时重新生成密码库密钥，
+// 旧的自动备份、恢复密钥和恢复份额随之失效，需要重新生成恢复密钥和恢复份额，所有条目重新导出同步记录
+func (db *DB) ChangeMasterPassword(credentials Credentials) error {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return fmt.Errorf("master key not set")
+	}
+
+	keyFileRequired, err := db.KeyFileRequired()
+	if err != nil {
+		return err
+	}
+	factorRequired, err := db.FactorRequired()
+	if err != nil {
+		return err
+	}
+	rekey := (len(credentials.KeyFile) > 0 && !keyFileRequired) || (credentials.Factor != nil && factorRequired == "")
+	if rekey {
+		// 重建审计日志的认证码链前确认日志未被篡改，避免掩盖篡改痕迹
+		if err := db.VerifyAuditLog(); err != nil {
+			return fmt.Errorf("cannot change vault key: %v", err)
+		}
+	}
+
+	var response []byte
+	challengeHex, factorName := "", ""
+	if credentials.Factor != nil {
+		challenge := make([]byte, factorChallengeSize)
+		if _, err := rand.Read(challenge); err != nil {
+			return err
+		}
+		response, err = credentials.Factor.Respond(challenge)
+		if err != nil {
+			return fmt.Errorf("second factor: %v", err)
+		}
+		challengeHex, factorName = hex.EncodeToString(challenge), credentials.Factor.Name()
+	}
+
+	newKey, committed := vaultKey, false
+	if rekey {
+		if newKey, err = crypto.GenerateKey(); err != nil {
+			return err
+		}
+		if db.rekeyHook != nil {
+			if err := db.rekeyHook(vaultKey, newKey); err != nil {
+				return fmt.Errorf("cannot change vault key: %v", err)
+			}
+			defer func() {
+				if !committed {
+					db.rekeyHook(newKey, vaultKey)
+				}
+			}()
+		}
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if rekey {
+		if err := db.rekey(tx, vaultKey, newKey); err != nil {
+			return err
+		}
+	}
+
+	password := crypto.CompositeKey(credentials.Password, credentials.KeyFile, response)
+	if err := saveMasterPassword(tx, password, newKey); err != nil {
+		return err
+	}
+
+	keyFileValue := ""
+	if len(credentials.KeyFile) > 0 {
+		keyFileValue = "1"
+	}
+	settings := map[string]string{
+		keyFileSetting:         keyFileValue,
+		factorChallengeSetting: challengeHex,
+		factorNameSetting:      factorName,
+	}
+	for key, value := range settings {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, value); err != nil {
+			return err
+		}
+	}
+
+	if !rekey {
+		return tx.Commit()
+	}
+
+	// 提交和切换密钥之间持有密钥锁，其他 goroutine 不会在提交后用旧密钥写入
+	db.keyMu.Lock()
+	err = tx.Commit()
+	if err == nil {
+		db.key = newKey
+		committed = true
+	}
+	db.keyMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// 回收旧密文所在的页，再替换包含旧密钥的备份
+	if _, err := db.conn.Exec("VACUUM"); err != nil {
+		return err
+	}
+	return db.replaceBackups()
+}
+
+// saveMasterPassword 保存主密码的哈希，并用主密码派生的密钥加密保存密码库密钥
+func saveMasterPassword(q queryer, password string, key []byte) error {
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return err
+	}
+
+	hash := crypto.HashMasterPassword(password, salt)
+	saltStr := base64.StdEncoding.EncodeToString(salt)
+	wrappedKey, err := crypto.Encrypt(key, crypto.DeriveWrappingKey(password, salt))
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec("INSERT OR REPLACE INTO master_password (id, password_hash, salt, wrapped_key) VALUES (1, ?, ?, ?)",
+		hash, saltStr, wrappedKey)
+	return err
+}
+
+// VerifyCredentials 在解锁后验证凭据是否正确，不改变当前的密码库密钥
+// 与 Unlock 共用输错退避，解锁后也不能借此无限次猜测主密码；输错不计入清除上限，不会清除密码库
+func (db *DB) VerifyCredentials(credentials Credentials) (bool, error) {
+	_, valid, err := db.checkCredentials(credentials, true)
+	return valid, err
+}
@@ -0,0 +1,324 @@
+package database
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/factor/factortest"
+	"hank.com/password_tool/models"
+)
+
+func newTestKeyFile(t *testing.T) []byte {
+	t.Helper()
+	data, err := crypto.GenerateKeyFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := crypto.ParseKeyFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyFile
+}
+
+func TestChangeMasterPasswordKeepsKey(t *testing.T) {
+	db := newUnlockedDB(t)
+	oldKey := db.GetKey()
+	recoveryKey, err := db.CreateRecoveryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 只修改主密码时只重新加密密码库密钥，恢复密钥仍然有效
+	if err := db.ChangeMasterPassword(Credentials{Password: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(db.GetKey(), oldKey) {
+		t.Error("vault key changed on a password-only change")
+	}
+	db.SetMasterKey(nil)
+	if err := db.RecoverWithRecoveryKey(recoveryKey); err != nil {
+		t.Errorf("RecoverWithRecoveryKey() = %v", err)
+	}
+}
+
+func TestChangeMasterPasswordRekeysWhenAddingKeyFile(t *testing.T) {
+	db := newUnlockedDB(t)
+	oldKey := db.GetKey()
+
+	if err := db.AddSSHKey(&models.SSHKey{Name: "id", PublicKey: "ssh-ed25519 AAAA"}, []byte("private")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSecretSetting("webdav_password", "dav-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSetting("entry_sort", "usage"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := db.GetPasswordEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeletePasswordEntry(entries[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 一个文件渲染后未修改，另一个在渲染后被修改
+	dir := t.TempDir()
+	unchanged, modified := filepath.Join(dir, "unchanged.env"), filepath.Join(dir, "modified.env")
+	for _, path := range []string{unchanged, modified} {
+		if err := os.WriteFile(path, []byte("TOKEN=secret"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SetRenderedFile(path, []byte("TOKEN=secret")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(modified, []byte("TOKEN=edited"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.CreateRecoveryShares(3, 2); err != nil {
+		t.Fatal(err)
+	}
+	oldRecoveryKey, err := db.CreateRecoveryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldBackup, err := db.CreateBackup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := newTestKeyFile(t)
+	if err := db.ChangeMasterPassword(Credentials{Password: fixturePassword, KeyFile: keyFile}); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(db.GetKey(), oldKey) {
+		t.Fatal("vault key not regenerated after adding a key file")
+	}
+
+	// 用新凭据解锁后所有数据仍可读取
+	db.SetMasterKey(nil)
+	if ok, err := db.Unlock(Credentials{Password: fixturePassword}); ok || err != nil {
+		t.Fatalf("Unlock() without key file = %v, %v", ok, err)
+	}
+	if ok, err := db.Unlock(Credentials{Password: fixturePassword, KeyFile: keyFile}); !ok || err != nil {
+		t.Fatalf("Unlock() = %v, %v", ok, err)
+	}
+
+	entries, err = db.GetPasswordEntries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("GetPasswordEntries() = %d entries, %v", len(entries), err)
+	}
+	for _, entry := range entries {
+		if entry.Password != "secret" {
+			t.Errorf("%s: password = %q", entry.Title, entry.Password)
+		}
+	}
+	trash, err := db.GetDeletedPasswordEntries()
+	if err != nil || len(trash) != 1 || trash[0].Password != "secret" {
+		t.Errorf("GetDeletedPasswordEntries() = %v, %v", trash, err)
+	}
+
+	keys, err := db.GetSSHKeys()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("GetSSHKeys() = %v, %v", keys, err)
+	}
+	if private, err := db.GetSSHPrivateKey(keys[0].ID); err != nil || string(private) != "private" {
+		t.Errorf("GetSSHPrivateKey() = %q, %v", private, err)
+	}
+	if value, err := db.GetSecretSetting("webdav_password"); err != nil || value != "dav-secret" {
+		t.Errorf("GetSecretSetting() = %q, %v", value, err)
+	}
+	if value, err := db.GetSetting("entry_sort"); err != nil || value != "usage" {
+		t.Errorf("GetSetting() = %q, %v", value, err)
+	}
+
+	if err := db.VerifyAuditLog(); err != nil {
+		t.Errorf("VerifyAuditLog() = %v", err)
+	}
+	records, err := db.GetAuditLog(AuditFilter{})
+	if err != nil || len(records) < 3 {
+		t.Errorf("GetAuditLog() = %d records, %v", len(records), err)
+	}
+
+	if generated, ok, err := db.CheckRenderedFile(unchanged, []byte("TOKEN=secret")); err != nil || !generated || !ok {
+		t.Errorf("CheckRenderedFile(unchanged) = %v, %v, %v", generated, ok, err)
+	}
+	if generated, ok, err := db.CheckRenderedFile(modified, []byte("TOKEN=edited")); err != nil || !generated || ok {
+		t.Errorf("CheckRenderedFile(modified) = %v, %v, %v", generated, ok, err)
+	}
+
+	// 旧的恢复密钥、恢复份额和备份都已失效
+	if threshold, err := db.RecoveryThreshold(); err != nil || threshold != 0 {
+		t.Errorf("RecoveryThreshold() = %d, %v", threshold, err)
+	}
+	if has, err := db.HasRecoveryKey(); err != nil || has {
+		t.Errorf("HasRecoveryKey() = %v, %v", has, err)
+	}
+	if err := db.RecoverWithRecoveryKey(oldRecoveryKey); err == nil {
+		t.Error("old recovery key still recovers the vault")
+	}
+	if _, err := os.Stat(oldBackup.Path); !os.IsNotExist(err) {
+		t.Errorf("old backup still exists: %v", err)
+	}
+	backups, err := db.ListBackups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("ListBackups() = %v, %v", backups, err)
+	}
+
+	// 更换为其他密钥文件不会再次生成密钥
+	key := db.GetKey()
+	if err := db.ChangeMasterPassword(Credentials{Password: fixturePassword, KeyFile: newTestKeyFile(t)}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(db.GetKey(), key) {
+		t.Error("vault key regenerated when replacing the key file")
+	}
+}
+
+func TestChangeMasterPasswordRefusesTamperedAuditLog(t *testing.T) {
+	db := newUnlockedDB(t)
+	oldKey := db.GetKey()
+	mustExec(t, db.conn, "DELETE FROM audit_log")
+
+	if err := db.ChangeMasterPassword(Credentials{Password: fixturePassword, KeyFile: newTestKeyFile(t)}); err == nil {
+		t.Fatal("ChangeMasterPassword() succeeded with a tampered audit log")
+	}
+	if !bytes.Equal(db.GetKey(), oldKey) {
+		t.Error("vault key changed after a failed change")
+	}
+	if required, err := db.KeyFileRequired(); err != nil || required {
+		t.Errorf("KeyFileRequired() = %v, %v", required, err)
+	}
+}
+
+func TestVerifyCredentialsLockout(t *testing.T) {
+	db := newUnlockedDB(t)
+	if err := db.SetWipeAfterFailures(5); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < freeLoginAttempts; i++ {
+		if ok, err := db.VerifyCredentials(Credentials{Password: "wrong"}); ok || err != nil {
+			t.Fatalf("attempt %d: VerifyCredentials() = %v, %v", i+1, ok, err)
+		}
+	}
+	// 输错次数与解锁共用，超过免等待次数后需要等待
+	var lockout *LockoutError
+	if _, err := db.VerifyCredentials(Credentials{Password: fixturePassword}); !errors.As(err, &lockout) {
+		t.Fatalf("VerifyCredentials() error = %v, want *LockoutError", err)
+	}
+	if attempts, err := db.FailedLoginAttempts(); err != nil || len(attempts) != freeLoginAttempts {
+		t.Errorf("FailedLoginAttempts() = %d, %v", len(attempts), err)
+	}
+
+	// 等待结束后验证成功会清除失败记录
+	mustExec(t, db.conn, "UPDATE failed_logins SET attempted_at = datetime('now', '-1 hour')")
+	if ok, err := db.VerifyCredentials(Credentials{Password: fixturePassword}); !ok || err != nil {
+		t.Fatalf("VerifyCredentials() = %v, %v", ok, err)
+	}
+	if attempts, err := db.FailedLoginAttempts(); err != nil || len(attempts) != 0 {
+		t.Errorf("FailedLoginAttempts() after success = %d, %v", len(attempts), err)
+	}
+}
+
+// storedChallenge 返回保存的第二因素挑战值
+func storedChallenge(t *testing.T, db *DB) string {
+	t.Helper()
+	challenge, err := db.GetSetting(factorChallengeSetting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+func TestChangeMasterPasswordIssuesFactorChallenge(t *testing.T) {
+	db := newUnlockedDB(t)
+	token := factortest.New("secret")
+
+	if err := db.ChangeMasterPassword(Credentials{Password: fixturePassword, Factor: token}); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := db.FactorRequired(); err != nil || name != token.Name() {
+		t.Errorf("FactorRequired() = %q, %v", name, err)
+	}
+	challenges := token.Challenges()
+	if len(challenges) != 1 || storedChallenge(t, db) != hex.EncodeToString(challenges[0]) {
+		t.Fatalf("challenges = %x, stored %s", challenges, storedChallenge(t, db))
+	}
+
+	// 每次修改都生成新的挑战值
+	if err := db.ChangeMasterPassword(Credentials{Password: fixturePassword, Factor: token}); err != nil {
+		t.Fatal(err)
+	}
+	challenges = token.Challenges()
+	if len(challenges) != 2 || bytes.Equal(challenges[0], challenges[1]) {
+		t.Fatalf("challenges after second change = %x", challenges)
+	}
+	if storedChallenge(t, db) != hex.EncodeToString(challenges[1]) {
+		t.Errorf("stored challenge %s, want %x", storedChallenge(t, db), challenges[1])
+	}
+
+	// 令牌出错时不修改凭据
+	failing := factortest.New("secret")
+	failing.Err = errors.New("token removed")
+	err := db.ChangeMasterPassword(Credentials{Password: "new", Factor: failing})
+	if err == nil || !strings.Contains(err.Error(), "token removed") {
+		t.Fatalf("ChangeMasterPassword() error = %v, want the token error", err)
+	}
+	if storedChallenge(t, db) != hex.EncodeToString(challenges[1]) {
+		t.Error("challenge changed after a failed change")
+	}
+}
+
+func TestFactorResponseRequired(t *testing.T) {
+	db := newUnlockedDB(t)
+	token := factortest.New("secret")
+	if err := db.ChangeMasterPassword(Credentials{Password: fixturePassword, Factor: token}); err != nil {
+		t.Fatal(err)
+	}
+	challenge := storedChallenge(t, db)
+
+	failing := factortest.New("secret")
+	failing.Err = errors.New("token removed")
+	checks := map[string]func(Credentials) (bool, error){
+		"Unlock": func(credentials Credentials) (bool, error) {
+			db.SetMasterKey(nil)
+			return db.Unlock(credentials)
+		},
+		"VerifyCredentials": db.VerifyCredentials,
+	}
+	for name, check := range checks {
+		if ok, err := check(Credentials{Password: fixturePassword}); ok || err == nil {
+			t.Errorf("%s() without the factor = %v, %v", name, ok, err)
+		}
+		if ok, err := check(Credentials{Password: fixturePassword, Factor: failing}); ok || err == nil || !strings.Contains(err.Error(), "token removed") {
+			t.Errorf("%s() with a failing factor = %v, %v", name, ok, err)
+		}
+		// 其他令牌的应答不同，与输错主密码一样失败
+		if ok, err := check(Credentials{Password: fixturePassword, Factor: factortest.New("other")}); ok || err != nil {
+			t.Errorf("%s() with a wrong response = %v, %v", name, ok, err)
+		}
+		if ok, err := check(Credentials{Password: fixturePassword, Factor: token}); !ok || err != nil {
+			t.Errorf("%s() with the factor = %v, %v", name, ok, err)
+		}
+	}
+
+	// 解锁和验证都使用保存的挑战值，不会更换
+	for _, c := range token.Challenges()[1:] {
+		if hex.EncodeToString(c) != challenge {
+			t.Errorf("responded to challenge %x, want %s", c, challenge)
+		}
+	}
+	if db.GetKey() == nil {
+		t.Error("vault locked after unlocking with the factor")
+	}
+}
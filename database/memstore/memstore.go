@@ -0,0 +1,885 @@
+package memstore
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// 第二因素挑战值的字节数，与 database 一致
+const factorChallengeSize = 32
+
+// trashRetentionSetting 回收站保留天数的设置项，与 database 一致
+const trashRetentionSetting = "trash_retention_days"
+
+// Store 只保存在内存中的 database.VaultStore 实现，供测试使用，不会读写 ~/.password_tool
+// 条目和设置以明文保存，主密码只保存组合后的 SHA-256，不做密钥派生；
+// 解锁退避、同步、备份等只有 SQLite 实现才有的功能不在此实现
+type Store struct {
+	mu sync.Mutex
+
+	// 主密码和解锁因素
+	passwordHash    []byte // 组合主密码的 SHA-256，未设置主密码时为空
+	vaultKey        []byte // 密码库密钥，与主密码相互独立
+	keyFileRequired bool
+	factorName      string
+	factorChallenge []byte
+
+	key            []byte // 当前的密码库密钥，锁定时为空
+	entries        map[int]*models.PasswordEntry
+	nextEntryID    int
+	categories     map[int]*models.Category
+	nextCategoryID int
+	tags           map[string]*models.Tag // 以小写名称为键，标签名称不区分大小写
+	nextTagID      int
+	settings       map[string]string
+	auditLog       []*models.AuditRecord
+	auditClient    string
+	closed         bool
+}
+
+var _ database.VaultStore = (*Store)(nil)
+
+// New 创建空的内存密码库
+func New() *Store {
+	return &Store{
+		entries:        make(map[int]*models.PasswordEntry),
+		nextEntryID:    1,
+		categories:     make(map[int]*models.Category),
+		nextCategoryID: 1,
+		tags:           make(map[string]*models.Tag),
+		nextTagID:      1,
+		settings:       make(map[string]string),
+	}
+}
+
+// copyEntry 返回条目的副本，调用方修改返回的条目不会影响保存的数据
+func copyEntry(entry *models.PasswordEntry) *models.PasswordEntry {
+	copied := *entry
+	copied.Tags = append([]string(nil), entry.Tags...)
+	return &copied
+}
+
+// entryOut 返回条目的副本并填入分类名称，调用方需持有锁
+func (s *Store) entryOut(entry *models.PasswordEntry) *models.PasswordEntry {
+	copied := copyEntry(entry)
+	copied.Category = ""
+	if category, ok := s.categories[entry.CategoryID]; ok {
+		copied.Category = category.Name
+	}
+	return copied
+}
+
+// newUID 生成条目的同步标识
+func newUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// checkKey 检查密码库是否已解锁，调用方需持有锁
+func (s *Store) checkKey() error {
+	if s.closed {
+		return fmt.Errorf("store closed")
+	}
+	if s.key == nil {
+		return fmt.Errorf("master key not set")
+	}
+	return nil
+}
+
+// appendAudit 追加审计记录，调用方需持有锁
+func (s *Store) appendAudit(record *models.AuditRecord) error {
+	if err := s.checkKey(); err != nil {
+		return err
+	}
+	if record.Client == "" {
+		record.Client = s.auditClient
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	record.ID = len(s.auditLog) + 1
+	copied := *record
+	s.auditLog = append(s.auditLog, &copied)
+	return nil
+}
+
+// SetAuditClient 设置写入审计日志时记录的客户端
+func (s *Store) SetAuditClient(client string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditClient = client
+}
+
+// AuditLog 返回写入的全部审计记录，最早的在前
+func (s *Store) AuditLog() []*models.AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]*models.AuditRecord, len(s.auditLog))
+	for i, record := range s.auditLog {
+		copied := *record
+		records[i] = &copied
+	}
+	return records
+}
+
+// AppendAudit 追加一条审计日志，未指定客户端时使用 SetAuditClient 设置的客户端
+func (s *Store) AppendAudit(record *models.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendAudit(record)
+}
+
+// AddPasswordEntry 添加密码条目
+func (s *Store) AddPasswordEntry(entry *models.PasswordEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkKey(); err != nil {
+		return err
+	}
+
+	if entry.UID == "" {
+		entry.UID = newUID()
+	}
+	if err := s.resolveCategory(entry); err != nil {
+		return err
+	}
+	s.resolveTags(entry)
+	database.ApplyRotation(entry, false, time.Time{})
+	now := time.Now()
+	entry.ID = s.nextEntryID
+	entry.CreatedAt, entry.UpdatedAt = now, now
+	entry.LastUsedAt, entry.UseCount = time.Time{}, 0
+	entry.DeletedAt = time.Time{}
+	s.nextEntryID++
+	s.entries[entry.ID] = copyEntry(entry)
+
+	return s.appendAudit(&models.AuditRecord{Action: models.AuditCreate, EntryID: entry.ID, Subject: entry.Title})
+}
+
+// listEntries 返回按 less 排序的条目副本，调用方需持有锁
+func (s *Store) listEntries(deleted bool, less func(a, b *models.PasswordEntry) bool) []*models.PasswordEntry {
+	var entries []*models.PasswordEntry
+	for _, entry := range s.entries {
+		if entry.DeletedAt.IsZero() == deleted {
+			continue
+		}
+		entries = append(entries, s.entryOut(entry))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return less(entries[i], entries[j])
+	})
+	return entries
+}
+
+// GetPasswordEntries 获取所有密码条目，按标题排序
+func (s *Store) GetPasswordEntries() ([]*models.PasswordEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkKey(); err != nil {
+		return nil, err
+	}
+
+	return s.listEntries(false, func(a, b *models.PasswordEntry) bool {
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID < b.ID
+	}), nil
+}
+
+// UpdatePasswordEntry 更新密码条目
+func (s *Store) UpdatePasswordEntry(entry *models.PasswordEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkKey(); err != nil {
+		return err
+	}
+
+	existing, ok := s.entries[entry.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if err := s.resolveCategory(entry); err != nil {
+		return err
+	}
+	s.resolveTags(entry)
+	database.ApplyRotation(entry, existing.Password != entry.Password, existing.ExpiresAt)
+	updated := copyEntry(entry)
+	updated.UID, updated.CreatedAt, updated.DeletedAt = existing.UID, existing.CreatedAt, existing.DeletedAt
+	updated.Favorite, updated.LastUsedAt, updated.UseCount = existing.Favorite, existing.LastUsedAt, existing.UseCount
+	updated.UpdatedAt = time.Now()
+	s.entries[entry.ID] = updated
+	s.deleteUnusedTags()
+
+	return s.appendAudit(&models.AuditRecord{Action: models.AuditUpdate, EntryID: entry.ID, Subject: entry.Title})
+}
+
+// DeletePasswordEntry 将密码条目移入回收站，条目不存在或已在回收站中时不做任何操作
+func (s *Store) DeletePasswordEntry(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok || !entry.DeletedAt.IsZero() {
+		return nil
+	}
+	entry.DeletedAt = time.Now().UTC()
+
+	return s.appendAudit(&models.AuditRecord{Action: models.AuditDelete, EntryID: id, Subject: entry.Title})
+}
+
+// GetDeletedPasswordEntries 获取回收站中的条目，最近删除的在前
+func (s *Store) GetDeletedPasswordEntries() ([]*models.PasswordEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkKey(); err != nil {
+		return nil, err
+	}
+
+	return s.listEntries(true, func(a, b *models.PasswordEntry) bool {
+		return a.DeletedAt.After(b.DeletedAt)
+	}), nil
+}
+
+// trashedEntry 返回回收站中的条目，与 SQLite 实现一样在不存在时返回 sql.ErrNoRows，调用方需持有锁
+func (s *Store) trashedEntry(id int) (*models.PasswordEntry, error) {
+	entry, ok := s.entries[id]
+	if !ok || entry.DeletedAt.IsZero() {
+		return nil, sql.ErrNoRows
+	}
+	return entry, nil
+}
+
+// RestorePasswordEntry 从回收站恢复条目
+func (s *Store) RestorePasswordEntry(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.trashedEntry(id)
+	if err != nil {
+		return err
+	}
+	entry.DeletedAt = time.Time{}
+	entry.UpdatedAt = time.Now()
+
+	return s.appendAudit(&models.AuditRecord{Action: models.AuditRestore, EntryID: id, Subject: entry.Title})
+}
+
+// PurgePasswordEntry 永久删除回收站中的条目
+func (s *Store) PurgePasswordEntry(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.trashedEntry(id)
+	if err != nil {
+		return err
+	}
+	delete(s.entries, id)
+	s.deleteUnusedTags()
+
+	return s.appendAudit(&models.AuditRecord{Action: models.AuditPurge, EntryID: id, Subject: entry.Title})
+}
+
+// TrashRetentionDays 返回回收站中的条目保留的天数，0 表示不自动清除
+func (s *Store) TrashRetentionDays() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.trashRetentionDays()
+}
+
+// trashRetentionDays 读取回收站保留天数，调用方需持有锁
+func (s *Store) trashRetentionDays() (int, error) {
+	value := s.settings[trashRetentionSetting]
+	if value == "" {
+		return database.DefaultTrashRetentionDays, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// SetTrashRetentionDays 设置回收站中的条目保留的天数，0 表示不自动清除
+func (s *Store) SetTrashRetentionDays(days int) error {
+	if days < 0 {
+		return fmt.Errorf("invalid retention %d days", days)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[trashRetentionSetting] = strconv.Itoa(days)
+	return nil
+}
+
+// PurgeExpiredTrash 永久删除在回收站中超过保留天数的条目，返回删除的数量
+func (s *Store) PurgeExpiredTrash() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.purgeExpiredTrash()
+}
+
+// purgeExpiredTrash 永久删除过期的回收站条目，调用方需持有锁
+func (s *Store) purgeExpiredTrash() (int, error) {
+	days, err := s.trashRetentionDays()
+	if err != nil || days == 0 {
+		return 0, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days)
+	var expired []*models.PasswordEntry
+	for _, entry := range s.entries {
+		if !entry.DeletedAt.IsZero() && entry.DeletedAt.Before(cutoff) {
+			expired = append(expired, entry)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+
+	for _, entry := range expired {
+		delete(s.entries, entry.ID)
+		if err := s.appendAudit(&models.AuditRecord{Action: models.AuditPurge, EntryID: entry.ID, Subject: entry.Title}); err != nil {
+			return 0, err
+		}
+	}
+	s.deleteUnusedTags()
+	return len(expired), nil
+}
+
+// SetFavorite 收藏或取消收藏条目，不改变条目的修改时间
+func (s *Store) SetFavorite(id int, favorite bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok || !entry.DeletedAt.IsZero() {
+		return sql.ErrNoRows
+	}
+	entry.Favorite = favorite
+	return nil
+}
+
+// RecordEntryUse 记录条目被查看或复制，更新最近使用时间和使用次数
+func (s *Store) RecordEntryUse(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[id]; ok {
+		entry.LastUsedAt = time.Now()
+		entry.UseCount++
+	}
+	return nil
+}
+
+// resolveTags 与 SQLite 实现一样规范化条目的标签，已有的标签使用第一次创建时的写法，调用方需持有锁
+func (s *Store) resolveTags(entry *models.PasswordEntry) {
+	entry.Tags = database.NormalizeTags(entry.Tags)
+	for i, name := range entry.Tags {
+		key := strings.ToLower(name)
+		tag, ok := s.tags[key]
+		if !ok {
+			tag = &models.Tag{ID: s.nextTagID, Name: name}
+			s.nextTagID++
+			s.tags[key] = tag
+		}
+		entry.Tags[i] = tag.Name
+	}
+}
+
+// deleteUnusedTags 删除没有条目使用的标签，调用方需持有锁
+func (s *Store) deleteUnusedTags() {
+	used := make(map[string]bool)
+	for _, entry := range s.entries {
+		for _, name := range entry.Tags {
+			used[strings.ToLower(name)] = true
+		}
+	}
+	for key := range s.tags {
+		if !used[key] {
+			delete(s.tags, key)
+		}
+	}
+}
+
+// GetTags 获取所有标签及回收站以外使用该标签的条目数，按名称排列
+func (s *Store) GetTags() ([]*models.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, entry := range s.entries {
+		if !entry.DeletedAt.IsZero() {
+			continue
+		}
+		for _, name := range entry.Tags {
+			counts[strings.ToLower(name)]++
+		}
+	}
+
+	var tags []*models.Tag
+	for key, tag := range s.tags {
+		copied := *tag
+		copied.EntryCount = counts[key]
+		tags = append(tags, &copied)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+	})
+	return tags, nil
+}
+
+// resolveCategory 与 SQLite 实现一样确定条目的文件夹：CategoryID 不为 0 时优先使用，
+// 否则按路径查找或创建文件夹，调用方需持有锁
+func (s *Store) resolveCategory(entry *models.PasswordEntry) error {
+	if entry.CategoryID != 0 {
+		category, ok := s.categories[entry.CategoryID]
+		if !ok {
+			return fmt.Errorf("category %d does not exist", entry.CategoryID)
+		}
+		entry.Category = category.Name
+		return nil
+	}
+
+	path := database.NormalizeCategoryPath(entry.Category)
+	entry.Category = path
+	if path != "" {
+		entry.CategoryID = s.ensureCategory(path).ID
+	}
+	return nil
+}
+
+// categoryByName 按路径查找文件夹，调用方需持有锁
+func (s *Store) categoryByName(name string) *models.Category {
+	for _, category := range s.categories {
+		if category.Name == name {
+			return category
+		}
+	}
+	return nil
+}
+
+// ensureCategory 返回路径对应的文件夹，不存在时连同上级一起创建，调用方需持有锁
+func (s *Store) ensureCategory(path string) *models.Category {
+	if category := s.categoryByName(path); category != nil {
+		return category
+	}
+
+	parentID := 0
+	if idx := strings.LastIndex(path, models.CategorySeparator); idx >= 0 {
+		parentID = s.ensureCategory(path[:idx]).ID
+	}
+	category := &models.Category{ID: s.nextCategoryID, Name: path, ParentID: parentID}
+	s.nextCategoryID++
+	s.categories[category.ID] = category
+	return category
+}
+
+// inSubtree 检查 path 是否为 root 或其子文件夹
+func inSubtree(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+models.CategorySeparator)
+}
+
+// subtree 返回文件夹及其所有子文件夹，调用方需持有锁
+func (s *Store) subtree(id int) []*models.Category {
+	root := s.categories[id].Name
+	var categories []*models.Category
+	for _, category := range s.categories {
+		if inSubtree(category.Name, root) {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// touchCategories 更新文件夹中回收站以外的条目的修改时间，调用方需持有锁
+func (s *Store) touchCategories(categories []*models.Category) {
+	now := time.Now()
+	for _, category := range categories {
+		for _, entry := range s.entries {
+			if entry.CategoryID == category.ID && entry.DeletedAt.IsZero() {
+				entry.UpdatedAt = now
+			}
+		}
+	}
+}
+
+// relocate 将文件夹改为新的路径，子文件夹的路径随之改变，调用方需持有锁
+func (s *Store) relocate(id int, newPath string) error {
+	category := s.categories[id]
+	oldPath := category.Name
+	if oldPath == newPath {
+		return nil
+	}
+	if strings.HasPrefix(newPath, oldPath+models.CategorySeparator) {
+		return fmt.Errorf("cannot move category %q into itself", oldPath)
+	}
+	if s.categoryByName(newPath) != nil {
+		return fmt.Errorf("category %q already exists", newPath)
+	}
+
+	category.ParentID = 0
+	if idx := strings.LastIndex(newPath, models.CategorySeparator); idx >= 0 {
+		category.ParentID = s.ensureCategory(newPath[:idx]).ID
+	}
+	subtree := s.subtree(id)
+	s.touchCategories(subtree)
+	for _, sub := range subtree {
+		sub.Name = newPath + sub.Name[len(oldPath):]
+	}
+	return nil
+}
+
+// GetCategories 获取所有文件夹及其直接包含的条目数，按树的先序排列
+func (s *Store) GetCategories() ([]*models.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var categories []*models.Category
+	for _, category := range s.categories {
+		copied := *category
+		copied.EntryCount = 0
+		for _, entry := range s.entries {
+			if entry.CategoryID == category.ID && entry.DeletedAt.IsZero() {
+				copied.EntryCount++
+			}
+		}
+		categories = append(categories, &copied)
+	}
+	database.SortCategories(categories)
+	return categories, nil
+}
+
+// AddCategory 添加文件夹，名称可以是路径，缺少的上级文件夹会一起创建
+func (s *Store) AddCategory(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := database.NormalizeCategoryPath(name)
+	if path == "" {
+		return fmt.Errorf("category name is empty")
+	}
+	if s.categoryByName(path) != nil {
+		return fmt.Errorf("category %q already exists", path)
+	}
+	s.ensureCategory(path)
+	return nil
+}
+
+// RenameCategory 重命名文件夹（只修改最后一级名称），子文件夹的路径随之改变
+func (s *Store) RenameCategory(id int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("category name is empty")
+	}
+	if strings.Contains(name, models.CategorySeparator) {
+		return fmt.Errorf("category name cannot contain %q", models.CategorySeparator)
+	}
+	category, ok := s.categories[id]
+	if !ok {
+		return fmt.Errorf("category %d does not exist", id)
+	}
+
+	newPath := name
+	if idx := strings.LastIndex(category.Name, models.CategorySeparator); idx >= 0 {
+		newPath = category.Name[:idx+1] + name
+	}
+	return s.relocate(id, newPath)
+}
+
+// MoveCategory 将文件夹连同其中的条目和子文件夹移到 parentID 下，parentID 为 0 时移到顶层
+func (s *Store) MoveCategory(id, parentID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return fmt.Errorf("category %d does not exist", id)
+	}
+	newPath := category.BaseName()
+	if parentID != 0 {
+		parent, ok := s.categories[parentID]
+		if !ok {
+			return fmt.Errorf("category %d does not exist", parentID)
+		}
+		newPath = parent.Name + models.CategorySeparator + newPath
+	}
+	return s.relocate(id, newPath)
+}
+
+// MergeCategories 将 sourceID 文件夹合并到 targetID 文件夹，子文件夹移到目标文件夹下，同名时继续合并
+func (s *Store) MergeCategories(sourceID, targetID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sourceID == targetID {
+		return fmt.Errorf("cannot merge category %d into itself", sourceID)
+	}
+	for _, id := range []int{sourceID, targetID} {
+		if _, ok := s.categories[id]; !ok {
+			return fmt.Errorf("category %d does not exist", id)
+		}
+	}
+	if source, target := s.categories[sourceID], s.categories[targetID]; inSubtree(target.Name, source.Name) {
+		return fmt.Errorf("cannot merge category %q into its subcategory %q", source.Name, target.Name)
+	}
+	return s.merge(sourceID, targetID)
+}
+
+// merge 递归地将文件夹合并到目标文件夹，调用方需持有锁
+func (s *Store) merge(sourceID, targetID int) error {
+	target := s.categories[targetID]
+	var children []*models.Category
+	for _, category := range s.categories {
+		if category.ParentID == sourceID {
+			children = append(children, category)
+		}
+	}
+	for _, child := range children {
+		newPath := target.Name + models.CategorySeparator + child.BaseName()
+		var err error
+		if existing := s.categoryByName(newPath); existing != nil {
+			err = s.merge(child.ID, existing.ID)
+		} else {
+			err = s.relocate(child.ID, newPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	s.touchCategories([]*models.Category{s.categories[sourceID]})
+	for _, entry := range s.entries {
+		if entry.CategoryID == sourceID {
+			entry.CategoryID = targetID
+		}
+	}
+	delete(s.categories, sourceID)
+	return nil
+}
+
+// DeleteCategory 删除文件夹及其子文件夹，其中的条目移入 reassignTo 文件夹，reassignTo 为 0 时条目变为未分类
+func (s *Store) DeleteCategory(id, reassignTo int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.categories[id]; !ok {
+		return fmt.Errorf("category %d does not exist", id)
+	}
+	subtree := s.subtree(id)
+	if reassignTo != 0 {
+		target, ok := s.categories[reassignTo]
+		if !ok {
+			return fmt.Errorf("category %d does not exist", reassignTo)
+		}
+		if inSubtree(target.Name, s.categories[id].Name) {
+			return fmt.Errorf("cannot move entries into a category being deleted")
+		}
+	}
+
+	s.touchCategories(subtree)
+	for _, category := range subtree {
+		for _, entry := range s.entries {
+			if entry.CategoryID == category.ID {
+				entry.CategoryID = reassignTo
+			}
+		}
+		delete(s.categories, category.ID)
+	}
+	return nil
+}
+
+// HasMasterPassword 检查是否已设置主密码
+func (s *Store) HasMasterPassword() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.passwordHash != nil, nil
+}
+
+// SetMasterPassword 为新密码库设置主密码并生成随机的密码库密钥
+func (s *Store) SetMasterPassword(password string) error {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash := sha256.Sum256([]byte(password))
+	s.passwordHash = hash[:]
+	s.vaultKey = key
+	s.key = key
+	return nil
+}
+
+// KeyFileRequired 检查解锁时是否需要密钥文件
+func (s *Store) KeyFileRequired() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keyFileRequired, nil
+}
+
+// FactorRequired 返回解锁所需的第二因素名称，未启用时返回空字符串
+func (s *Store) FactorRequired() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.factorName, nil
+}
+
+// verify 组合凭据并与保存的主密码比较，调用方需持有锁
+func (s *Store) verify(credentials database.Credentials) (bool, error) {
+	if s.passwordHash == nil {
+		return false, nil
+	}
+
+	var response []byte
+	if s.factorChallenge != nil {
+		if credentials.Factor == nil {
+			return false, fmt.Errorf("second factor required")
+		}
+		var err error
+		response, err = credentials.Factor.Respond(s.factorChallenge)
+		if err != nil {
+			return false, fmt.Errorf("second factor: %v", err)
+		}
+	}
+
+	hash := sha256.Sum256([]byte(crypto.CompositeKey(credentials.Password, credentials.KeyFile, response)))
+	return subtle.ConstantTimeCompare(hash[:], s.passwordHash) == 1, nil
+}
+
+// Unlock 验证凭据并设置密码库密钥
+func (s *Store) Unlock(credentials database.Credentials) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	valid, err := s.verify(credentials)
+	if err != nil || !valid {
+		return false, err
+	}
+	s.key = s.vaultKey
+
+	if err := s.appendAudit(&models.AuditRecord{Action: models.AuditUnlock}); err != nil {
+		return false, err
+	}
+	if _, err := s.purgeExpiredTrash(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// VerifyCredentials 验证凭据是否正确，不改变当前的密码库密钥
+func (s *Store) VerifyCredentials(credentials database.Credentials) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.verify(credentials)
+}
+
+// ChangeMasterPassword 修改主密码、密钥文件和第二因素，启用第二因素时会生成新的挑战值
+// 与 SQLite 实现一样，新启用密钥文件或第二因素时重新生成密码库密钥
+func (s *Store) ChangeMasterPassword(credentials database.Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkKey(); err != nil {
+		return err
+	}
+
+	var challenge, response []byte
+	factorName := ""
+	if credentials.Factor != nil {
+		challenge = make([]byte, factorChallengeSize)
+		if _, err := rand.Read(challenge); err != nil {
+			return err
+		}
+		var err error
+		response, err = credentials.Factor.Respond(challenge)
+		if err != nil {
+			return fmt.Errorf("second factor: %v", err)
+		}
+		factorName = credentials.Factor.Name()
+	}
+
+	if (len(credentials.KeyFile) > 0 && !s.keyFileRequired) || (factorName != "" && s.factorName == "") {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return err
+		}
+		s.key = key
+	}
+
+	hash := sha256.Sum256([]byte(crypto.CompositeKey(credentials.Password, credentials.KeyFile, response)))
+	s.passwordHash = hash[:]
+	s.vaultKey = s.key
+	s.keyFileRequired = len(credentials.KeyFile) > 0
+	s.factorName, s.factorChallenge = factorName, challenge
+	return nil
+}
+
+// SetMasterKey 设置主密钥，设为 nil 即锁定
+func (s *Store) SetMasterKey(key []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+}
+
+// GetKey 获取主密钥
+func (s *Store) GetKey() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.key
+}
+
+// GetSetting 获取设置项，不存在时返回空字符串
+func (s *Store) GetSetting(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings[key], nil
+}
+
+// SetSetting 保存设置项
+func (s *Store) SetSetting(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[key] = value
+	return nil
+}
+
+// GetSecretSetting 获取需要解锁才能读取的设置项，不存在时返回空字符串
+func (s *Store) GetSecretSetting(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkKey(); err != nil {
+		return "", err
+	}
+	return s.settings[key], nil
+}
+
+// SetSecretSetting 保存需要解锁才能读取的设置项，值为空时清除
+func (s *Store) SetSecretSetting(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkKey(); err != nil {
+		return err
+	}
+	s.settings[key] = value
+	return nil
+}
+
+// Close 关闭内存密码库，之后需要密钥的操作都会失败
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.key = nil
+	return nil
+}
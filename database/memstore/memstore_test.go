@@ -0,0 +1,400 @@
+package memstore
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/factor"
+	"hank.com/password_tool/models"
+)
+
+// forEachStore 对内存实现和 SQLite 实现执行同样的检查，保证两者行为一致
+func forEachStore(t *testing.T, fn func(t *testing.T, store database.VaultStore)) {
+	t.Run("memstore", func(t *testing.T) {
+		store := New()
+		if err := store.SetMasterPassword("pw"); err != nil {
+			t.Fatal(err)
+		}
+		fn(t, store)
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		db, err := database.NewDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := db.SetMasterPassword("pw"); err != nil {
+			t.Fatal(err)
+		}
+		fn(t, db)
+	})
+}
+
+func mustAdd(t *testing.T, store database.VaultStore, title, category string, tags ...string) *models.PasswordEntry {
+	t.Helper()
+	entry := &models.PasswordEntry{Title: title, Username: "user", Password: "secret", Category: category, Tags: tags}
+	if err := store.AddPasswordEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func entryByTitle(t *testing.T, store database.VaultStore, title string) *models.PasswordEntry {
+	t.Helper()
+	entries, err := store.GetPasswordEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Title == title {
+			return entry
+		}
+	}
+	return nil
+}
+
+// categoryIDs 返回文件夹路径到 ID 的映射和按顺序排列的路径
+func categoryIDs(t *testing.T, store database.VaultStore) (map[string]int, string) {
+	t.Helper()
+	categories, err := store.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]int{}
+	var names []string
+	for _, c := range categories {
+		ids[c.Name] = c.ID
+		names = append(names, c.Name)
+	}
+	return ids, strings.Join(names, ",")
+}
+
+func TestEntries(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store database.VaultStore) {
+		added := mustAdd(t, store, "github", " 工作 / git ", "Work", "work", " dev ")
+		if added.ID == 0 || added.UID == "" || added.Category != "工作/git" {
+			t.Fatalf("added entry = %+v", added)
+		}
+		mustAdd(t, store, "aws", "", "WORK")
+
+		// 返回的条目是副本
+		entry := entryByTitle(t, store, "github")
+		entry.Title = "changed"
+		if entryByTitle(t, store, "github") == nil {
+			t.Fatal("modifying a returned entry changed the store")
+		}
+		if got := strings.Join(entry.Tags, ","); got != "dev,Work" {
+			t.Errorf("tags = %s, want dev,Work", got)
+		}
+
+		// 更新不改变收藏和使用统计
+		entry = entryByTitle(t, store, "github")
+		if err := store.SetFavorite(entry.ID, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RecordEntryUse(entry.ID); err != nil {
+			t.Fatal(err)
+		}
+		entry.Password = "rotated"
+		entry.Tags = nil
+		if err := store.UpdatePasswordEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+		updated := entryByTitle(t, store, "github")
+		if updated.Password != "rotated" || !updated.Favorite || updated.UseCount != 1 || updated.UID != added.UID {
+			t.Errorf("updated entry = %+v", updated)
+		}
+
+		tags, err := store.GetTags()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var counts []string
+		for _, tag := range tags {
+			counts = append(counts, fmt.Sprintf("%s:%d", tag.Name, tag.EntryCount))
+		}
+		if got := strings.Join(counts, ","); got != "Work:1" {
+			t.Errorf("tags = %s, want Work:1", got)
+		}
+
+		// 回收站
+		if err := store.DeletePasswordEntry(updated.ID); err != nil {
+			t.Fatal(err)
+		}
+		if entryByTitle(t, store, "github") != nil {
+			t.Error("deleted entry still listed")
+		}
+		trash, err := store.GetDeletedPasswordEntries()
+		if err != nil || len(trash) != 1 || trash[0].Password != "rotated" {
+			t.Fatalf("GetDeletedPasswordEntries() = %v, %v", trash, err)
+		}
+		if err := store.RestorePasswordEntry(updated.ID); err != nil {
+			t.Fatal(err)
+		}
+		if entryByTitle(t, store, "github") == nil {
+			t.Error("restored entry not listed")
+		}
+		if err := store.PurgePasswordEntry(updated.ID); err == nil {
+			t.Error("PurgePasswordEntry() of an entry outside the trash succeeded")
+		}
+		if err := store.DeletePasswordEntry(updated.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.PurgePasswordEntry(updated.ID); err != nil {
+			t.Fatal(err)
+		}
+		if trash, err := store.GetDeletedPasswordEntries(); err != nil || len(trash) != 0 {
+			t.Errorf("trash after purge = %v, %v", trash, err)
+		}
+
+		// 锁定后不能读取条目
+		store.SetMasterKey(nil)
+		if _, err := store.GetPasswordEntries(); err == nil {
+			t.Error("GetPasswordEntries() succeeded while locked")
+		}
+		if err := store.AddPasswordEntry(&models.PasswordEntry{Title: "x"}); err == nil {
+			t.Error("AddPasswordEntry() succeeded while locked")
+		}
+	})
+}
+
+func TestRotationKeepsTypedExpiry(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store database.VaultStore) {
+		entry := &models.PasswordEntry{Title: "github", Password: "secret", RotationDays: 30}
+		if err := store.AddPasswordEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+		entry = entryByTitle(t, store, "github")
+		if entry.ExpiresAt.IsZero() {
+			t.Fatal("no expiry after adding an entry with a rotation period")
+		}
+
+		// 修改密码时同时填写的过期时间保持不变
+		typed := time.Now().AddDate(0, 0, 7).Truncate(time.Second)
+		entry.Password = "rotated"
+		entry.ExpiresAt = typed
+		if err := store.UpdatePasswordEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+		entry = entryByTitle(t, store, "github")
+		if !entry.ExpiresAt.Equal(typed) {
+			t.Errorf("ExpiresAt = %v, want the typed %v", entry.ExpiresAt, typed)
+		}
+
+		// 只修改密码时按轮换周期顺延
+		entry.Password = "rotated again"
+		if err := store.UpdatePasswordEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+		entry = entryByTitle(t, store, "github")
+		if entry.ExpiresAt.Before(time.Now().AddDate(0, 0, 29)) {
+			t.Errorf("ExpiresAt = %v, want about 30 days from now", entry.ExpiresAt)
+		}
+	})
+}
+
+func TestCategories(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store database.VaultStore) {
+		mustAdd(t, store, "aws", "工作/云")
+		mustAdd(t, store, "gcp", "工作/云/gcp")
+		mustAdd(t, store, "blog", "个人/云")
+		if err := store.AddCategory("工作/云"); err == nil {
+			t.Error("AddCategory() of an existing path succeeded")
+		}
+		if err := store.AddCategory("空"); err != nil {
+			t.Fatal(err)
+		}
+
+		ids, names := categoryIDs(t, store)
+		if names != "个人,个人/云,工作,工作/云,工作/云/gcp,空" {
+			t.Fatalf("categories = %s", names)
+		}
+
+		if err := store.RenameCategory(ids["工作/云"], "cloud"); err != nil {
+			t.Fatal(err)
+		}
+		if got := entryByTitle(t, store, "gcp").Category; got != "工作/cloud/gcp" {
+			t.Errorf("category after rename = %q", got)
+		}
+		if err := store.RenameCategory(ids["工作"], "a/b"); err == nil {
+			t.Error("RenameCategory() with a separator succeeded")
+		}
+		if err := store.MoveCategory(ids["工作"], ids["工作/云/gcp"]); err == nil {
+			t.Error("MoveCategory() into a subcategory succeeded")
+		}
+
+		// 合并时同名的子文件夹继续合并
+		if err := store.MoveCategory(ids["工作/云"], 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RenameCategory(ids["工作/云"], "云"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.MergeCategories(ids["个人"], ids["空"]); err != nil {
+			t.Fatal(err)
+		}
+		ids, names = categoryIDs(t, store)
+		if names != "云,云/gcp,工作,空,空/云" {
+			t.Fatalf("categories after merge = %s", names)
+		}
+		if got := entryByTitle(t, store, "blog").Category; got != "空/云" {
+			t.Errorf("category after merge = %q", got)
+		}
+
+		if err := store.DeleteCategory(ids["云"], ids["云/gcp"]); err == nil {
+			t.Error("DeleteCategory() into a deleted subcategory succeeded")
+		}
+		if err := store.DeleteCategory(ids["云"], ids["工作"]); err != nil {
+			t.Fatal(err)
+		}
+		if _, names := categoryIDs(t, store); names != "工作,空,空/云" {
+			t.Errorf("categories after delete = %s", names)
+		}
+		for _, title := range []string{"aws", "gcp"} {
+			if got := entryByTitle(t, store, title).Category; got != "工作" {
+				t.Errorf("%s: category after delete = %q", title, got)
+			}
+		}
+	})
+}
+
+func TestMasterPassword(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store database.VaultStore) {
+		if has, err := store.HasMasterPassword(); err != nil || !has {
+			t.Fatalf("HasMasterPassword() = %v, %v", has, err)
+		}
+		mustAdd(t, store, "github", "")
+		if err := store.SetSecretSetting("token", "s3cret"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetSetting("entry_sort", "usage"); err != nil {
+			t.Fatal(err)
+		}
+		key := store.GetKey()
+
+		// 只修改主密码时密钥不变
+		if err := store.ChangeMasterPassword(database.Credentials{Password: "new"}); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(store.GetKey(), key) {
+			t.Error("vault key changed on a password-only change")
+		}
+
+		store.SetMasterKey(nil)
+		if _, err := store.GetSecretSetting("token"); err == nil {
+			t.Error("GetSecretSetting() succeeded while locked")
+		}
+		if value, err := store.GetSetting("entry_sort"); err != nil || value != "usage" {
+			t.Errorf("GetSetting() while locked = %q, %v", value, err)
+		}
+		if ok, err := store.Unlock(database.Credentials{Password: "pw"}); ok || err != nil {
+			t.Errorf("Unlock() with old password = %v, %v", ok, err)
+		}
+		if ok, err := store.Unlock(database.Credentials{Password: "new"}); !ok || err != nil {
+			t.Fatalf("Unlock() = %v, %v", ok, err)
+		}
+		if value, err := store.GetSecretSetting("token"); err != nil || value != "s3cret" {
+			t.Errorf("GetSecretSetting() = %q, %v", value, err)
+		}
+
+		// 新启用密钥文件和第二因素时重新生成密钥，之后需要全部凭据才能解锁
+		keyFile, err := crypto.ParseKeyFile([]byte("key file contents"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, _, err := factor.GenerateSoftwareToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		credentials := database.Credentials{Password: "new", KeyFile: keyFile, Factor: token}
+		if err := store.ChangeMasterPassword(credentials); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(store.GetKey(), key) {
+			t.Error("vault key not regenerated after adding a key file and second factor")
+		}
+		if required, err := store.KeyFileRequired(); err != nil || !required {
+			t.Errorf("KeyFileRequired() = %v, %v", required, err)
+		}
+		if name, err := store.FactorRequired(); err != nil || name != token.Name() {
+			t.Errorf("FactorRequired() = %q, %v", name, err)
+		}
+		if ok, _ := store.VerifyCredentials(database.Credentials{Password: "new", KeyFile: keyFile}); ok {
+			t.Error("VerifyCredentials() without the second factor succeeded")
+		}
+		if ok, err := store.VerifyCredentials(credentials); !ok || err != nil {
+			t.Errorf("VerifyCredentials() = %v, %v", ok, err)
+		}
+
+		store.SetMasterKey(nil)
+		if ok, err := store.Unlock(credentials); !ok || err != nil {
+			t.Fatalf("Unlock() with all credentials = %v, %v", ok, err)
+		}
+		if entry := entryByTitle(t, store, "github"); entry == nil || entry.Password != "secret" {
+			t.Errorf("entry after rekey = %+v", entry)
+		}
+	})
+}
+
+func TestAuditLog(t *testing.T) {
+	store := New()
+	store.SetAuditClient(models.AuditClientCLI)
+	if err := store.SetMasterPassword("pw"); err != nil {
+		t.Fatal(err)
+	}
+	entry := mustAdd(t, store, "github", "")
+	if err := store.AppendAudit(&models.AuditRecord{Action: models.AuditCopy, EntryID: entry.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	records := store.AuditLog()
+	if len(records) != 2 {
+		t.Fatalf("AuditLog() = %d records, want 2", len(records))
+	}
+	if records[0].Action != models.AuditCreate || records[1].Action != models.AuditCopy || records[1].Client != models.AuditClientCLI {
+		t.Errorf("records = %+v, %+v", records[0], records[1])
+	}
+
+	// 关闭后不能再写入
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AppendAudit(&models.AuditRecord{Action: models.AuditCopy}); err == nil {
+		t.Error("AppendAudit() succeeded after Close()")
+	}
+}
+
+func TestTrashRetention(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store database.VaultStore) {
+		if days, err := store.TrashRetentionDays(); err != nil || days != database.DefaultTrashRetentionDays {
+			t.Fatalf("TrashRetentionDays() = %d, %v", days, err)
+		}
+		if err := store.SetTrashRetentionDays(-1); err == nil {
+			t.Error("SetTrashRetentionDays(-1) succeeded")
+		}
+		if err := store.SetTrashRetentionDays(7); err != nil {
+			t.Fatal(err)
+		}
+		if days, err := store.TrashRetentionDays(); err != nil || days != 7 {
+			t.Errorf("TrashRetentionDays() = %d, %v, want 7", days, err)
+		}
+
+		// 刚删除的条目未过期，不会被清除
+		entry := mustAdd(t, store, "github", "")
+		if err := store.DeletePasswordEntry(entry.ID); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := store.PurgeExpiredTrash(); err != nil || n != 0 {
+			t.Errorf("PurgeExpiredTrash() = %d, %v, want 0", n, err)
+		}
+		if trash, err := store.GetDeletedPasswordEntries(); err != nil || len(trash) != 1 {
+			t.Errorf("GetDeletedPasswordEntries() = %d, %v", len(trash), err)
+		}
+	})
+}
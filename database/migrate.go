@@ -0,0 +1,440 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 数据库结构的每次变更都以迁移的形式追加到 migrations 末尾，已发布的迁移不能修改。
+// 引入版本号之前的密码库没有 schema_version 表，视为版本 0，会从头执行所有迁移，
+// 因此迁移需要能在已经包含部分结构的旧密码库上执行（使用 IF NOT EXISTS 和 addColumn）。
+
+// migration 一次数据库结构变更
+type migration struct {
+	version     int
+	description string
+	up          func(tx *sql.Tx) error
+}
+
+// migrations 按版本排列的全部迁移
+var migrations = []migration{
+	{1, "initial schema", migrateInitialSchema},
+	{2, "ssh keys", migrateSSHKeys},
+	{3, "rendered files", migrateRenderedFiles},
+	{4, "sync", migrateSync},
+	{5, "wrapped vault key", migrateWrappedKey},
+	{6, "failed logins", migrateFailedLogins},
+	{7, "audit log", migrateAuditLog},
+	{8, "trash", migrateTrash},
+	{9, "category ids", migrateCategoryIDs},
+	{10, "nested folders", migrateNestedFolders},
+	{11, "tags", migrateTags},
+	{12, "favorites and usage", migrateFavorites},
+	{13, "password expiry", migratePasswordExpiry},
+	{14, "folder moves", migrateFolderMoves},
+	{15, "failed checks while unlocked", migrateUnlockedFailures},
+}
+
+// LatestSchemaVersion 返回当前程序支持的数据库结构版本
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaTooNewError 密码库由更新版本的程序创建，为避免损坏数据拒绝打开
+type SchemaTooNewError struct {
+	Version   int
+	Supported int
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("vault schema version %d is newer than supported version %d, please upgrade password_tool", e.Version, e.Supported)
+}
+
+// SchemaVersion 返回密码库当前的结构版本
+func (db *DB) SchemaVersion() (int, error) {
+	var version sql.NullInt64
+	if err := db.conn.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// migrate 将数据库升级到最新结构，升级前自动备份，每个迁移在单独的事务中执行
+func (db *DB) migrate() error {
+	_, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	latest := LatestSchemaVersion()
+	if current > latest {
+		return &SchemaTooNewError{Version: current, Supported: latest}
+	}
+	if current == latest {
+		return nil
+	}
+
+	// 已有密码库升级前先备份，升级出错时可以从备份恢复
+	var tables int
+	err = db.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='master_password'").Scan(&tables)
+	if err != nil {
+		return err
+	}
+	if tables > 0 {
+		if _, err := db.createBackup(); err != nil {
+			return fmt.Errorf("failed to back up vault before migration: %v", err)
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := db.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration 在事务中执行一个迁移并记录版本
+func (db *DB) applyMigration(m migration) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO schema_version (version, description, applied_at) VALUES (?, ?, ?)",
+		m.version, m.description, time.Now())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execAll 依次执行多条语句
+func execAll(tx *sql.Tx, queries ...string) error {
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn 在列不存在时为表添加列
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// migrateInitialSchema 最初版本的主密码、分类和密码条目表
+func migrateInitialSchema(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS master_password (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			password_hash TEXT NOT NULL,
+			salt TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS categories (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS password_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			username TEXT,
+			password TEXT NOT NULL,
+			url TEXT,
+			notes TEXT,
+			category TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+	)
+}
+
+// migrateSSHKeys SSH 密钥表
+func migrateSSHKeys(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS ssh_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			private_key TEXT NOT NULL,
+			public_key TEXT NOT NULL,
+			comment TEXT,
+			confirm INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+	)
+}
+
+// migrateRenderedFiles inject 命令生成的文件记录
+func migrateRenderedFiles(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS rendered_files (
+			path TEXT PRIMARY KEY,
+			checksum TEXT NOT NULL,
+			rendered_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+	)
+}
+
+// migrateSync 设置表、同步所需的删除记录和条目修订，并为已有条目生成同步标识和初始修订
+func migrateSync(tx *sql.Tx) error {
+	err := execAll(tx,
+		`CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS sync_tombstones (
+			uid TEXT PRIMARY KEY,
+			revisions TEXT NOT NULL,
+			exported INTEGER NOT NULL DEFAULT 0,
+			deleted_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS sync_seen (
+			name TEXT PRIMARY KEY
+		)`,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range []string{"uid", "revisions", "synced_revision"} {
+		if err := addColumn(tx, "password_entries", column, "TEXT"); err != nil {
+			return err
+		}
+	}
+
+	return execAll(tx,
+		`UPDATE password_entries SET uid = lower(hex(randomblob(16))) WHERE uid IS NULL`,
+		`UPDATE password_entries SET revisions = lower(hex(randomblob(16))) WHERE revisions IS NULL`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_password_entries_uid ON password_entries(uid)`,
+	)
+}
+
+// migrateWrappedKey 加密保存的随机密码库密钥
+func migrateWrappedKey(tx *sql.Tx) error {
+	return addColumn(tx, "master_password", "wrapped_key", "TEXT")
+}
+
+// migrateFailedLogins 失败的解锁尝试
+func migrateFailedLogins(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS failed_logins (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			attempted_at DATETIME NOT NULL
+		)`,
+	)
+}
+
+// migrateAuditLog 审计日志
+func migrateAuditLog(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY,
+			record TEXT NOT NULL,
+			mac TEXT NOT NULL
+		)`,
+	)
+}
+
+// migrateTrash 回收站
+func migrateTrash(tx *sql.Tx) error {
+	return addColumn(tx, "password_entries", "deleted_at", "DATETIME")
+}
+
+// migrateCategoryIDs 条目改为通过 category_id 引用分类表
+// 只为旧条目实际使用的分类名称创建分类记录，旧的 category 文本列不再使用并被清空
+func migrateCategoryIDs(tx *sql.Tx) error {
+	if err := addColumn(tx, "password_entries", "category_id", "INTEGER REFERENCES categories(id) ON DELETE SET NULL"); err != nil {
+		return err
+	}
+
+	return execAll(tx,
+		`INSERT OR IGNORE INTO categories (name)
+			SELECT DISTINCT trim(category) FROM password_entries WHERE trim(ifnull(category, '')) != ''`,
+		`UPDATE password_entries SET category_id = (SELECT id FROM categories WHERE name = trim(password_entries.category))
+			WHERE trim(ifnull(category, '')) != ''`,
+		`UPDATE password_entries SET category = NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_password_entries_category ON password_entries(category_id)`,
+	)
+}
+
+// migrateNestedFolders 分类改为可以嵌套的文件夹，分类名称保存完整路径，parent_id 指向上级文件夹
+// 名称中已经包含 / 的旧分类视为路径，缺少的上级文件夹会被创建
+func migrateNestedFolders(tx *sql.Tx) error {
+	if err := addColumn(tx, "categories", "parent_id", "INTEGER REFERENCES categories(id) ON DELETE CASCADE"); err != nil {
+		return err
+	}
+
+	type category struct {
+		id   int
+		name string
+	}
+	rows, err := tx.Query("SELECT id, name FROM categories WHERE instr(name, '/') > 0 ORDER BY length(name)")
+	if err != nil {
+		return err
+	}
+	var nested []category
+	for rows.Next() {
+		var c category
+		if err := rows.Scan(&c.id, &c.name); err != nil {
+			rows.Close()
+			return err
+		}
+		nested = append(nested, c)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	// ensure 返回路径对应的文件夹 ID，不存在时连同上级一起创建
+	var ensure func(path string) (int64, error)
+	ensure = func(path string) (int64, error) {
+		var id int64
+		err := tx.QueryRow("SELECT id FROM categories WHERE name=?", path).Scan(&id)
+		if err != sql.ErrNoRows {
+			return id, err
+		}
+
+		var parent sql.NullInt64
+		if idx := strings.LastIndex(path, "/"); idx > 0 {
+			if parent.Int64, err = ensure(path[:idx]); err != nil {
+				return 0, err
+			}
+			parent.Valid = true
+		}
+		result, err := tx.Exec("INSERT INTO categories (name, parent_id) VALUES (?, ?)", path, parent)
+		if err != nil {
+			return 0, err
+		}
+		return result.LastInsertId()
+	}
+
+	for _, c := range nested {
+		// 规范化路径中每一级的空白，规范化后与已有文件夹重名时合并到已有文件夹
+		name := NormalizeCategoryPath(c.name)
+		if name != c.name {
+			var existing sql.NullInt64
+			err := tx.QueryRow("SELECT id FROM categories WHERE name=?", name).Scan(&existing)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			if name == "" || existing.Valid {
+				if _, err := tx.Exec("UPDATE password_entries SET category_id=? WHERE category_id=?", existing, c.id); err != nil {
+					return err
+				}
+				if _, err := tx.Exec("DELETE FROM categories WHERE id=?", c.id); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := tx.Exec("UPDATE categories SET name=? WHERE id=?", name, c.id); err != nil {
+				return err
+			}
+		}
+
+		idx := strings.LastIndex(name, "/")
+		if idx <= 0 {
+			continue
+		}
+		parent, err := ensure(name[:idx])
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE categories SET parent_id=? WHERE id=?", parent, c.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateTags 标签，条目和标签通过 entry_tags 多对多关联，标签名称不区分大小写
+func migrateTags(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE
+		)`,
+		`CREATE TABLE IF NOT EXISTS entry_tags (
+			entry_id INTEGER NOT NULL REFERENCES password_entries(id) ON DELETE CASCADE,
+			tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			PRIMARY KEY (entry_id, tag_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_entry_tags_tag ON entry_tags(tag_id)`,
+	)
+}
+
+// migrateFavorites 收藏标记和使用统计，使用统计只在本机记录
+func migrateFavorites(tx *sql.Tx) error {
+	if err := addColumn(tx, "password_entries", "favorite", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumn(tx, "password_entries", "last_used_at", "DATETIME"); err != nil {
+		return err
+	}
+	return addColumn(tx, "password_entries", "use_count", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// migratePasswordExpiry 密码的过期时间和轮换周期
+func migratePasswordExpiry(tx *sql.Tx) error {
+	if err := addColumn(tx, "password_entries", "expires_at", "DATETIME"); err != nil {
+		return err
+	}
+	return addColumn(tx, "password_entries", "rotation_days", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// migrateFolderMoves 记录条目因文件夹移动、合并或删除而离开的文件夹，同步到其他设备后删除变空的旧文件夹
+func migrateFolderMoves(tx *sql.Tx) error {
+	return addColumn(tx, "password_entries", "moved_from", "TEXT")
+}
+
+// migrateUnlockedFailures 标记解锁期间验证凭据失败的记录，这些记录只参与退避，不计入清除上限
+func migrateUnlockedFailures(tx *sql.Tx) error {
+	return addColumn(tx, "failed_logins", "while_unlocked", "INTEGER NOT NULL DEFAULT 0")
+}
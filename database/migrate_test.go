@@ -0,0 +1,211 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hank.com/password_tool/crypto"
+)
+
+const fixturePassword = "pw"
+
+// newFixtureDB 创建结构版本为 version 的密码库，version 为 0 时是引入版本号之前的结构
+// 数据在最初的结构下写入，再执行前 version 个迁移，与旧版本用户的密码库一致
+func newFixtureDB(t *testing.T, version int) *DB {
+	t.Helper()
+	dir := t.TempDir()
+	conn, err := openConn(filepath.Join(dir, "passwords.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &DB{conn: conn, dir: dir}
+	t.Cleanup(func() { db.Close() })
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateInitialSchema(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 早期版本的密钥直接由主密码派生
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := crypto.DeriveKey(fixturePassword, salt)
+	password, err := crypto.Encrypt([]byte("secret"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, conn, "INSERT INTO master_password (id, password_hash, salt) VALUES (1, ?, ?)",
+		crypto.HashMasterPassword(fixturePassword, salt), base64.StdEncoding.EncodeToString(salt))
+	for _, name := range []string{"工作", "工作/aws", "个人"} {
+		mustExec(t, conn, "INSERT INTO categories (name) VALUES (?)", name)
+	}
+	// 旧版本写入条目时所有字段都有值，没有填写的字段为空字符串
+	for _, entry := range [][]string{
+		{"github", "octocat", "https://github.com", "工作"},
+		{"aws", "admin", "", "工作/aws"},
+		{"wifi", "", "", ""},
+	} {
+		mustExec(t, conn, "INSERT INTO password_entries (title, username, password, url, notes, category) VALUES (?, ?, ?, ?, '', ?)",
+			entry[0], entry[1], password, entry[2], entry[3])
+	}
+
+	if version > 0 {
+		withMigrations(t, migrations[:version], func() {
+			if err := db.migrate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if v, err := db.SchemaVersion(); err != nil || v != version {
+			t.Fatalf("fixture version = %d, %v, want %d", v, err, version)
+		}
+	}
+	return db
+}
+
+// withMigrations 在 fn 执行期间使用指定的迁移列表
+func withMigrations(t *testing.T, list []migration, fn func()) {
+	t.Helper()
+	saved := migrations
+	migrations = list
+	defer func() { migrations = saved }()
+	fn()
+}
+
+func mustExec(t *testing.T, conn *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := conn.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+func TestMigrateFromEveryVersion(t *testing.T) {
+	latest := LatestSchemaVersion()
+	for version := 0; version < latest; version++ {
+		version := version
+		t.Run(migrationName(version), func(t *testing.T) {
+			db := newFixtureDB(t, version)
+			// 设置表从版本 4 开始存在
+			if version >= 4 {
+				if err := db.SetSetting("entry_sort", "usage"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := db.migrate(); err != nil {
+				t.Fatal(err)
+			}
+			if v, err := db.SchemaVersion(); err != nil || v != latest {
+				t.Fatalf("SchemaVersion() = %d, %v, want %d", v, err, latest)
+			}
+
+			ok, err := db.Unlock(Credentials{Password: fixturePassword})
+			if err != nil || !ok {
+				t.Fatalf("Unlock() = %v, %v", ok, err)
+			}
+			entries, err := db.GetPasswordEntries()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, entry := range entries {
+				if entry.Password != "secret" {
+					t.Errorf("%s: password = %q", entry.Title, entry.Password)
+				}
+				got[entry.Title] = entry.Category
+			}
+			want := map[string]string{"github": "工作", "aws": "工作/aws", "wifi": ""}
+			if len(got) != len(want) {
+				t.Fatalf("entries = %v, want %v", got, want)
+			}
+			for title, category := range want {
+				if got[title] != category {
+					t.Errorf("%s: category = %q, want %q", title, got[title], category)
+				}
+			}
+
+			categories, err := db.GetCategories()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, c := range categories {
+				names = append(names, c.Name)
+			}
+			if strings.Join(names, ",") != "个人,工作,工作/aws" {
+				t.Errorf("categories = %v", names)
+			}
+
+			if version >= 4 {
+				if value, err := db.GetSetting("entry_sort"); err != nil || value != "usage" {
+					t.Errorf("setting = %q, %v", value, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateSchemaTooNew(t *testing.T) {
+	db := newFixtureDB(t, LatestSchemaVersion())
+	mustExec(t, db.conn, "INSERT INTO schema_version (version, description, applied_at) VALUES (?, 'future', CURRENT_TIMESTAMP)",
+		LatestSchemaVersion()+1)
+
+	err := db.migrate()
+	var tooNew *SchemaTooNewError
+	if !errors.As(err, &tooNew) {
+		t.Fatalf("migrate() = %v, want *SchemaTooNewError", err)
+	}
+	if tooNew.Version != LatestSchemaVersion()+1 || tooNew.Supported != LatestSchemaVersion() {
+		t.Errorf("error = %+v", tooNew)
+	}
+}
+
+func TestMigrateRollsBackFailedMigration(t *testing.T) {
+	db := newFixtureDB(t, LatestSchemaVersion())
+	latest := LatestSchemaVersion()
+
+	failing := migration{latest + 1, "failing", func(tx *sql.Tx) error {
+		if err := execAll(tx,
+			`CREATE TABLE half_done (id INTEGER PRIMARY KEY)`,
+			`UPDATE password_entries SET title = 'changed'`,
+		); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	}}
+	withMigrations(t, append(append([]migration{}, migrations...), failing), func() {
+		if err := db.migrate(); err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Fatalf("migrate() = %v, want failure", err)
+		}
+	})
+
+	if v, err := db.SchemaVersion(); err != nil || v != latest {
+		t.Errorf("SchemaVersion() = %d, %v, want %d", v, err, latest)
+	}
+	var tables int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name='half_done'").Scan(&tables); err != nil || tables != 0 {
+		t.Errorf("half_done tables = %d, %v", tables, err)
+	}
+	var changed int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM password_entries WHERE title='changed'").Scan(&changed); err != nil || changed != 0 {
+		t.Errorf("changed entries = %d, %v", changed, err)
+	}
+}
+
+func migrationName(version int) string {
+	if version == 0 {
+		return "baseline"
+	}
+	return strings.ReplaceAll(migrations[version-1].description, " ", "_")
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+
+	"hank.com/password_tool/crypto"
+)
+
+// 恢复份额相关的设置项
+const (
+	recoveryCheckSetting     = "recovery_key_check"
+	recoveryThresholdSetting = "recovery_threshold"
+	// recoveryKeySetting 用恢复密钥加密的密码库密钥
+	recoveryKeySetting = "recovery_wrapped_key"
+)
+
+// CreateRecoveryShares 将密码库密钥拆分为 n 个恢复份额，任意 k 个可以恢复，返回各份额的单词形式
+// 重新生成后旧的份额仍然有效，直到密码库密钥改变
+func (db *DB) CreateRecoveryShares(n, k int) ([]string, error) {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return nil, fmt.Errorf("master key not set")
+	}
+
+	shares, err := crypto.SplitSecret(vaultKey, n, k)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]string, len(shares))
+	for i, share := range shares {
+		words[i] = crypto.EncodeShareWords(share)
+	}
+
+	if err := db.SetSetting(recoveryCheckSetting, crypto.KeyCheck(vaultKey)); err != nil {
+		return nil, err
+	}
+	if err := db.SetSetting(recoveryThresholdSetting, strconv.Itoa(k)); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// RecoveryThreshold 返回恢复所需的份额数，未启用恢复份额时返回 0
+func (db *DB) RecoveryThreshold() (int, error) {
+	value, err := db.GetSetting(recoveryThresholdSetting)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// RecoverWithShares 从恢复份额还原密码库密钥，成功后需要调用 ChangeMasterPassword 设置新主密码
+func (db *DB) RecoverWithShares(shareWords []string) error {
+	check, err := db.GetSetting(recoveryCheckSetting)
+	if err != nil {
+		return err
+	}
+	if check == "" {
+		return fmt.Errorf("recovery shares are not enabled for this vault")
+	}
+
+	shares := make([]crypto.Share, 0, len(shareWords))
+	for i, words := range shareWords {
+		share, err := crypto.DecodeShareWords(words)
+		if err != nil {
+			return fmt.Errorf("share %d: %v", i+1, err)
+		}
+		shares = append(shares, share)
+	}
+
+	key, err := crypto.CombineShares(shares)
+	if err != nil {
+		return err
+	}
+	if crypto.KeyCheck(key) != check {
+		return fmt.Errorf("recovered key does not match this vault")
+	}
+
+	db.setKey(key)
+	return nil
+}
+
+// CreateRecoveryKey 生成新的恢复密钥并用它加密保存密码库密钥，旧的恢复密钥随之失效
+func (db *DB) CreateRecoveryKey() (string, error) {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return "", fmt.Errorf("master key not set")
+	}
+
+	recoveryKey, err := crypto.GenerateRecoveryKey()
+	if err != nil {
+		return "", err
+	}
+	wrappingKey, err := crypto.DeriveRecoveryWrappingKey(recoveryKey)
+	if err != nil {
+		return "", err
+	}
+	wrappedKey, err := crypto.Encrypt(vaultKey, wrappingKey)
+	if err != nil {
+		return "", err
+	}
+
+	if err := db.SetSetting(recoveryKeySetting, wrappedKey); err != nil {
+		return "", err
+	}
+	return recoveryKey, nil
+}
+
+// HasRecoveryKey 检查是否已生成恢复密钥
+func (db *DB) HasRecoveryKey() (bool, error) {
+	wrappedKey, err := db.GetSetting(recoveryKeySetting)
+	return wrappedKey != "", err
+}
+
+// RecoverWithRecoveryKey 使用恢复密钥还原密码库密钥
+// 成功后需要调用 ChangeMasterPassword 设置新主密码，并调用 CreateRecoveryKey 使已用过的恢复密钥失效
+func (db *DB) RecoverWithRecoveryKey(recoveryKey string) error {
+	wrappedKey, err := db.GetSetting(recoveryKeySetting)
+	if err != nil {
+		return err
+	}
+	if wrappedKey == "" {
+		return fmt.Errorf("recovery key is not enabled for this vault")
+	}
+
+	wrappingKey, err := crypto.DeriveRecoveryWrappingKey(recoveryKey)
+	if err != nil {
+		return err
+	}
+	key, err := crypto.Decrypt(wrappedKey, wrappingKey)
+	if err != nil {
+		return fmt.Errorf("incorrect recovery key")
+	}
+
+	db.setKey(key)
+	return nil
+}
@@ -0,0 +1,269 @@
+package database
+
+import (
+	"crypto/hmac"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"hank.com/password_tool/crypto"
+)
+
+// 添加密钥文件或第二因素时重新生成密码库密钥：旧密钥可能已经随旧的备份、恢复密钥、恢复份额
+// 或仅凭主密码就能解开的旧 wrapped_key 泄露，只重新加密密钥无法阻止用这些材料解密。
+// 换成新密钥后，所有由密钥加密或认证的数据在同一事务中重新处理，旧的备份、恢复密钥和恢复份额随之失效；
+// 所有条目以新修订重新导出同步记录，交换位置上用旧密钥加密的记录被逐条取代。
+
+// rekey 在事务中将所有使用旧密钥加密或认证的数据换成新密钥
+func (db *DB) rekey(tx *sql.Tx, oldKey, newKey []byte) error {
+	if err := reencryptColumn(tx, "password_entries", "password", oldKey, newKey); err != nil {
+		return err
+	}
+	if err := reencryptColumn(tx, "ssh_keys", "private_key", oldKey, newKey); err != nil {
+		return err
+	}
+
+	// 恢复密钥和恢复份额还原出的是旧密钥，清除后需要重新生成
+	if _, err := tx.Exec("DELETE FROM settings WHERE key IN (?, ?, ?)",
+		recoveryKeySetting, recoveryCheckSetting, recoveryThresholdSetting); err != nil {
+		return err
+	}
+	if err := rekeySettings(tx, oldKey, newKey); err != nil {
+		return err
+	}
+	if err := rekeyAuditLog(tx, oldKey, newKey); err != nil {
+		return err
+	}
+	if err := rekeyRenderedFiles(tx, oldKey, newKey); err != nil {
+		return err
+	}
+	return resetSyncState(tx)
+}
+
+// resetSyncState 让所有条目和删除记录以新的修订重新导出，取代交换位置上用旧密钥加密的记录，
+// 并清除同步游标和已处理的记录，下次同步时重新拉取全部记录
+func resetSyncState(tx *sql.Tx) error {
+	// 同步游标由 vaultsync 以 sync_cursor:交换位置 为键保存
+	if _, err := tx.Exec("DELETE FROM settings WHERE key LIKE 'sync_cursor:%'"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM sync_seen"); err != nil {
+		return err
+	}
+	if err := appendRevisions(tx, "password_entries", "id", "deleted_at IS NULL"); err != nil {
+		return err
+	}
+	if err := appendRevisions(tx, "sync_tombstones", "uid", "1"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE password_entries SET synced_revision=NULL"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE sync_tombstones SET exported=0")
+	return err
+}
+
+// appendRevisions 为表中满足条件的每一行追加一个新修订
+func appendRevisions(tx *sql.Tx, table, idColumn, where string) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT %s, revisions FROM %s WHERE %s", idColumn, table, where))
+	if err != nil {
+		return err
+	}
+	revisions := map[string]string{}
+	for rows.Next() {
+		var id, list string
+		if err := rows.Scan(&id, &list); err != nil {
+			rows.Close()
+			return err
+		}
+		revisions[id] = list
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for id, list := range revisions {
+		query := fmt.Sprintf("UPDATE %s SET revisions=? WHERE %s=?", table, idColumn)
+		if _, err := tx.Exec(query, appendRevision(list, newSyncID()), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reencryptColumn 用新密钥重新加密表中按 id 标识的一列
+func reencryptColumn(tx *sql.Tx, table, column string, oldKey, newKey []byte) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT id, %s FROM %s", column, table))
+	if err != nil {
+		return err
+	}
+	values := map[int]string{}
+	for rows.Next() {
+		var id int
+		var encrypted string
+		if err := rows.Scan(&id, &encrypted); err != nil {
+			rows.Close()
+			return err
+		}
+		values[id] = encrypted
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for id, encrypted := range values {
+		reencrypted, err := reencrypt(encrypted, oldKey, newKey)
+		if err != nil {
+			return fmt.Errorf("%s %d: %v", table, id, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s=? WHERE id=?", table, column), reencrypted, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rekeySettings 重新加密 SetSecretSetting 保存的设置项
+// 设置表不区分是否加密，使用认证加密，能用旧密钥解密的值就是加密保存的设置
+func rekeySettings(tx *sql.Tx, oldKey, newKey []byte) error {
+	rows, err := tx.Query("SELECT key, value FROM settings WHERE value != ''")
+	if err != nil {
+		return err
+	}
+	secrets := map[string][]byte{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		if plaintext, err := crypto.Decrypt(value, oldKey); err == nil {
+			secrets[key] = plaintext
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for key, plaintext := range secrets {
+		encrypted, err := crypto.Encrypt(plaintext, newKey)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE settings SET value=? WHERE key=?", encrypted, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rekeyAuditLog 重新加密审计日志并用新的审计密钥重建认证码链，调用前需要先校验日志完整
+func rekeyAuditLog(tx *sql.Tx, oldKey, newKey []byte) error {
+	rows, err := tx.Query("SELECT id, record FROM audit_log ORDER BY id")
+	if err != nil {
+		return err
+	}
+	var ids []int
+	var records []string
+	for rows.Next() {
+		var id int
+		var encrypted string
+		if err := rows.Scan(&id, &encrypted); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		records = append(records, encrypted)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	auditKey := crypto.DeriveAuditKey(newKey)
+	lastMAC := ""
+	for i, id := range ids {
+		encrypted, err := reencrypt(records[i], oldKey, newKey)
+		if err != nil {
+			return fmt.Errorf("audit record %d: %v", id, err)
+		}
+		mac := crypto.AuditMAC(auditKey, lastMAC, id, encrypted)
+		if _, err := tx.Exec("UPDATE audit_log SET record=?, mac=? WHERE id=?", encrypted, mac, id); err != nil {
+			return err
+		}
+		lastMAC = mac
+	}
+
+	lastID := ids[len(ids)-1]
+	head := fmt.Sprintf("%d:%s:%s", lastID, lastMAC, crypto.AuditMAC(auditKey, lastMAC, lastID, "head"))
+	_, err = tx.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", auditHeadSetting, head)
+	return err
+}
+
+// rekeyRenderedFiles 为磁盘上仍与记录一致的渲染文件重新计算认证码
+// 已被修改或已删除的文件保留原记录，重新渲染时仍会提示被修改
+func rekeyRenderedFiles(tx *sql.Tx, oldKey, newKey []byte) error {
+	rows, err := tx.Query("SELECT path, checksum FROM rendered_files")
+	if err != nil {
+		return err
+	}
+	checksums := map[string]string{}
+	for rows.Next() {
+		var path, checksum string
+		if err := rows.Scan(&path, &checksum); err != nil {
+			rows.Close()
+			return err
+		}
+		checksums[path] = checksum
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for path, checksum := range checksums {
+		content, err := os.ReadFile(path)
+		if err != nil || !hmac.Equal([]byte(checksum), []byte(crypto.RenderedFileMAC(oldKey, content))) {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE rendered_files SET checksum=? WHERE path=?", crypto.RenderedFileMAC(newKey, content), path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reencrypt 用旧密钥解密后再用新密钥加密
+func reencrypt(encrypted string, oldKey, newKey []byte) (string, error) {
+	plaintext, err := crypto.Decrypt(encrypted, oldKey)
+	if err != nil {
+		return "", err
+	}
+	return crypto.Encrypt(plaintext, newKey)
+}
+
+// replaceBackups 删除包含旧密钥的自动备份和恢复前的副本，再用新密钥创建一份备份
+func (db *DB) replaceBackups() error {
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+
+	if err := db.removeBackups(); err != nil {
+		return err
+	}
+	_, err := db.createBackup()
+	return err
+}
+
+// removeBackups 删除自动备份和恢复前的副本，调用方需持有 backupMu
+func (db *DB) removeBackups() error {
+	os.Remove(db.Path() + ".restore")
+	return os.RemoveAll(db.BackupDir())
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"crypto/hmac"
+	"database/sql"
+	"time"
+
+	"hank.com/password_tool/crypto"
+)
+
+// CheckRenderedFile 检查文件是否由模板渲染生成且生成后未被修改
+// 文件不是由本工具生成时 generated 为 false
+func (db *DB) CheckRenderedFile(path string, content []byte) (generated, unchanged bool, err error) {
+	vaultKey := db.currentKey()
+	var checksum string
+	err = db.conn.QueryRow("SELECT checksum FROM rendered_files WHERE path=?", path).Scan(&checksum)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+
+	return true, hmac.Equal([]byte(checksum), []byte(crypto.RenderedFileMAC(vaultKey, content))), nil
+}
+
+// SetRenderedFile 记录模板渲染生成的文件及其认证码
+func (db *DB) SetRenderedFile(path string, content []byte) error {
+	vaultKey := db.currentKey()
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO rendered_files (path, checksum, rendered_at)
+		VALUES (?, ?, ?)`,
+		path, crypto.RenderedFileMAC(vaultKey, content), time.Now())
+	return err
+}
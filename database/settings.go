@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"hank.com/password_tool/crypto"
+)
+
+// GetSetting 获取设置项，不存在时返回空字符串
+func (db *DB) GetSetting(key string) (string, error) {
+	var value string
+	err := db.conn.QueryRow("SELECT value FROM settings WHERE key=?", key).Scan(&value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return value, nil
+}
+
+// SetSetting 保存设置项
+func (db *DB) SetSetting(key, value string) error {
+	_, err := db.conn.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, value)
+	return err
+}
+
+// GetSecretSetting 获取使用主密钥加密保存的设置项，不存在时返回空字符串
+func (db *DB) GetSecretSetting(key string) (string, error) {
+	vaultKey := db.currentKey()
+	encrypted, err := db.GetSetting(key)
+	if err != nil || encrypted == "" {
+		return "", err
+	}
+
+	value, err := crypto.Decrypt(encrypted, vaultKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt setting %s: %v", key, err)
+	}
+	return string(value), nil
+}
+
+// SetSecretSetting 使用主密钥加密后保存设置项，值为空时清除
+func (db *DB) SetSecretSetting(key, value string) error {
+	vaultKey := db.currentKey()
+	if value == "" {
+		return db.SetSetting(key, "")
+	}
+
+	encrypted, err := crypto.Encrypt([]byte(value), vaultKey)
+	if err != nil {
+		return err
+	}
+	return db.SetSetting(key, encrypted)
+}
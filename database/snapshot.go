@@ -0,0 +1,26 @@
+package database
+
+import (
+	"os"
+)
+
+// Snapshot 使用 VACUUM INTO 将密码库完整复制到 path，条目仍为加密状态
+// 可以在后台 goroutine 中调用，与 RestoreBackup 互斥
+func (db *DB) Snapshot(path string) error {
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+	return db.snapshot(path)
+}
+
+// snapshot 复制密码库，调用方需持有 backupMu
+func (db *DB) snapshot(path string) error {
+	// VACUUM INTO 要求目标文件不存在
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if _, err := db.conn.Exec("VACUUM INTO ?", path); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
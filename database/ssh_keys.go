@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/models"
+)
+
+// AddSSHKey 添加SSH密钥，私钥使用主密钥加密后存储
+func (db *DB) AddSSHKey(key *models.SSHKey, privateKey []byte) error {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return fmt.Errorf("master key not set")
+	}
+
+	encryptedKey, err := crypto.Encrypt(privateKey, vaultKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.conn.Exec(`
+		INSERT INTO ssh_keys (name, private_key, public_key, comment, confirm, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		key.Name, encryptedKey, key.PublicKey, key.Comment, key.Confirm, time.Now())
+
+	return err
+}
+
+// GetSSHKeys 获取所有SSH密钥（不包含私钥）
+func (db *DB) GetSSHKeys() ([]*models.SSHKey, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, public_key, comment, confirm, created_at
+		FROM ssh_keys ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.SSHKey
+	for rows.Next() {
+		key := &models.SSHKey{}
+		err := rows.Scan(&key.ID, &key.Name, &key.PublicKey, &key.Comment, &key.Confirm, &key.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// GetSSHPrivateKey 获取并解密指定SSH密钥的私钥
+func (db *DB) GetSSHPrivateKey(id int) ([]byte, error) {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return nil, fmt.Errorf("master key not set")
+	}
+
+	var encryptedKey string
+	err := db.conn.QueryRow("SELECT private_key FROM ssh_keys WHERE id=?", id).Scan(&encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Decrypt(encryptedKey, vaultKey)
+}
+
+// SetSSHKeyConfirm 设置SSH密钥是否需要每次使用前确认
+func (db *DB) SetSSHKeyConfirm(id int, confirm bool) error {
+	_, err := db.conn.Exec("UPDATE ssh_keys SET confirm=? WHERE id=?", confirm, id)
+	return err
+}
+
+// DeleteSSHKey 删除SSH密钥
+func (db *DB) DeleteSSHKey(id int) error {
+	_, err := db.conn.Exec("DELETE FROM ssh_keys WHERE id=?", id)
+	return err
+}
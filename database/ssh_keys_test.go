@@ -0,0 +1,37 @@
+package database
+
+import (
+	"sync"
+	"testing"
+
+	"hank.com/password_tool/models"
+)
+
+func TestGetSSHPrivateKeyWhileLocking(t *testing.T) {
+	db := newUnlockedDB(t)
+	if err := db.AddSSHKey(&models.SSHKey{Name: "id", PublicKey: "ssh-ed25519 AAAA"}, []byte("private")); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := db.GetSSHKeys()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("GetSSHKeys() = %v, %v", keys, err)
+	}
+
+	// agent 的连接 goroutine 签名时，界面锁定并清除密钥
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if private, err := db.GetSSHPrivateKey(keys[0].ID); err == nil && string(private) != "private" {
+				t.Errorf("GetSSHPrivateKey() = %q", private)
+			}
+		}
+	}()
+	db.SetMasterKey(nil)
+	wg.Wait()
+
+	if _, err := db.GetSSHPrivateKey(keys[0].ID); err == nil {
+		t.Error("GetSSHPrivateKey() succeeded after locking")
+	}
+}
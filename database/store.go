@@ -0,0 +1,57 @@
+package database
+
+import "hank.com/password_tool/models"
+
+// VaultStore 密码库的条目、分类、主密码和设置操作
+// DB 是基于 SQLite 的实现，database/memstore 提供不读写磁盘的内存实现，
+// 图形界面、命令行和导入逻辑只依赖这些操作时应接受 VaultStore，便于在测试中替换。
+// 输错退避和清除上限、恢复密钥和恢复份额不在其中：失败记录需要在进程之间持久保存，
+// 清除和恢复还要处理磁盘上的自动备份，内存实现无从模拟，这些功能只使用 DB
+type VaultStore interface {
+	// 条目
+	AddPasswordEntry(entry *models.PasswordEntry) error
+	GetPasswordEntries() ([]*models.PasswordEntry, error)
+	UpdatePasswordEntry(entry *models.PasswordEntry) error
+	DeletePasswordEntry(id int) error
+	GetDeletedPasswordEntries() ([]*models.PasswordEntry, error)
+	RestorePasswordEntry(id int) error
+	PurgePasswordEntry(id int) error
+	TrashRetentionDays() (int, error)
+	SetTrashRetentionDays(days int) error
+	PurgeExpiredTrash() (int, error)
+	SetFavorite(id int, favorite bool) error
+	RecordEntryUse(id int) error
+
+	// 分类（文件夹）
+	GetCategories() ([]*models.Category, error)
+	AddCategory(name string) error
+	RenameCategory(id int, name string) error
+	MoveCategory(id, parentID int) error
+	MergeCategories(sourceID, targetID int) error
+	DeleteCategory(id, reassignTo int) error
+
+	// 标签
+	GetTags() ([]*models.Tag, error)
+
+	// 主密码和解锁
+	HasMasterPassword() (bool, error)
+	SetMasterPassword(password string) error
+	KeyFileRequired() (bool, error)
+	FactorRequired() (string, error)
+	Unlock(credentials Credentials) (bool, error)
+	VerifyCredentials(credentials Credentials) (bool, error)
+	ChangeMasterPassword(credentials Credentials) error
+	SetMasterKey(key []byte)
+	GetKey() []byte
+
+	// 设置
+	GetSetting(key string) (string, error)
+	SetSetting(key, value string) error
+	GetSecretSetting(key string) (string, error)
+	SetSecretSetting(key, value string) error
+
+	AppendAudit(record *models.AuditRecord) error
+	Close() error
+}
+
+var _ VaultStore = (*DB)(nil)
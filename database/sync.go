@@ -0,0 +1,319 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/models"
+)
+
+// 每个条目保留的修订历史数量
+const maxRevisions = 64
+
+// newSyncID 生成随机的同步标识，用于条目 UID 和修订号
+func newSyncID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// splitRevisions 解析以逗号分隔的修订历史
+func splitRevisions(revisions string) []string {
+	if revisions == "" {
+		return nil
+	}
+	return strings.Split(revisions, ",")
+}
+
+// joinRevisions 将修订历史拼接为字符串，超出上限时丢弃最早的修订
+func joinRevisions(list []string) string {
+	if len(list) > maxRevisions {
+		list = list[len(list)-maxRevisions:]
+	}
+	return strings.Join(list, ",")
+}
+
+// appendRevision 在修订历史末尾追加新修订
+func appendRevision(revisions, revision string) string {
+	return joinRevisions(append(splitRevisions(revisions), revision))
+}
+
+// DeviceID 返回本机的设备标识，首次调用时生成
+// 标识保存在数据库之外的文件中，复制数据库到其他设备时不会带走
+func (db *DB) DeviceID() (string, error) {
+	path := filepath.Join(db.dir, "device_id")
+	data, err := os.ReadFile(path)
+	if err == nil && len(data) > 0 {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	device := newSyncID()
+	if err := os.WriteFile(path, []byte(device), 0600); err != nil {
+		return "", err
+	}
+	return device, nil
+}
+
+// GetPendingSyncRecords 获取本机尚未导出的变更记录
+func (db *DB) GetPendingSyncRecords(device string) ([]*models.SyncRecord, error) {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return nil, fmt.Errorf("master key not set")
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT e.id, e.uid, e.revisions, e.title, e.username, e.password, e.url, e.notes, COALESCE(c.name, ''),
+			e.favorite, e.expires_at, e.rotation_days, e.created_at, e.updated_at, COALESCE(e.moved_from, '')
+		FROM password_entries e LEFT JOIN categories c ON c.id = e.category_id
+		WHERE e.deleted_at IS NULL AND (e.synced_revision IS NULL OR e.revisions NOT LIKE '%' || e.synced_revision)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*models.SyncRecord
+	var entries []*models.PasswordEntry
+	for rows.Next() {
+		entry := &models.PasswordEntry{}
+		var revisions, encryptedPassword, movedFrom string
+		var expiresAt sql.NullTime
+		err := rows.Scan(&entry.ID, &entry.UID, &revisions, &entry.Title, &entry.Username, &encryptedPassword,
+			&entry.URL, &entry.Notes, &entry.Category, &entry.Favorite, &expiresAt, &entry.RotationDays,
+			&entry.CreatedAt, &entry.UpdatedAt, &movedFrom)
+		if err != nil {
+			return nil, err
+		}
+		entry.ExpiresAt = expiresAt.Time
+
+		decryptedPassword, err := crypto.Decrypt(encryptedPassword, vaultKey)
+		if err != nil {
+			return nil, err
+		}
+		entry.Password = string(decryptedPassword)
+
+		entries = append(entries, entry)
+		records = append(records, &models.SyncRecord{
+			UID:       entry.UID,
+			Revisions: splitRevisions(revisions),
+			Device:    device,
+			UpdatedAt: entry.UpdatedAt,
+			Folder:    entry.Category,
+			MovedFrom: movedFrom,
+			Entry:     entry,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := db.loadEntryTags(entries); err != nil {
+		return nil, err
+	}
+
+	tombstones, err := db.conn.Query("SELECT uid, revisions, deleted_at FROM sync_tombstones WHERE exported=0")
+	if err != nil {
+		return nil, err
+	}
+	defer tombstones.Close()
+
+	for tombstones.Next() {
+		record := &models.SyncRecord{Device: device, Deleted: true}
+		var revisions string
+		if err := tombstones.Scan(&record.UID, &revisions, &record.UpdatedAt); err != nil {
+			return nil, err
+		}
+		record.Revisions = splitRevisions(revisions)
+		records = append(records, record)
+	}
+
+	return records, tombstones.Err()
+}
+
+// MarkSyncRecordExported 标记变更记录已导出
+func (db *DB) MarkSyncRecordExported(record *models.SyncRecord) error {
+	if record.Deleted {
+		_, err := db.conn.Exec("UPDATE sync_tombstones SET exported=1 WHERE uid=?", record.UID)
+		return err
+	}
+
+	_, err := db.conn.Exec("UPDATE password_entries SET synced_revision=?, moved_from=NULL WHERE uid=?", record.Revision(), record.UID)
+	return err
+}
+
+// GetLocalSyncRecord 获取条目在本机的同步状态，条目不存在时返回 nil
+func (db *DB) GetLocalSyncRecord(uid, device string) (*models.SyncRecord, error) {
+	record := &models.SyncRecord{UID: uid, Device: device}
+
+	var revisions string
+	err := db.conn.QueryRow("SELECT revisions, updated_at FROM password_entries WHERE uid=? AND deleted_at IS NULL", uid).
+		Scan(&revisions, &record.UpdatedAt)
+	if err == nil {
+		record.Revisions = splitRevisions(revisions)
+		return record, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	err = db.conn.QueryRow("SELECT revisions, deleted_at FROM sync_tombstones WHERE uid=?", uid).
+		Scan(&revisions, &record.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	record.Revisions = splitRevisions(revisions)
+	record.Deleted = true
+	return record, nil
+}
+
+// ApplySyncRecord 将其他设备的变更写入本机，写入后的条目视为已同步
+func (db *DB) ApplySyncRecord(record *models.SyncRecord) error {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return fmt.Errorf("master key not set")
+	}
+
+	revisions := joinRevisions(record.Revisions)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 其他设备删除的条目同样移入回收站
+	if record.Deleted {
+		_, err := tx.Exec("UPDATE password_entries SET deleted_at=?, revisions=? WHERE uid=? AND deleted_at IS NULL",
+			record.UpdatedAt.UTC(), revisions, record.UID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT OR REPLACE INTO sync_tombstones (uid, revisions, exported, deleted_at)
+			VALUES (?, ?, 1, ?)`,
+			record.UID, revisions, record.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	entry := record.Entry
+	if entry == nil {
+		return fmt.Errorf("sync record %s has no entry", record.UID)
+	}
+
+	encryptedPassword, err := crypto.Encrypt([]byte(entry.Password), vaultKey)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM sync_tombstones WHERE uid=?", record.UID); err != nil {
+		return err
+	}
+
+	// 分类 ID 只在本机有效，按记录的文件夹路径查找或创建分类
+	entry.CategoryID = 0
+	entry.Category = record.Folder
+	categoryID, err := resolveCategory(tx, entry)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE password_entries
+		SET title=?, username=?, password=?, url=?, notes=?, category_id=?, favorite=?, expires_at=?, rotation_days=?,
+			updated_at=?, revisions=?, synced_revision=?, moved_from=NULL, deleted_at=NULL
+		WHERE uid=?`,
+		entry.Title, entry.Username, encryptedPassword, entry.URL, entry.Notes, categoryID, entry.Favorite,
+		nullTime(entry.ExpiresAt), entry.RotationDays, record.UpdatedAt,
+		revisions, record.Revision(), record.UID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// 本机还没有该条目时插入新条目
+	if affected == 0 {
+		createdAt := entry.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		_, err = tx.Exec(`
+			INSERT INTO password_entries (uid, revisions, synced_revision, title, username, password, url, notes, category_id, favorite,
+				expires_at, rotation_days, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			record.UID, revisions, record.Revision(), entry.Title, entry.Username, encryptedPassword,
+			entry.URL, entry.Notes, categoryID, entry.Favorite, nullTime(entry.ExpiresAt), entry.RotationDays, createdAt, record.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	var id int64
+	if err := tx.QueryRow("SELECT id FROM password_entries WHERE uid=?", record.UID).Scan(&id); err != nil {
+		return err
+	}
+	if entry.Tags, err = saveEntryTags(tx, id, entry.Tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetSyncRevisions 更新条目或删除记录的修订历史，用于合并冲突后保留对方的修订
+func (db *DB) SetSyncRevisions(uid string, revisions []string) error {
+	joined := joinRevisions(revisions)
+	if _, err := db.conn.Exec("UPDATE password_entries SET revisions=? WHERE uid=?", joined, uid); err != nil {
+		return err
+	}
+	_, err := db.conn.Exec("UPDATE sync_tombstones SET revisions=? WHERE uid=?", joined, uid)
+	return err
+}
+
+// IsSyncRecordSeen 检查变更记录是否已处理过
+func (db *DB) IsSyncRecordSeen(name string) (bool, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM sync_seen WHERE name=?", name).Scan(&count)
+	return count > 0, err
+}
+
+// MarkSyncRecordSeen 标记变更记录已处理
+// group 为同一设备同一条目的记录名称的共同前缀，新记录取代了旧记录，旧记录的名称一并删除，
+// 已处理的记录数量不会超过设备数与条目数的乘积
+func (db *DB) MarkSyncRecordSeen(name, group string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if group != "" {
+		_, err := tx.Exec("DELETE FROM sync_seen WHERE substr(name, 1, length(?)) = ? AND name != ?", group, group, name)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("INSERT OR IGNORE INTO sync_seen (name) VALUES (?)", name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkSyncRecordSeenReplacesGroup(t *testing.T) {
+	db := newFixtureDB(t, LatestSchemaVersion())
+	for _, name := range []string{"a/x-1.rec", "a/y-1.rec", "b/x-1.rec", "a/x-2.rec"} {
+		if err := db.MarkSyncRecordSeen(name, name[:strings.Index(name, "-")+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 早期版本的记录名称没有分组
+	if err := db.MarkSyncRecordSeen("a/legacy.rec", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"a/x-1.rec": false, "a/x-2.rec": true, "a/y-1.rec": true, "b/x-1.rec": true, "a/legacy.rec": true}
+	for name, seen := range want {
+		if got, err := db.IsSyncRecordSeen(name); err != nil || got != seen {
+			t.Errorf("IsSyncRecordSeen(%s) = %v, %v, want %v", name, got, err, seen)
+		}
+	}
+}
+
+func TestFolderMoveSyncRecords(t *testing.T) {
+	db := newUnlockedDB(t)
+	device, err := db.DeviceID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]int{}
+	categories, err := db.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range categories {
+		ids[c.Name] = c.ID
+	}
+
+	if err := db.MergeCategories(ids["工作/aws"], ids["个人"]); err != nil {
+		t.Fatal(err)
+	}
+	records, err := db.GetPendingSyncRecords(device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, record := range records {
+		if record.Entry == nil || record.Entry.Title != "aws" {
+			continue
+		}
+		found = true
+		if record.Folder != "个人" || record.MovedFrom != "工作/aws" {
+			t.Errorf("record folder = %q, moved from %q", record.Folder, record.MovedFrom)
+		}
+		// 导出后不再带有原文件夹
+		if err := db.MarkSyncRecordExported(record); err != nil {
+			t.Fatal(err)
+		}
+		var movedFrom int
+		if err := db.conn.QueryRow("SELECT COUNT(*) FROM password_entries WHERE moved_from IS NOT NULL").Scan(&movedFrom); err != nil || movedFrom != 0 {
+			t.Errorf("entries with moved_from after export = %d, %v", movedFrom, err)
+		}
+	}
+	if !found {
+		t.Fatal("no pending record for the moved entry")
+	}
+}
+
+func TestPruneMovedCategories(t *testing.T) {
+	db := newUnlockedDB(t)
+	if err := db.AddCategory("空/子"); err != nil {
+		t.Fatal(err)
+	}
+	// 回收站中的条目仍然使用文件夹
+	entries, err := db.GetPasswordEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Title == "aws" {
+			if err := db.DeletePasswordEntry(entry.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := db.PruneMovedCategories([]string{"空", "空/子", "工作", "工作/aws", "不存在"}); err != nil {
+		t.Fatal(err)
+	}
+	categories, err := db.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range categories {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "个人,工作,工作/aws" {
+		t.Errorf("categories = %s", got)
+	}
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"sort"
+	"strings"
+
+	"hank.com/password_tool/models"
+)
+
+// 标签和条目通过 entry_tags 多对多关联。标签名称不区分大小写，第一次使用时的写法作为标签名称，
+// 不再被任何条目（包括回收站中的条目）使用的标签会被删除。
+// 同步记录中保存条目的标签名称，其他设备写入条目时按名称查找或创建标签。
+
+// NormalizeTags 规范化标签列表：去除首尾空白，忽略空标签和只有大小写不同的重复标签，按名称排序
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	SortTags(normalized)
+	return normalized
+}
+
+// SortTags 按名称排序标签，不区分大小写
+func SortTags(tags []string) {
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i]) < strings.ToLower(tags[j])
+	})
+}
+
+// saveEntryTags 将条目的标签替换为 tags，并把 tags 更新为标签实际保存的名称
+func saveEntryTags(q queryer, entryID int64, tags []string) ([]string, error) {
+	if _, err := q.Exec("DELETE FROM entry_tags WHERE entry_id=?", entryID); err != nil {
+		return nil, err
+	}
+
+	tags = NormalizeTags(tags)
+	for i, tag := range tags {
+		if _, err := q.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", tag); err != nil {
+			return nil, err
+		}
+		var tagID int64
+		if err := q.QueryRow("SELECT id, name FROM tags WHERE name=?", tag).Scan(&tagID, &tags[i]); err != nil {
+			return nil, err
+		}
+		if _, err := q.Exec("INSERT INTO entry_tags (entry_id, tag_id) VALUES (?, ?)", entryID, tagID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := deleteUnusedTags(q); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// deleteUnusedTags 删除没有条目使用的标签
+func deleteUnusedTags(q queryer) error {
+	_, err := q.Exec("DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM entry_tags)")
+	return err
+}
+
+// loadEntryTags 读取条目的标签
+func (db *DB) loadEntryTags(entries []*models.PasswordEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT et.entry_id, t.name FROM entry_tags et JOIN tags t ON t.id = et.tag_id
+		ORDER BY t.name COLLATE NOCASE`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]string)
+	for rows.Next() {
+		var entryID int
+		var name string
+		if err := rows.Scan(&entryID, &name); err != nil {
+			return err
+		}
+		tags[entryID] = append(tags[entryID], name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		entry.Tags = tags[entry.ID]
+	}
+	return nil
+}
+
+// GetTags 获取所有标签及回收站以外使用该标签的条目数，按名称排列
+func (db *DB) GetTags() ([]*models.Tag, error) {
+	rows, err := db.conn.Query(`
+		SELECT t.id, t.name, COUNT(e.id)
+		FROM tags t
+			LEFT JOIN entry_tags et ON et.tag_id = t.id
+			LEFT JOIN password_entries e ON e.id = et.entry_id AND e.deleted_at IS NULL
+		GROUP BY t.id ORDER BY t.name COLLATE NOCASE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*models.Tag
+	for rows.Next() {
+		tag := &models.Tag{}
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.EntryCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
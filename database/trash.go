@@ -0,0 +1,169 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/models"
+)
+
+// trashRetentionSetting 回收站中的条目保留的天数，0 表示不自动清除
+const trashRetentionSetting = "trash_retention_days"
+
+// DefaultTrashRetentionDays 默认的回收站保留天数
+const DefaultTrashRetentionDays = 30
+
+// GetDeletedPasswordEntries 获取回收站中的条目，最近删除的在前
+func (db *DB) GetDeletedPasswordEntries() ([]*models.PasswordEntry, error) {
+	vaultKey := db.currentKey()
+	if vaultKey == nil {
+		return nil, fmt.Errorf("master key not set")
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT e.id, e.uid, e.title, e.username, e.password, e.url, e.notes, COALESCE(e.category_id, 0), COALESCE(c.name, ''),
+			e.favorite, e.expires_at, e.rotation_days, e.created_at, e.updated_at, e.deleted_at
+		FROM password_entries e LEFT JOIN categories c ON c.id = e.category_id
+		WHERE e.deleted_at IS NOT NULL ORDER BY e.deleted_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.PasswordEntry
+	for rows.Next() {
+		entry := &models.PasswordEntry{}
+		var encryptedPassword string
+		var expiresAt sql.NullTime
+		err := rows.Scan(&entry.ID, &entry.UID, &entry.Title, &entry.Username, &encryptedPassword,
+			&entry.URL, &entry.Notes, &entry.CategoryID, &entry.Category, &entry.Favorite, &expiresAt, &entry.RotationDays,
+			&entry.CreatedAt, &entry.UpdatedAt, &entry.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.ExpiresAt = expiresAt.Time
+
+		decryptedPassword, err := crypto.Decrypt(encryptedPassword, vaultKey)
+		if err != nil {
+			return nil, err
+		}
+		entry.Password = string(decryptedPassword)
+
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, db.loadEntryTags(entries)
+}
+
+// RestorePasswordEntry 从回收站恢复条目，新的修订会在同步时覆盖其他设备上的删除
+func (db *DB) RestorePasswordEntry(id int) error {
+	var uid, revisions, title string
+	err := db.conn.QueryRow("SELECT uid, revisions, title FROM password_entries WHERE id=? AND deleted_at IS NOT NULL", id).
+		Scan(&uid, &revisions, &title)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE password_entries SET deleted_at=NULL, revisions=?, updated_at=? WHERE id=?",
+		appendRevision(revisions, newSyncID()), time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM sync_tombstones WHERE uid=?", uid); err != nil {
+		return err
+	}
+	if err := db.audit(tx, models.AuditRestore, id, title); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgePasswordEntry 永久删除回收站中的条目，删除记录仍然保留供同步使用
+func (db *DB) PurgePasswordEntry(id int) error {
+	var title string
+	err := db.conn.QueryRow("SELECT title FROM password_entries WHERE id=? AND deleted_at IS NOT NULL", id).Scan(&title)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM password_entries WHERE id=?", id); err != nil {
+		return err
+	}
+	if err := deleteUnusedTags(tx); err != nil {
+		return err
+	}
+	if err := db.audit(tx, models.AuditPurge, id, title); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TrashRetentionDays 返回回收站中的条目保留的天数，0 表示不自动清除
+func (db *DB) TrashRetentionDays() (int, error) {
+	value, err := db.GetSetting(trashRetentionSetting)
+	if err != nil || value == "" {
+		return DefaultTrashRetentionDays, err
+	}
+	return strconv.Atoi(value)
+}
+
+// SetTrashRetentionDays 设置回收站中的条目保留的天数，0 表示不自动清除
+func (db *DB) SetTrashRetentionDays(days int) error {
+	if days < 0 {
+		return fmt.Errorf("invalid retention %d days", days)
+	}
+	return db.SetSetting(trashRetentionSetting, strconv.Itoa(days))
+}
+
+// PurgeExpiredTrash 永久删除在回收站中超过保留天数的条目，返回删除的数量
+// deleted_at 统一保存为 UTC 时间，按文本比较时才能得到正确的先后顺序
+func (db *DB) PurgeExpiredTrash() (int, error) {
+	days, err := db.TrashRetentionDays()
+	if err != nil || days == 0 {
+		return 0, err
+	}
+
+	rows, err := db.conn.Query("SELECT id FROM password_entries WHERE deleted_at IS NOT NULL AND deleted_at < ?",
+		time.Now().UTC().AddDate(0, 0, -days))
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := db.PurgePasswordEntry(id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
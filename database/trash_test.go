@@ -0,0 +1,82 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"hank.com/password_tool/models"
+)
+
+// deleteEntryAt 将条目移入回收站，并把删除时间改为 days 天前
+func deleteEntryAt(t *testing.T, db *DB, title string, days int) {
+	t.Helper()
+	entry := &models.PasswordEntry{Title: title, Password: "secret"}
+	if err := db.AddPasswordEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeletePasswordEntry(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, db.conn, "UPDATE password_entries SET deleted_at=? WHERE id=?", time.Now().UTC().AddDate(0, 0, -days), entry.ID)
+}
+
+// trashTitles 返回回收站中条目的标题
+func trashTitles(t *testing.T, db *DB) map[string]bool {
+	t.Helper()
+	entries, err := db.GetDeletedPasswordEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := map[string]bool{}
+	for _, entry := range entries {
+		titles[entry.Title] = true
+	}
+	return titles
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	db := newUnlockedDB(t)
+	if days, err := db.TrashRetentionDays(); err != nil || days != DefaultTrashRetentionDays {
+		t.Fatalf("TrashRetentionDays() = %d, %v", days, err)
+	}
+
+	deleteEntryAt(t, db, "expired", DefaultTrashRetentionDays+1)
+	deleteEntryAt(t, db, "recent", DefaultTrashRetentionDays-1)
+	kept := &models.PasswordEntry{Title: "kept", Password: "secret"}
+	if err := db.AddPasswordEntry(kept); err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, db.conn, "UPDATE password_entries SET updated_at=? WHERE id=?", time.Now().AddDate(-1, 0, 0), kept.ID)
+
+	// 只清除删除时间超过保留天数的条目，未删除的条目不受影响
+	if n, err := db.PurgeExpiredTrash(); err != nil || n != 1 {
+		t.Fatalf("PurgeExpiredTrash() = %d, %v, want 1", n, err)
+	}
+	if titles := trashTitles(t, db); len(titles) != 1 || !titles["recent"] {
+		t.Errorf("trash after purge = %v, want only recent", titles)
+	}
+	var remaining int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM password_entries WHERE title='kept' AND deleted_at IS NULL").Scan(&remaining); err != nil || remaining != 1 {
+		t.Errorf("kept entries = %d, %v", remaining, err)
+	}
+
+	// 保留天数为 0 时不自动清除
+	if err := db.SetTrashRetentionDays(0); err != nil {
+		t.Fatal(err)
+	}
+	deleteEntryAt(t, db, "ancient", 365)
+	if n, err := db.PurgeExpiredTrash(); err != nil || n != 0 {
+		t.Errorf("PurgeExpiredTrash() with retention 0 = %d, %v", n, err)
+	}
+
+	// 缩短保留天数后，较早删除的条目随之过期
+	if err := db.SetTrashRetentionDays(7); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := db.PurgeExpiredTrash(); err != nil || n != 2 {
+		t.Fatalf("PurgeExpiredTrash() with retention 7 = %d, %v, want 2", n, err)
+	}
+	if titles := trashTitles(t, db); len(titles) != 0 {
+		t.Errorf("trash after shorter retention = %v", titles)
+	}
+}
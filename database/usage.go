@@ -0,0 +1,30 @@
+package database
+
+import "time"
+
+// SetFavorite 收藏或取消收藏条目，产生新的修订使收藏同步到其他设备，但不改变条目的修改时间
+func (db *DB) SetFavorite(id int, favorite bool) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var revisions string
+	if err := tx.QueryRow("SELECT revisions FROM password_entries WHERE id=? AND deleted_at IS NULL", id).Scan(&revisions); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE password_entries SET favorite=?, revisions=? WHERE id=?",
+		favorite, appendRevision(revisions, newSyncID()), id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordEntryUse 记录条目被查看或复制，更新最近使用时间和使用次数
+// 使用统计只在本机记录，不产生修订也不同步
+func (db *DB) RecordEntryUse(id int) error {
+	_, err := db.conn.Exec("UPDATE password_entries SET last_used_at=?, use_count=use_count+1 WHERE id=?", time.Now(), id)
+	return err
+}
@@ -0,0 +1,77 @@
+package factor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+)
+
+// Responder 挑战-应答式的第二因素，例如 YubiKey 的 HMAC-SHA1 挑战-应答（slot 2）
+// 解锁时密码库提供保存的挑战值，应答参与密钥派生，没有该因素无法解锁
+type Responder interface {
+	// Name 返回因素的名称，用于在界面上提示
+	Name() string
+	// Respond 对挑战值计算应答，同一挑战必须始终得到相同的应答
+	Respond(challenge []byte) ([]byte, error)
+}
+
+// secretSize 与 YubiKey HMAC-SHA1 挑战-应答的密钥长度一致
+const secretSize = 20
+
+// SoftwareToken 软件实现的 HMAC-SHA1 挑战-应答令牌，密钥保存在令牌文件中
+// 令牌文件应存放在与密码库不同的位置（如U盘），否则起不到第二因素的作用
+type SoftwareToken struct {
+	secret []byte
+}
+
+// NewSoftwareToken 使用给定密钥创建软件令牌
+func NewSoftwareToken(secret []byte) (*SoftwareToken, error) {
+	if len(secret) != secretSize {
+		return nil, errors.New("software token secret must be 20 bytes")
+	}
+	return &SoftwareToken{secret: append([]byte(nil), secret...)}, nil
+}
+
+// GenerateSoftwareToken 生成随机密钥的软件令牌，并返回令牌文件内容
+func GenerateSoftwareToken() (*SoftwareToken, []byte, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, nil, err
+	}
+
+	token, err := NewSoftwareToken(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, []byte(hex.EncodeToString(secret) + "\n"), nil
+}
+
+// LoadSoftwareToken 从令牌文件加载软件令牌，文件内容为十六进制的密钥
+func LoadSoftwareToken(path string) (*SoftwareToken, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.New("invalid software token file")
+	}
+	return NewSoftwareToken(secret)
+}
+
+// Name 返回令牌名称
+func (t *SoftwareToken) Name() string {
+	return "软件令牌"
+}
+
+// Respond 计算 HMAC-SHA1(密钥, 挑战)
+func (t *SoftwareToken) Respond(challenge []byte) ([]byte, error) {
+	mac := hmac.New(sha1.New, t.secret)
+	mac.Write(challenge)
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,49 @@
+package factortest
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"sync"
+)
+
+// Responder 可控的挑战-应答替身：记录收到的挑战，可以模拟令牌被拔出等错误
+type Responder struct {
+	// Secret 计算应答使用的密钥
+	Secret []byte
+	// Err 不为空时 Respond 返回该错误
+	Err error
+
+	mu         sync.Mutex
+	challenges [][]byte
+}
+
+// New 使用固定密钥创建替身
+func New(secret string) *Responder {
+	return &Responder{Secret: []byte(secret)}
+}
+
+// Name 返回替身名称
+func (r *Responder) Name() string {
+	return "factortest"
+}
+
+// Respond 记录挑战并返回 HMAC-SHA1(Secret, 挑战)
+func (r *Responder) Respond(challenge []byte) ([]byte, error) {
+	r.mu.Lock()
+	r.challenges = append(r.challenges, append([]byte(nil), challenge...))
+	r.mu.Unlock()
+
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	mac := hmac.New(sha1.New, r.Secret)
+	mac.Write(challenge)
+	return mac.Sum(nil), nil
+}
+
+// Challenges 返回收到过的所有挑战
+func (r *Responder) Challenges() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]byte(nil), r.challenges...)
+}
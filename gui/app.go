@@ -1,8 +1,11 @@
 package gui
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -11,26 +14,51 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 
-	"hank.com/password_tool/crypto"
 	"hank.com/password_tool/database"
 	"hank.com/password_tool/models"
+	"hank.com/password_tool/search"
+	"hank.com/password_tool/sshagent"
+	"hank.com/password_tool/vaultsync"
 )
 
-// 默认分类列表，可以方便地进行调整
-var DefaultCategories = []string{"sky", "co", "meet", "own", "个人", "玄熠"}
-
 type App struct {
 	fyneApp        fyne.App
 	window         fyne.Window
 	db             *database.DB
-	entryList      *widget.List
+	store          database.VaultStore // 条目、分类、主密码和设置操作使用的存储，运行时即 db；备份、同步、输错退避和恢复等只有 SQLite 实现的功能使用 db
+	entryTable     *widget.Table
 	entries        []*models.PasswordEntry
 	categories     []*models.Category
+	tags           []*models.Tag
+	selectedTags   map[string]bool // 标签筛选中勾选的标签，键为小写名称
+	searchText     string          // 搜索框中的查询
+	sortMode       string          // 主列表的排序方式
+	sortColumn     int             // 点击标题行选择的排序列，noSortColumn 表示使用 sortMode
+	sortDescending bool            // 按列降序排列
+	selectedIDs    map[int]bool    // 表格中勾选的条目 ID
+	currentID      int             // 快捷键操作的当前条目 ID
+	shortcuts      map[string]func() // 按快捷键名称索引的全局快捷键操作
 	lockTimer      *time.Timer
 	isLocked       bool
 	lastActivity   time.Time
 	openDialogs    []*dialog.CustomDialog // 跟踪打开的对话框
 	categoryFilter *widget.Select         // 分类筛选下拉框
+	folderTree     *widget.Tree           // 文件夹树侧边栏
+	folderNodes    []*folderNode          // 文件夹树创建的节点，用于查找拖放目标
+	tagList        *widget.List           // 标签筛选列表
+	selectionLabel *widget.Label          // 显示勾选的条目数
+	bulkButtons    []*widget.Button       // 批量操作按钮，没有勾选条目时禁用
+	statusLabel    *widget.Label          // 显示复制等操作的提示
+	searchEntry    *keyEntry              // 搜索框，Ctrl+F 聚焦
+	sshAgent       *sshagent.Server       // 解锁期间运行的 SSH agent
+	backupTicker   *time.Ticker           // 定时备份
+	backupDone     chan struct{}          // 关闭后定时备份的 goroutine 退出
+	expiryTicker   *time.Ticker           // 解锁期间定期检查密码是否过期
+	expiryDone     chan struct{}          // 关闭后过期检查的 goroutine 退出
+
+	lastExpiryCheck time.Time // 上次检查过期的时间，之后过期的条目会发送通知
+
+	rotateRecoveryKey bool // 使用恢复密钥重置主密码后需要更换恢复密钥
 }
 
 // NewApp 创建新的应用实例
@@ -59,14 +87,38 @@ func NewApp() *App {
 func (a *App) Run() {
 	var err error
 	a.db, err = database.NewDB()
-	if err != nil {
+	var tooNew *database.SchemaTooNewError
+	if errors.As(err, &tooNew) {
+		err = fmt.Errorf("密码库由更新版本的程序创建（结构版本 %d，当前程序支持 %d），请升级后再打开", tooNew.Version, tooNew.Supported)
 		dialog.ShowError(err, a.window)
 		return
 	}
+	if err != nil {
+		// 密码库损坏无法打开时只提供从备份恢复
+		var openErr error
+		if a.db, openErr = database.OpenForRestore(); openErr != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+	}
+	a.db.SetAuditClient(models.AuditClientGUI)
+	a.db.SetRekeyHook(vaultsync.RekeyHook(a.db))
+	a.store = a.db
 	defer a.db.Close()
+	defer a.stopSSHAgent()
+	defer a.stopExpiryWatch()
+
+	if err != nil {
+		a.showDamagedVault(err)
+		a.window.ShowAndRun()
+		return
+	}
+
+	a.startBackupSchedule()
+	defer a.stopBackupSchedule()
 
 	// 检查是否已设置主密码
-	hasMasterPassword, err := a.db.HasMasterPassword()
+	hasMasterPassword, err := a.store.HasMasterPassword()
 	if err != nil {
 		dialog.ShowError(err, a.window)
 		return
@@ -81,64 +133,11 @@ func (a *App) Run() {
 	a.window.ShowAndRun()
 }
 
-// createHeaderRow 创建列表标题行
-func (a *App) createHeaderRow() *fyne.Container {
-	// 创建标题标签，设置与列表项相同的宽度和样式
-	titleLabel := widget.NewLabel("标题")
-	titleLabel.Resize(fyne.NewSize(150, 30))
-	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
-	titleLabel.Alignment = fyne.TextAlignLeading
-
-	// 创建用户名标签
-	usernameLabel := widget.NewLabel("用户名")
-	usernameLabel.Resize(fyne.NewSize(100, 30))
-	usernameLabel.TextStyle = fyne.TextStyle{Bold: true}
-	usernameLabel.Alignment = fyne.TextAlignLeading
-
-	// 创建网址标签容器，与列表项的URL容器保持一致
-	urlContainer := container.NewWithoutLayout()
-	urlContainer.Resize(fyne.NewSize(280, 30))
-	urlLabel := widget.NewLabel("网址")
-	urlLabel.Resize(fyne.NewSize(280, 30))
-	urlLabel.Move(fyne.NewPos(0, 0))
-	urlLabel.TextStyle = fyne.TextStyle{Bold: true}
-	urlLabel.Alignment = fyne.TextAlignLeading
-	urlContainer.Add(urlLabel)
-
-	// 创建操作标题标签，替代具体的按钮
-	operationLabel := widget.NewLabel("操作")
-	operationLabel.Resize(fyne.NewSize(180, 30)) // 与按钮容器总宽度一致 (60*3)
-	operationLabel.TextStyle = fyne.TextStyle{Bold: true}
-	operationLabel.Alignment = fyne.TextAlignCenter
-
-	// 创建信息容器，与列表项布局保持一致
-	infoContainer := container.NewHBox(
-		titleLabel,
-		usernameLabel,
-		urlContainer,
-	)
-
-	// 使用与列表项相同的边框布局
-	headerRow := container.NewBorder(
-		nil,            // 顶部
-		nil,            // 底部
-		nil,            // 左侧
-		operationLabel, // 右侧：操作标题
-		infoContainer,  // 中心：信息标签
-	)
-
-	// 创建带分隔符的标题行容器来突出显示
-	headerContainer := container.NewVBox(
-		widget.NewSeparator(),
-		headerRow,
-		widget.NewSeparator(),
-	)
-	
-	return headerContainer
-}
-
 // showSetMasterPasswordDialog 显示设置主密码对话框
+// 通过恢复份额取得密码库密钥后也使用该界面强制设置新主密码
 func (a *App) showSetMasterPasswordDialog() {
+	recovering := a.store.GetKey() != nil
+
 	passwordEntry := widget.NewPasswordEntry()
 	passwordEntry.Resize(fyne.NewSize(300, 40))
 
@@ -160,21 +159,25 @@ func (a *App) showSetMasterPasswordDialog() {
 			return
 		}
 
-		if err := a.db.SetMasterPassword(password); err != nil {
-			dialog.ShowError(err, a.window)
-			return
-		}
-
-		// 设置主密钥
-		salt, err := a.db.GetMasterPasswordSalt()
-		if err != nil {
+		// 通过恢复份额取得密钥后重置主密码，否则为新密码库设置主密码
+		if recovering {
+			// 重置后不再需要密钥文件，可在设置中重新启用
+			if err := a.store.ChangeMasterPassword(database.Credentials{Password: password}); err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+		} else if err := a.store.SetMasterPassword(password); err != nil {
 			dialog.ShowError(err, a.window)
 			return
 		}
-		key := crypto.DeriveKey(password, salt)
-		a.db.SetMasterKey(key)
 
 		a.showMainWindow()
+
+		// 新建密码库或使用过恢复密钥后生成新的恢复密钥
+		if !recovering || a.rotateRecoveryKey {
+			a.rotateRecoveryKey = false
+			a.createAndShowRecoveryKey()
+		}
 	}
 
 	// 添加回车键监听
@@ -216,7 +219,11 @@ func (a *App) showSetMasterPasswordDialog() {
 	paddedContent := container.NewPadded(content)
 
 	// 设置主窗口标题和内容
-	a.window.SetTitle("设置主密码")
+	if recovering {
+		a.window.SetTitle("设置新主密码")
+	} else {
+		a.window.SetTitle("设置主密码")
+	}
 	a.window.SetContent(paddedContent)
 	a.window.Resize(fyne.NewSize(450, 250))
 	a.window.CenterOnScreen()
@@ -227,31 +234,73 @@ func (a *App) showLoginDialog() {
 	passwordEntry := widget.NewPasswordEntry()
 	passwordEntry.Resize(fyne.NewSize(300, 40))
 
+	// 启用密钥文件时需要同时选择密钥文件
+	keyFileRequired, err := a.store.KeyFileRequired()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+	}
+	keyFileEntry, keyFilePicker := a.newFilePicker("密钥文件路径")
+	if keyFileRequired {
+		savedPath, _ := a.store.GetSetting(keyFilePathSetting)
+		keyFileEntry.SetText(savedPath)
+	} else {
+		keyFilePicker.Hide()
+	}
+
+	// 启用第二因素时需要选择令牌文件
+	factorName, err := a.store.FactorRequired()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+	}
+	tokenEntry, tokenPicker := a.newFilePicker(factorName + "文件路径")
+	if factorName != "" {
+		savedPath, _ := a.store.GetSetting(factorTokenPathSetting)
+		tokenEntry.SetText(savedPath)
+	} else {
+		tokenPicker.Hide()
+	}
+
 	// 登录处理函数
 	loginFunc := func() {
-		password := passwordEntry.Text
+		credentials := database.Credentials{Password: passwordEntry.Text}
+		if keyFileRequired {
+			keyFile, err := loadKeyFile(keyFileEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			credentials.KeyFile = keyFile
+		}
+		if factorName != "" {
+			token, err := loadSoftwareToken(tokenEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			credentials.Factor = token
+		}
 
-		valid, err := a.db.VerifyMasterPassword(password)
+		// 验证主密码并设置密码库密钥
+		valid, err := a.store.Unlock(credentials)
 		if err != nil {
-			dialog.ShowError(err, a.window)
+			a.showUnlockError(err)
 			return
 		}
 
 		if !valid {
-			dialog.ShowError(fmt.Errorf("密码错误"), a.window)
+			a.showWrongPasswordError(false)
 			return
 		}
 
-		// 设置主密钥
-		salt, err := a.db.GetMasterPasswordSalt()
-		if err != nil {
-			dialog.ShowError(err, a.window)
-			return
+		if keyFileRequired {
+			a.store.SetSetting(keyFilePathSetting, keyFileEntry.Text)
+		}
+		if factorName != "" {
+			a.store.SetSetting(factorTokenPathSetting, tokenEntry.Text)
 		}
-		key := crypto.DeriveKey(password, salt)
-		a.db.SetMasterKey(key)
 
 		a.showMainWindow()
+		a.showFailedLoginReport()
 	}
 
 	// 添加回车键监听
@@ -265,6 +314,19 @@ func (a *App) showLoginDialog() {
 	})
 	loginButton.Resize(fyne.NewSize(100, 35))
 
+	// 已生成恢复密钥或恢复份额时可以重置主密码
+	forgotButton := widget.NewButton("忘记主密码", nil)
+	forgotButton.Importance = widget.LowImportance
+	threshold, _ := a.db.RecoveryThreshold()
+	hasRecoveryKey, _ := a.db.HasRecoveryKey()
+	if threshold > 0 || hasRecoveryKey {
+		forgotButton.OnTapped = func() {
+			a.showForgotPasswordDialog(hasRecoveryKey, threshold)
+		}
+	} else {
+		forgotButton.Hide()
+	}
+
 	// 创建简单的标签和输入框布局
 	label := widget.NewLabel("主密码:")
 
@@ -276,8 +338,10 @@ func (a *App) showLoginDialog() {
 		spacer,
 		label,
 		passwordEntry,
+		keyFilePicker,
+		tokenPicker,
 		spacer,
-		container.NewCenter(loginButton),
+		container.NewCenter(container.NewHBox(loginButton, forgotButton)),
 		spacer,
 	)
 
@@ -287,216 +351,153 @@ func (a *App) showLoginDialog() {
 	// 设置主窗口标题和内容
 	a.window.SetTitle("输入主密码")
 	a.window.SetContent(paddedContent)
-	a.window.Resize(fyne.NewSize(380, 160))
+	a.window.Resize(fyne.NewSize(420, 160))
 	a.window.CenterOnScreen()
 }
 
 // showMainWindow 显示主窗口
 func (a *App) showMainWindow() {
 	a.isLocked = false
+	a.searchText = "" // 搜索框随主界面重新创建
+	a.sortColumn = noSortColumn
+	a.selectedIDs = make(map[int]bool)
+	a.currentID = 0
 	a.startAutoLockTimer()
 	a.loadEntries()
+	a.startSSHAgent()
+	a.syncOnUnlock()
+	a.backupOnUnlock()
+	a.startExpiryWatch()
 
-	// 创建密码列表，增加列宽度和操作按钮
-	a.entryList = widget.NewList(
-		func() int {
-			return len(a.entries)
-		},
-		func() fyne.CanvasObject {
-			// 创建可点击的标题按钮，设置为透明样式
-			titleBtn := widget.NewButton("标题", func() {
-				// 点击功能将在更新时设置
-			})
-			titleBtn.Resize(fyne.NewSize(150, 30))     // 减少标题宽度
-			titleBtn.Importance = widget.LowImportance // 设置为低重要性，减少按钮样式
-
-			// 创建可点击的用户名按钮，设置为透明样式
-			usernameBtn := widget.NewButton("用户名", func() {
-				// 点击功能将在更新时设置
-			})
-			usernameBtn.Resize(fyne.NewSize(100, 30))     // 减少用户名宽度
-			usernameBtn.Importance = widget.LowImportance // 设置为低重要性，减少按钮样式
-
-			// 创建URL容器，使用无布局容器但设置合适的尺寸
-			urlContainer := container.NewWithoutLayout()
-			urlContainer.Resize(fyne.NewSize(280, 30)) // 给URL更多空间
-
-			// 创建编辑按钮
-			editBtn := widget.NewButton("编辑", func() {
-				// 编辑功能将在更新时设置
-			})
-			editBtn.Resize(fyne.NewSize(60, 30))
-
-			// 创建删除按钮
-			deleteBtn := widget.NewButton("删除", func() {
-				// 删除功能将在更新时设置
-			})
-			deleteBtn.Resize(fyne.NewSize(60, 30))
-
-			// 创建复制按钮
-			copyBtn := widget.NewButton("复制", func() {
-				// 复制功能将在更新时设置
-			})
-			copyBtn.Resize(fyne.NewSize(60, 30))
-
-			// 创建按钮容器
-			buttonContainer := container.NewHBox(copyBtn, editBtn, deleteBtn)
-
-			// 使用更简单的布局结构，避免事件冲突
-			infoContainer := container.NewHBox(
-				titleBtn,
-				usernameBtn,
-				urlContainer,
-			)
-
-			return container.NewBorder(
-				nil,             // 顶部
-				nil,             // 底部
-				nil,             // 左侧
-				buttonContainer, // 右侧：操作按钮
-				infoContainer,   // 中心：信息标签
-			)
-		},
-		func(id widget.ListItemID, obj fyne.CanvasObject) {
-			if id >= len(a.entries) {
-				return
-			}
-			entry := a.entries[id]
-			borderContainer := obj.(*fyne.Container)
-
-			// 获取中心的信息容器
-			infoContainer := borderContainer.Objects[0].(*fyne.Container)
-			titleBtn := infoContainer.Objects[0].(*widget.Button)
-			usernameBtn := infoContainer.Objects[1].(*widget.Button)
-			urlContainer := infoContainer.Objects[2].(*fyne.Container)
-
-			// 设置标题和用户名文本
-			titleBtn.SetText(entry.Title)
-			usernameBtn.SetText(entry.Username)
-
-			// 设置点击事件，点击标题或用户名都显示详情
-			titleBtn.OnTapped = func() {
-				a.showEntryDetails(entry)
-			}
-			usernameBtn.OnTapped = func() {
-				a.showEntryDetails(entry)
-			}
+	// 创建工具栏按钮
+	addButton := widget.NewButton("添加密码", func() {
+		a.showAddEntryDialog()
+	})
+	addButton.Resize(fyne.NewSize(100, 35))
 
-			// 更新URL容器内容，确保URL链接能正常工作
-			urlContainer.RemoveAll()
-			if entry.URL != "" {
-				urlWidget := a.createURLWidget(entry.URL)
-				// 对于无布局容器，需要手动设置位置和大小
-				urlWidget.Resize(fyne.NewSize(280, 30))
-				urlWidget.Move(fyne.NewPos(0, 0))
-				urlContainer.Add(urlWidget)
-			} else {
-				noUrlLabel := widget.NewLabel("无")
-				noUrlLabel.Resize(fyne.NewSize(280, 30))
-				noUrlLabel.Move(fyne.NewPos(0, 0))
-				urlContainer.Add(noUrlLabel)
-			}
+	// 创建SSH密钥管理按钮
+	sshKeysButton := widget.NewButton("SSH密钥", func() {
+		a.showSSHKeysDialog()
+	})
 
-			// 获取右侧的按钮容器
-			buttonContainer := borderContainer.Objects[1].(*fyne.Container)
-			copyBtn := buttonContainer.Objects[0].(*widget.Button)   // 第一个是复制按钮
-			editBtn := buttonContainer.Objects[1].(*widget.Button)   // 第二个是编辑按钮
-			deleteBtn := buttonContainer.Objects[2].(*widget.Button) // 第三个是删除按钮
+	// 创建同步按钮
+	syncButton := widget.NewButton("同步", func() {
+		a.showSyncDialog()
+	})
 
-			// 设置编辑按钮功能
-			editBtn.OnTapped = func() {
-				a.showEntryDialog(entry)
-			}
+	// 创建备份按钮
+	backupButton := widget.NewButton("备份", func() {
+		a.showBackupsDialog()
+	})
 
-			// 设置删除按钮功能
-			deleteBtn.OnTapped = func() {
-				a.showCustomConfirmDialog("确认删除", "确定要删除这个密码条目吗？", func(confirmed bool) {
-					if confirmed {
-						if err := a.db.DeletePasswordEntry(entry.ID); err != nil {
-							dialog.ShowError(err, a.window)
-							return
-						}
-						a.loadEntries()
-					}
-				})
-			}
+	// 创建账户恢复按钮
+	recoveryButton := widget.NewButton("账户恢复", func() {
+		a.showRecoverySetupDialog()
+	})
 
-			// 设置复制按钮功能
-			copyBtn.OnTapped = func() {
-				// 格式化复制内容：账号和密码换行显示
-				// entry.Password 已经是解密后的明文密码，无需再次解密
-				copyContent := fmt.Sprintf("账号: %s\n密码: %s", entry.Username, entry.Password)
+	// 创建密钥文件按钮
+	keyFileButton := widget.NewButton("密钥文件", func() {
+		a.showKeyFileDialog()
+	})
 
-				// 复制到剪切板
-				a.window.Clipboard().SetContent(copyContent)
+	// 创建第二因素按钮
+	factorButton := widget.NewButton("第二因素", func() {
+		a.showFactorDialog()
+	})
 
-				// 显示复制成功提示
-				dialog.ShowInformation("复制成功", "账号和密码已复制到剪切板", a.window)
-			}
-		},
-	)
+	// 创建登录保护按钮
+	lockoutButton := widget.NewButton("登录保护", func() {
+		a.showLoginProtectionDialog()
+	})
 
-	// 移除双击查看详情的OnSelected事件，避免与URL点击冲突
-	// 现在只能通过点击标题或用户名来查看详情
-	// a.entryList.OnSelected = func(id widget.ListItemID) {
-	// 	if id >= len(a.entries) {
-	// 		return
-	// 	}
-	// 	a.showEntryDetails(a.entries[id])
-	// 	// 显示详情后立即取消选中，确保下次点击同一项目时能再次触发
-	// 	a.entryList.UnselectAll()
-	// }
+	// 创建分类管理按钮
+	categoryButton := widget.NewButton("分类管理", func() {
+		a.showCategoryDialog()
+	})
 
-	// 创建工具栏按钮
-	addButton := widget.NewButton("添加密码", func() {
-		a.showAddEntryDialog()
+	// 创建回收站按钮
+	trashButton := widget.NewButton("回收站", func() {
+		a.showTrashDialog()
+	})
+
+	// 创建审计日志按钮
+	auditButton := widget.NewButton("审计日志", func() {
+		a.showAuditLogDialog()
 	})
-	addButton.Resize(fyne.NewSize(100, 35))
 
 	// 创建工具栏容器
 	toolbar := container.NewHBox(
 		addButton,
+		categoryButton,
+		sshKeysButton,
+		syncButton,
+		backupButton,
+		trashButton,
+		recoveryButton,
+		keyFileButton,
+		factorButton,
+		lockoutButton,
+		auditButton,
 	)
 
 	// 创建搜索框，增加高度
-	searchEntry := widget.NewEntry()
-	searchEntry.SetPlaceHolder("搜索密码条目...")
+	// 方向键选择当前条目，回车显示详情，Esc 清空搜索
+	searchEntry := newKeyEntry(a)
+	searchEntry.SetPlaceHolder("搜索密码条目，支持拼音首字母和 user: url: tag: notes: folder: 限定字段...")
 	searchEntry.Resize(fyne.NewSize(0, 35)) // 宽度自适应，高度35
 	searchEntry.OnChanged = func(text string) {
 		a.filterEntries(text)
 	}
+	searchEntry.OnSubmitted = func(string) {
+		a.openCurrentEntry()
+	}
+	searchEntry.onMove = a.moveCurrentEntry
+	searchEntry.onEscape = func() {
+		searchEntry.SetText("")
+	}
+	a.searchEntry = searchEntry
 
-	// 创建分类筛选下拉框
-	categoryOptions := append([]string{"全部分类"}, DefaultCategories...)
-	a.categoryFilter = widget.NewSelect(categoryOptions, func(selected string) {
-		a.filterByCategory(selected)
+	// 创建分类筛选下拉框，选项在加载分类时设置
+	a.categoryFilter = widget.NewSelect(nil, func(selected string) {
+		a.loadEntries()
+		a.selectFolderNode(selected)
 	})
-	a.categoryFilter.SetSelected("全部分类")
+	a.refreshCategories()
+	a.categoryFilter.SetSelected(allCategoriesOption)
 	a.categoryFilter.Resize(fyne.NewSize(150, 35))
 
 	// 创建搜索和筛选容器
 	searchFilterContainer := container.NewBorder(
-		nil, nil, a.categoryFilter, nil, // 左侧放置分类筛选
+		nil, nil, a.categoryFilter, a.createSortSelect(), // 左侧放置分类筛选，右侧放置排序方式
 		searchEntry, // 中心放置搜索框
 	)
 
-	// 创建列表标题行
-	headerContainer := a.createHeaderRow()
-
 	// 创建顶部容器，增加间距
 	topContainer := container.NewVBox(
 		container.NewPadded(toolbar),
 		container.NewPadded(searchFilterContainer),
-		headerContainer, // 添加标题行
 	)
 
+	// 左侧为文件夹树和标签筛选，右侧为批量操作栏和密码表格
+	sidebar := container.NewVSplit(
+		container.NewPadded(a.createFolderTree()),
+		container.NewPadded(a.createTagFilter()),
+	)
+	sidebar.Offset = 0.65
+	split := container.NewHSplit(
+		sidebar,
+		container.NewBorder(a.createBulkActionBar(), nil, nil, nil, container.NewPadded(a.createEntryTable())),
+	)
+	split.Offset = 0.2
+	a.selectFolderNode(a.categoryFilter.Selected)
+
 	// 布局
 	content := container.NewBorder(
 		topContainer,
 		nil,
 		nil,
 		nil,
-		container.NewPadded(a.entryList),
+		split,
 	)
 
 	// 设置主窗口标题和内容
@@ -507,6 +508,7 @@ func (a *App) showMainWindow() {
 
 	// 添加窗口事件监听，用于检测用户活动
 	a.setupActivityListeners(content)
+	a.registerShortcuts()
 }
 
 // startAutoLockTimer 启动自动锁定定时器
@@ -548,9 +550,13 @@ func (a *App) lockApplication() {
 	}
 	a.openDialogs = nil
 
+	// 停止 SSH agent，锁定期间不再提供密钥
+	a.stopSSHAgent()
+	a.stopExpiryWatch()
+
 	// 清除主密钥
 	if a.db != nil {
-		a.db.SetMasterKey(nil)
+		a.store.SetMasterKey(nil)
 	}
 
 	// 返回到登录界面
@@ -588,6 +594,14 @@ func (a *App) addActivityListenersToContainer(container *fyne.Container) {
 					originalChanged(text)
 				}
 			}
+		case *keyEntry:
+			originalChanged := widget.OnChanged
+			widget.OnChanged = func(text string) {
+				a.resetAutoLockTimer()
+				if originalChanged != nil {
+					originalChanged(text)
+				}
+			}
 		case *widget.List:
 			originalSelected := widget.OnSelected
 			widget.OnSelected = func(id int) {
@@ -605,73 +619,65 @@ func (a *App) addActivityListenersToContainer(container *fyne.Container) {
 
 // loadEntries 加载密码条目
 func (a *App) loadEntries() {
-	entries, err := a.db.GetPasswordEntries()
+	entries, err := a.store.GetPasswordEntries()
 	if err != nil {
 		dialog.ShowError(err, a.window)
 		return
 	}
-	a.entries = entries
-	if a.entryList != nil {
-		a.entryList.Refresh()
-	}
-}
+	a.refreshCategories()
+	a.refreshTags()
 
-// filterEntries 过滤密码条目
-// filterByCategory 根据分类筛选密码条目
-func (a *App) filterByCategory(category string) {
-	allEntries, err := a.db.GetPasswordEntries()
-	if err != nil {
-		dialog.ShowError(err, a.window)
-		return
-	}
-
-	if category == "全部分类" {
-		a.entries = allEntries
-	} else {
-		var filtered []*models.PasswordEntry
-		for _, entry := range allEntries {
-			if entry.Category == category {
-				filtered = append(filtered, entry)
-			}
+	// 按分类和标签筛选，有搜索查询时按相关度排序，否则按选择的排序方式排序
+	var filtered []*models.PasswordEntry
+	for _, entry := range entries {
+		if a.matchesFilters(entry) {
+			filtered = append(filtered, entry)
 		}
-		a.entries = filtered
 	}
-
-	a.entryList.Refresh()
-}
-
-func (a *App) filterEntries(searchText string) {
-	if searchText == "" {
-		a.loadEntries()
-		return
+	if a.searchText == "" {
+		sortEntries(filtered, a.sortMode)
+		a.entries = filtered
+	} else {
+		a.entries = search.Search(filtered, a.searchText)
 	}
-
-	allEntries, err := a.db.GetPasswordEntries()
-	if err != nil {
-		dialog.ShowError(err, a.window)
-		return
+	// 点击标题行选择的排序列优先
+	if a.sortColumn != noSortColumn {
+		sortByColumn(a.entries, a.sortColumn, a.sortDescending)
 	}
+	a.pruneSelection()
+	a.refreshSelection()
+}
 
-	var filtered []*models.PasswordEntry
-	for _, entry := range allEntries {
-		if contains(entry.Title, searchText) ||
-			contains(entry.Username, searchText) ||
-			contains(entry.URL, searchText) ||
-			contains(entry.Category, searchText) {
-			filtered = append(filtered, entry)
+// matchesFilters 检查条目是否符合分类筛选和标签筛选，选择文件夹时包括其子文件夹中的条目
+func (a *App) matchesFilters(entry *models.PasswordEntry) bool {
+	if a.categoryFilter != nil {
+		switch category := a.categoryFilter.Selected; category {
+		case "", allCategoriesOption:
+		case favoritesOption:
+			if !entry.Favorite {
+				return false
+			}
+		case expiringOption:
+			if !expiringSoon(entry, time.Now()) {
+				return false
+			}
+		case uncategorizedOption:
+			if entry.Category != "" {
+				return false
+			}
+		default:
+			if !inFolder(entry, category) {
+				return false
+			}
 		}
 	}
-
-	a.entries = filtered
-	a.entryList.Refresh()
+	return a.hasSelectedTags(entry)
 }
 
-// contains 检查字符串是否包含子字符串（忽略大小写）
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) &&
-		(s == substr ||
-			len(substr) == 0 ||
-			(len(s) > 0 && len(substr) > 0 && s[0] == substr[0]))
+// filterEntries 按搜索框的查询筛选密码条目，与分类筛选和标签筛选同时生效
+func (a *App) filterEntries(searchText string) {
+	a.searchText = searchText
+	a.loadEntries()
 }
 
 // showAddEntryDialog 显示添加条目对话框
@@ -694,13 +700,26 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 	urlEntry := widget.NewEntry()
 	urlEntry.Resize(fyne.NewSize(350, 35))
 
-	// 创建分类下拉选择框，使用全局默认分类
-	categorySelect := widget.NewSelect(DefaultCategories, nil)
+	// 创建分类下拉选择框，选项为分类管理中的分类
+	categorySelect := widget.NewSelect(append(a.categoryNames(), uncategorizedOption), nil)
 	categorySelect.Resize(fyne.NewSize(350, 35))
 
 	notesEntry := widget.NewMultiLineEntry()
 	notesEntry.Resize(fyne.NewSize(350, 80))
 
+	// 创建标签编辑器，输入时补全已有的标签
+	var currentTags []string
+	if entry != nil {
+		currentTags = entry.Tags
+	}
+	tagEditor := newTagEditor(a, currentTags)
+
+	// 过期日期和轮换周期，设置轮换周期后修改密码时自动顺延过期日期
+	expiryEntry := widget.NewEntry()
+	expiryEntry.SetPlaceHolder("YYYY-MM-DD，留空表示不过期")
+	rotationEntry := widget.NewEntry()
+	rotationEntry.SetPlaceHolder("天数，留空表示不轮换")
+
 	// 如果是编辑模式，填充现有数据
 	if entry != nil {
 		titleEntry.SetText(entry.Title)
@@ -708,7 +727,17 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 		passwordEntry.SetText(entry.Password)
 		urlEntry.SetText(entry.URL)
 		notesEntry.SetText(entry.Notes)
-		categorySelect.SetSelected(entry.Category)
+		if entry.Category == "" {
+			categorySelect.SetSelected(uncategorizedOption)
+		} else {
+			categorySelect.SetSelected(entry.Category)
+		}
+		if !entry.ExpiresAt.IsZero() {
+			expiryEntry.SetText(entry.ExpiresAt.Local().Format(expiryDateLayout))
+		}
+		if entry.RotationDays > 0 {
+			rotationEntry.SetText(strconv.Itoa(entry.RotationDays))
+		}
 	}
 
 	// 创建标签，设置固定宽度以确保对齐
@@ -718,6 +747,8 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 	urlLabel := widget.NewLabel("网址:")
 	categoryLabel := widget.NewLabel("分类:")
 	notesLabel := widget.NewLabel("备注:")
+	expiryLabel := widget.NewLabel("过期日期:")
+	rotationLabel := widget.NewLabel("轮换周期:")
 
 	// 使用网格布局创建表单，2列布局：标签列和输入框列
 	formContent := container.NewGridWithColumns(2,
@@ -726,11 +757,16 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 		passwordLabel, passwordEntry,
 		urlLabel, urlEntry,
 		categoryLabel, categorySelect,
+		expiryLabel, expiryEntry,
+		rotationLabel, rotationEntry,
 		notesLabel, notesEntry,
 	)
 
+	// 标签编辑器高度不固定，放在表单下方
+	tagsContent := container.NewBorder(nil, nil, widget.NewLabel("标签:"), nil, tagEditor.Content())
+
 	// 添加垂直间距和内边距的容器，不使用Card组件避免额外按钮
-	paddedContent := container.NewPadded(formContent)
+	paddedContent := container.NewPadded(container.NewVBox(formContent, tagsContent))
 
 	// 确定对话框标题
 	title := "添加密码"
@@ -749,6 +785,11 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 			dialog.ShowError(fmt.Errorf("标题和密码不能为空"), a.window)
 			return
 		}
+		expiresAt, rotationDays, err := parseExpiry(expiryEntry.Text, rotationEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
 
 		newEntry := &models.PasswordEntry{
 			Title:    titleEntry.Text,
@@ -756,15 +797,18 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 			Password: passwordEntry.Text,
 			URL:      urlEntry.Text,
 			Notes:    notesEntry.Text,
-			Category: categorySelect.Selected,
+			Category: selectedCategoryName(categorySelect),
+			Tags:     tagEditor.Tags(),
+
+			ExpiresAt:    expiresAt,
+			RotationDays: rotationDays,
 		}
 
-		var err error
 		if entry == nil {
-			err = a.db.AddPasswordEntry(newEntry)
+			err = a.store.AddPasswordEntry(newEntry)
 		} else {
 			newEntry.ID = entry.ID
-			err = a.db.UpdatePasswordEntry(newEntry)
+			err = a.store.UpdatePasswordEntry(newEntry)
 		}
 
 		if err != nil {
@@ -815,6 +859,11 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 			dialog.ShowError(fmt.Errorf("标题和密码不能为空"), a.window)
 			return
 		}
+		expiresAt, rotationDays, err := parseExpiry(expiryEntry.Text, rotationEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
 
 		newEntry := &models.PasswordEntry{
 			Title:    titleEntry.Text,
@@ -822,15 +871,18 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 			Password: passwordEntry.Text,
 			URL:      urlEntry.Text,
 			Notes:    notesEntry.Text,
-			Category: categorySelect.Selected,
+			Category: selectedCategoryName(categorySelect),
+			Tags:     tagEditor.Tags(),
+
+			ExpiresAt:    expiresAt,
+			RotationDays: rotationDays,
 		}
 
-		var err error
 		if entry == nil {
-			err = a.db.AddPasswordEntry(newEntry)
+			err = a.store.AddPasswordEntry(newEntry)
 		} else {
 			newEntry.ID = entry.ID
-			err = a.db.UpdatePasswordEntry(newEntry)
+			err = a.store.UpdatePasswordEntry(newEntry)
 		}
 
 		if err != nil {
@@ -843,7 +895,7 @@ func (a *App) showEntryDialog(entry *models.PasswordEntry) {
 		d.Hide()
 	}
 
-	d.Resize(fyne.NewSize(500, 500))
+	d.Resize(fyne.NewSize(500, 600))
 	d.Show()
 }
 
@@ -871,12 +923,16 @@ func (a *App) showEntryDetails(entry *models.PasswordEntry) {
 	categoryLabel := widget.NewLabel(entry.Category)
 	categoryLabel.Wrapping = fyne.TextWrapWord
 
+	tagsLabel := widget.NewLabel(strings.Join(entry.Tags, ", "))
+	tagsLabel.Wrapping = fyne.TextWrapWord
+
 	notesLabel := widget.NewLabel(entry.Notes)
 	notesLabel.Wrapping = fyne.TextWrapWord
 
 	showPasswordBtn := widget.NewButton("显示密码", func() {
 		if passwordLabel.Text == "••••••••" {
 			passwordLabel.SetText(entry.Password)
+			a.auditEntry(models.AuditView, entry, "显示密码")
 		} else {
 			passwordLabel.SetText("••••••••")
 		}
@@ -896,6 +952,15 @@ func (a *App) showEntryDetails(entry *models.PasswordEntry) {
 		widget.NewLabel(""), // 中心：空白占位
 	)
 
+	// 创建使用统计标签
+	lastUsed := "从未使用"
+	if !entry.LastUsedAt.IsZero() {
+		lastUsed = entry.LastUsedAt.Local().Format("2006-01-02 15:04")
+	}
+	usageLabel := widget.NewLabel(fmt.Sprintf("%d 次，最近 %s", entry.UseCount, lastUsed))
+
+	expiryLabel := widget.NewLabel(expiryText(entry, time.Now()))
+
 	// 创建URL容器，确保URL能正确显示
 	urlWidget := a.createURLWidget(entry.URL)
 	urlContainer := container.NewGridWithColumns(2,
@@ -924,6 +989,18 @@ func (a *App) showEntryDetails(entry *models.PasswordEntry) {
 			widget.NewLabel("分类:"), categoryLabel,
 		),
 		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			widget.NewLabel("标签:"), tagsLabel,
+		),
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			widget.NewLabel("使用:"), usageLabel,
+		),
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			widget.NewLabel("过期:"), expiryLabel,
+		),
+		widget.NewSeparator(),
 		container.NewGridWithColumns(2,
 			widget.NewLabel("备注:"), notesLabel,
 		),
@@ -941,7 +1018,7 @@ func (a *App) showEntryDetails(entry *models.PasswordEntry) {
 
 	// 创建详情对话框，设置合适的大小
 	detailsDialog := dialog.NewCustomWithoutButtons("密码详情", content, a.window)
-	detailsDialog.Resize(fyne.NewSize(600, 450))
+	detailsDialog.Resize(fyne.NewSize(600, 500))
 
 	// 将对话框添加到跟踪列表
 	a.openDialogs = append(a.openDialogs, detailsDialog)
@@ -953,6 +1030,8 @@ func (a *App) showEntryDetails(entry *models.PasswordEntry) {
 	}
 
 	detailsDialog.Show()
+	a.auditEntry(models.AuditView, entry, "")
+	a.recordUse(entry)
 }
 
 // createURLWidget 创建可点击和选择复制的URL组件
@@ -979,8 +1058,8 @@ func (a *App) createURLWidget(urlStr string) fyne.CanvasObject {
 	return label
 }
 
-// showCustomConfirmDialog 显示自定义确认对话框，"是"在左边，"否"在右边
-func (a *App) showCustomConfirmDialog(title, message string, callback func(bool)) {
+// showCustomConfirmDialog 显示自定义确认对话框，"是"在左边，"否"在右边，返回对话框供调用方提前关闭
+func (a *App) showCustomConfirmDialog(title, message string, callback func(bool)) *dialog.CustomDialog {
 	// 创建消息标签，居中对齐
 	messageLabel := widget.NewLabel(message)
 	messageLabel.Alignment = fyne.TextAlignCenter
@@ -1033,4 +1112,5 @@ func (a *App) showCustomConfirmDialog(title, message string, callback func(bool)
 	}
 
 	confirmDialog.Show()
+	return confirmDialog
 }
@@ -0,0 +1,211 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// auditActionNames 审计日志操作的显示名称
+var auditActionNames = map[string]string{
+	models.AuditUnlock:  "解锁",
+	models.AuditView:    "查看",
+	models.AuditCopy:    "复制",
+	models.AuditCreate:  "新建",
+	models.AuditUpdate:  "修改",
+	models.AuditDelete:  "删除",
+	models.AuditRestore: "恢复",
+	models.AuditPurge:   "永久删除",
+	models.AuditSign:    "SSH签名",
+	models.AuditExport:  "导出",
+}
+
+// 筛选下拉框中的操作和客户端顺序
+var (
+	auditActions = []string{
+		models.AuditUnlock, models.AuditView, models.AuditCopy, models.AuditCreate,
+		models.AuditUpdate, models.AuditDelete, models.AuditRestore, models.AuditPurge, models.AuditSign,
+		models.AuditExport,
+	}
+	auditClients = []string{models.AuditClientGUI, models.AuditClientCLI, models.AuditClientAgent}
+)
+
+// 筛选下拉框中表示不筛选的选项
+const auditFilterAll = "全部"
+
+// auditEntry 在审计日志中记录对条目的查看或复制
+func (a *App) auditEntry(action string, entry *models.PasswordEntry, detail string) {
+	err := a.store.AppendAudit(&models.AuditRecord{
+		Action:  action,
+		EntryID: entry.ID,
+		Subject: entry.Title,
+		Detail:  detail,
+	})
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("无法写入审计日志: %v", err), a.window)
+	}
+}
+
+// auditedKeyStore 在 agent 每次取出私钥签名时写入审计日志
+type auditedKeyStore struct {
+	db *database.DB
+}
+
+// GetSSHKeys 返回所有SSH密钥
+func (s auditedKeyStore) GetSSHKeys() ([]*models.SSHKey, error) {
+	return s.db.GetSSHKeys()
+}
+
+// GetSSHPrivateKey 记录签名后返回解密的私钥
+func (s auditedKeyStore) GetSSHPrivateKey(id int) ([]byte, error) {
+	name := ""
+	if keys, err := s.db.GetSSHKeys(); err == nil {
+		for _, key := range keys {
+			if key.ID == id {
+				name = key.Name
+			}
+		}
+	}
+
+	err := s.db.AppendAudit(&models.AuditRecord{
+		Action:  models.AuditSign,
+		Client:  models.AuditClientAgent,
+		Subject: name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.db.GetSSHPrivateKey(id)
+}
+
+// showAuditLogDialog 显示审计日志，支持按操作、客户端和文本筛选
+func (a *App) showAuditLogDialog() {
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	if err := a.db.VerifyAuditLog(); err != nil {
+		statusLabel.SetText("⚠️ 审计日志校验失败，日志可能已被篡改: " + err.Error())
+		statusLabel.Importance = widget.DangerImportance
+	} else {
+		statusLabel.SetText("审计日志完整，未发现篡改")
+		statusLabel.Importance = widget.SuccessImportance
+	}
+
+	actionOptions := []string{auditFilterAll}
+	for _, action := range auditActions {
+		actionOptions = append(actionOptions, auditActionNames[action])
+	}
+	actionSelect := widget.NewSelect(actionOptions, nil)
+	actionSelect.SetSelected(auditFilterAll)
+
+	clientSelect := widget.NewSelect(append([]string{auditFilterAll}, auditClients...), nil)
+	clientSelect.SetSelected(auditFilterAll)
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("搜索标题或详情...")
+
+	var records []*models.AuditRecord
+	recordList := widget.NewList(
+		func() int {
+			return len(records)
+		},
+		func() fyne.CanvasObject {
+			timeLabel := widget.NewLabel("时间")
+			clientLabel := widget.NewLabel("客户端")
+			actionLabel := widget.NewLabel("操作")
+			actionLabel.TextStyle = fyne.TextStyle{Bold: true}
+			subjectLabel := widget.NewLabel("条目")
+			subjectLabel.Truncation = fyne.TextTruncateEllipsis
+
+			return container.NewBorder(
+				nil, nil,
+				container.NewHBox(timeLabel, clientLabel, actionLabel), // 左侧：时间、客户端和操作
+				nil,
+				subjectLabel,
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(records) {
+				return
+			}
+			record := records[id]
+			borderContainer := obj.(*fyne.Container)
+
+			subject := record.Subject
+			if record.Detail != "" {
+				subject += "（" + record.Detail + "）"
+			}
+			borderContainer.Objects[0].(*widget.Label).SetText(subject)
+
+			infoContainer := borderContainer.Objects[1].(*fyne.Container)
+			infoContainer.Objects[0].(*widget.Label).SetText(record.Time.Format("2006-01-02 15:04:05"))
+			infoContainer.Objects[1].(*widget.Label).SetText(record.Client)
+			name := auditActionNames[record.Action]
+			if name == "" {
+				name = record.Action
+			}
+			infoContainer.Objects[2].(*widget.Label).SetText(name)
+		},
+	)
+
+	countLabel := widget.NewLabel("")
+
+	// reload 按当前筛选条件重新加载日志
+	reload := func() {
+		filter := database.AuditFilter{Text: searchEntry.Text}
+		if index := actionSelect.SelectedIndex(); index > 0 {
+			filter.Action = auditActions[index-1]
+		}
+		if clientSelect.Selected != auditFilterAll {
+			filter.Client = clientSelect.Selected
+		}
+
+		var err error
+		records, err = a.db.GetAuditLog(filter)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			records = nil
+		}
+		countLabel.SetText(fmt.Sprintf("共 %d 条记录", len(records)))
+		recordList.Refresh()
+	}
+	actionSelect.OnChanged = func(string) { reload() }
+	clientSelect.OnChanged = func(string) { reload() }
+	searchEntry.OnChanged = func(string) { reload() }
+	reload()
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	filterBar := container.NewBorder(nil, nil,
+		container.NewHBox(actionSelect, clientSelect), nil,
+		searchEntry,
+	)
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, closeBtn, statusLabel),
+			filterBar,
+		),
+		countLabel,
+		nil, nil,
+		recordList,
+	)
+
+	d := dialog.NewCustomWithoutButtons("审计日志", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	d.Resize(fyne.NewSize(800, 560))
+	d.Show()
+}
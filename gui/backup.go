@@ -0,0 +1,221 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/models"
+)
+
+// 定时备份的间隔，应用长时间运行时也能保证每天至少备份一次
+const (
+	backupInterval      = 24 * time.Hour
+	backupCheckInterval = time.Hour
+)
+
+// backupOnUnlock 解锁后在后台创建一次备份
+func (a *App) backupOnUnlock() {
+	go func() {
+		if _, err := a.db.CreateBackup(); err != nil {
+			fyne.Do(func() {
+				dialog.ShowError(fmt.Errorf("自动备份失败: %v", err), a.window)
+			})
+		}
+	}()
+}
+
+// startBackupSchedule 定期检查并创建备份，备份文件本身是加密的，锁定时也可以备份
+func (a *App) startBackupSchedule() {
+	a.backupTicker = time.NewTicker(backupCheckInterval)
+	a.backupDone = make(chan struct{})
+	ticker, done := a.backupTicker, a.backupDone
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			latest, err := a.db.LatestBackupTime()
+			if err != nil || time.Since(latest) < backupInterval {
+				continue
+			}
+			if _, err := a.db.CreateBackup(); err != nil {
+				fyne.Do(func() {
+					dialog.ShowError(fmt.Errorf("定时备份失败: %v", err), a.window)
+				})
+			}
+		}
+	}()
+}
+
+// stopBackupSchedule 停止定时备份
+func (a *App) stopBackupSchedule() {
+	if a.backupTicker != nil {
+		a.backupTicker.Stop()
+		a.backupTicker = nil
+	}
+	if a.backupDone != nil {
+		close(a.backupDone)
+		a.backupDone = nil
+	}
+}
+
+// showBackupsDialog 显示备份列表，可以立即备份或从备份恢复
+func (a *App) showBackupsDialog() {
+	backups, err := a.db.ListBackups()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	var d *dialog.CustomDialog
+	backupList := widget.NewList(
+		func() int {
+			return len(backups)
+		},
+		func() fyne.CanvasObject {
+			timeLabel := widget.NewLabel("时间")
+			timeLabel.TextStyle = fyne.TextStyle{Bold: true}
+			sizeLabel := widget.NewLabel("大小")
+
+			restoreBtn := widget.NewButton("恢复", func() {
+				// 恢复功能将在更新时设置
+			})
+
+			return container.NewBorder(
+				nil, nil, nil,
+				restoreBtn, // 右侧：操作
+				container.NewHBox(timeLabel, sizeLabel),
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(backups) {
+				return
+			}
+			backup := backups[id]
+			borderContainer := obj.(*fyne.Container)
+
+			infoContainer := borderContainer.Objects[0].(*fyne.Container)
+			infoContainer.Objects[0].(*widget.Label).SetText(backup.CreatedAt.Format("2006-01-02 15:04:05"))
+			infoContainer.Objects[1].(*widget.Label).SetText(fmt.Sprintf("%.1f KB", float64(backup.Size)/1024))
+
+			restoreBtn := borderContainer.Objects[1].(*widget.Button)
+			restoreBtn.OnTapped = func() {
+				a.restoreBackup(backup)
+			}
+		},
+	)
+
+	statusLabel := widget.NewLabel(fmt.Sprintf("备份目录: %s", a.db.BackupDir()))
+	statusLabel.Wrapping = fyne.TextWrapBreak
+
+	backupBtn := widget.NewButton("立即备份", func() {
+		if _, err := a.db.CreateBackup(); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		updated, err := a.db.ListBackups()
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		backups = updated
+		backupList.Refresh()
+		statusLabel.SetText("备份完成，已校验完整性")
+	})
+
+	helpLabel := widget.NewLabel("每次解锁时自动备份，并每天定时备份。" +
+		"保留最近 7 天、4 周和 12 个月各一份备份，每份备份都会校验完整性。" +
+		"恢复后需要使用备份时的主密码登录。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, closeBtn, backupBtn),
+			helpLabel,
+			statusLabel,
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		backupList,
+	)
+
+	d = dialog.NewCustomWithoutButtons("备份", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	d.Resize(fyne.NewSize(550, 450))
+	d.Show()
+}
+
+// restoreBackup 确认后用备份替换当前密码库，并返回登录界面
+func (a *App) restoreBackup(backup *models.Backup) {
+	message := fmt.Sprintf("确定要恢复 %s 的备份吗？\n当前密码库会先自动备份。", backup.CreatedAt.Format("2006-01-02 15:04:05"))
+	a.showCustomConfirmDialog("确认恢复", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		// 恢复会关闭并替换数据库连接，先锁定并停止 SSH agent、过期检查和定时备份等后台 goroutine；
+		// 锁定同时关闭备份对话框并回到登录界面，恢复后主密钥已清除，需要重新登录
+		scheduled := a.backupTicker != nil
+		a.lockApplication()
+		a.stopBackupSchedule()
+		err := a.db.RestoreBackup(backup.Path)
+		if scheduled {
+			a.startBackupSchedule()
+		}
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		hasMasterPassword, err := a.store.HasMasterPassword()
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		if !hasMasterPassword {
+			a.showSetMasterPasswordDialog()
+			return
+		}
+		dialog.ShowInformation("恢复完成", "请使用备份时的主密码登录", a.window)
+	})
+}
+
+// showDamagedVault 密码库损坏无法打开时显示错误，只能从备份恢复
+// 登录界面不提供恢复，避免未解锁的用户把密码库回滚到旧版本
+func (a *App) showDamagedVault(openErr error) {
+	errorLabel := widget.NewLabel(fmt.Sprintf("无法打开密码库：%v", openErr))
+	errorLabel.Wrapping = fyne.TextWrapWord
+
+	restoreButton := widget.NewButton("从备份恢复", func() {
+		a.showBackupsDialog()
+	})
+
+	content := container.NewVBox(
+		errorLabel,
+		widget.NewLabel("密码库文件可能已损坏，可以选择一份备份恢复，恢复后使用备份时的主密码登录。"),
+		container.NewCenter(restoreButton),
+	)
+
+	a.window.SetTitle("密码库已损坏")
+	a.window.SetContent(container.NewPadded(content))
+	a.window.Resize(fyne.NewSize(480, 200))
+	a.window.CenterOnScreen()
+}
@@ -0,0 +1,274 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/models"
+)
+
+// 分类筛选下拉框中的特殊选项
+const (
+	allCategoriesOption = "全部分类"
+	uncategorizedOption = "未分类"
+)
+
+// refreshCategories 重新加载分类，并更新分类筛选下拉框的选项
+func (a *App) refreshCategories() {
+	categories, err := a.store.GetCategories()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	a.categories = categories
+	if a.folderTree != nil {
+		a.folderTree.Refresh()
+	}
+
+	if a.categoryFilter == nil {
+		return
+	}
+	options := append([]string{allCategoriesOption, favoritesOption, expiringOption}, a.categoryNames()...)
+	options = append(options, uncategorizedOption)
+	a.categoryFilter.Options = options
+	a.categoryFilter.Refresh()
+
+	// 当前筛选的分类被删除或重命名后回到全部分类
+	for _, option := range options {
+		if option == a.categoryFilter.Selected {
+			return
+		}
+	}
+	a.categoryFilter.SetSelected(allCategoriesOption)
+}
+
+// categoryNames 返回所有分类的名称
+func (a *App) categoryNames() []string {
+	names := make([]string, len(a.categories))
+	for i, category := range a.categories {
+		names[i] = category.Name
+	}
+	return names
+}
+
+// selectedCategoryName 返回分类下拉框选择的分类名称，未分类时返回空字符串
+func selectedCategoryName(categorySelect *widget.Select) string {
+	if categorySelect.Selected == uncategorizedOption {
+		return ""
+	}
+	return categorySelect.Selected
+}
+
+// showCategoryDialog 显示分类管理，可以添加、重命名、合并和删除分类
+func (a *App) showCategoryDialog() {
+	a.refreshCategories()
+
+	var categoryList *widget.List
+
+	// reload 重新加载分类和主列表
+	reload := func() {
+		a.loadEntries()
+		categoryList.Refresh()
+	}
+
+	categoryList = widget.NewList(
+		func() int {
+			return len(a.categories)
+		},
+		func() fyne.CanvasObject {
+			nameLabel := widget.NewLabel("分类")
+			nameLabel.TextStyle = fyne.TextStyle{Bold: true}
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			countLabel := widget.NewLabel("条目数")
+
+			renameBtn := widget.NewButton("重命名", func() {
+				// 重命名功能将在更新时设置
+			})
+			mergeBtn := widget.NewButton("合并到", func() {
+				// 合并功能将在更新时设置
+			})
+			deleteBtn := widget.NewButton("删除", func() {
+				// 删除功能将在更新时设置
+			})
+			deleteBtn.Importance = widget.DangerImportance
+
+			return container.NewBorder(
+				nil, nil, nil,
+				container.NewHBox(countLabel, renameBtn, mergeBtn, deleteBtn), // 右侧：条目数和操作
+				nameLabel,
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(a.categories) {
+				return
+			}
+			category := a.categories[id]
+			borderContainer := obj.(*fyne.Container)
+
+			borderContainer.Objects[0].(*widget.Label).SetText(category.Name)
+
+			actionContainer := borderContainer.Objects[1].(*fyne.Container)
+			actionContainer.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%d 个条目", category.EntryCount))
+			actionContainer.Objects[1].(*widget.Button).OnTapped = func() {
+				a.showRenameCategoryDialog(category, reload)
+			}
+			actionContainer.Objects[2].(*widget.Button).OnTapped = func() {
+				a.showMergeCategoryDialog(category, reload)
+			}
+			actionContainer.Objects[3].(*widget.Button).OnTapped = func() {
+				a.showDeleteCategoryDialog(category, reload)
+			}
+		},
+	)
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("新分类名称，使用 / 创建子文件夹，如 work/aws")
+
+	addFunc := func() {
+		if nameEntry.Text == "" {
+			return
+		}
+		if err := a.store.AddCategory(nameEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("无法添加分类: %v", err), a.window)
+			return
+		}
+		nameEntry.SetText("")
+		reload()
+	}
+	nameEntry.OnSubmitted = func(string) {
+		addFunc()
+	}
+	addBtn := widget.NewButton("添加", addFunc)
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	helpLabel := widget.NewLabel("重命名分类会同时更新其中的条目；合并或删除分类时，其中的条目移入选择的分类。删除分类会同时删除其子文件夹。在左侧文件夹树中拖动文件夹或条目行末的手柄可以移动它们。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, container.NewHBox(addBtn, closeBtn), nameEntry),
+			helpLabel,
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		categoryList,
+	)
+
+	d := dialog.NewCustomWithoutButtons("分类管理", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	d.Resize(fyne.NewSize(650, 480))
+	d.Show()
+}
+
+// showRenameCategoryDialog 重命名分类
+func (a *App) showRenameCategoryDialog(category *models.Category, done func()) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(category.BaseName())
+
+	d := dialog.NewCustomConfirm("重命名分类", "保存", "取消", nameEntry, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := a.store.RenameCategory(category.ID, nameEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("无法重命名分类（同名分类已存在时请使用合并）: %v", err), a.window)
+			return
+		}
+		done()
+	}, a.window)
+	d.Resize(fyne.NewSize(360, 160))
+	d.Show()
+}
+
+// otherCategories 返回除 category 以外的分类
+func (a *App) otherCategories(category *models.Category) []*models.Category {
+	var others []*models.Category
+	for _, other := range a.categories {
+		if other.ID != category.ID {
+			others = append(others, other)
+		}
+	}
+	return others
+}
+
+// showMergeCategoryDialog 选择目标分类，将分类中的条目移入目标分类后删除该分类
+func (a *App) showMergeCategoryDialog(category *models.Category, done func()) {
+	others := a.otherCategories(category)
+	if len(others) == 0 {
+		dialog.ShowInformation("合并分类", "没有可以合并到的其他分类", a.window)
+		return
+	}
+
+	names := make([]string, len(others))
+	for i, other := range others {
+		names[i] = other.Name
+	}
+	targetSelect := widget.NewSelect(names, nil)
+	targetSelect.SetSelectedIndex(0)
+
+	message := widget.NewLabel(fmt.Sprintf("将「%s」中的 %d 个条目移入以下分类，然后删除「%s」：", category.Name, category.EntryCount, category.Name))
+	message.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("合并分类", "合并", "取消", container.NewVBox(message, targetSelect), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := a.store.MergeCategories(category.ID, others[targetSelect.SelectedIndex()].ID); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		done()
+	}, a.window)
+	d.Resize(fyne.NewSize(420, 200))
+	d.Show()
+}
+
+// showDeleteCategoryDialog 删除分类及其子文件夹，可以选择将其中的条目移入其他分类或设为未分类
+func (a *App) showDeleteCategoryDialog(category *models.Category, done func()) {
+	// 被删除的子文件夹不能作为条目的新分类
+	var others []*models.Category
+	for _, other := range a.otherCategories(category) {
+		if !strings.HasPrefix(other.Name, category.Name+models.CategorySeparator) {
+			others = append(others, other)
+		}
+	}
+	options := []string{uncategorizedOption}
+	for _, other := range others {
+		options = append(options, other.Name)
+	}
+	reassignSelect := widget.NewSelect(options, nil)
+	reassignSelect.SetSelectedIndex(0)
+
+	message := widget.NewLabel(fmt.Sprintf("删除分类「%s」及其子文件夹，其中的条目改为：", category.Name))
+	message.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("删除分类", "删除", "取消", container.NewVBox(message, reassignSelect), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		reassignTo := 0
+		if index := reassignSelect.SelectedIndex(); index > 0 {
+			reassignTo = others[index-1].ID
+		}
+		if err := a.store.DeleteCategory(category.ID, reassignTo); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		done()
+	}, a.window)
+	d.Resize(fyne.NewSize(420, 200))
+	d.Show()
+}
@@ -0,0 +1,69 @@
+package gui
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+// emergencyKitTemplate 应急包页面，适合在浏览器中打开后打印或另存为 PDF
+var emergencyKitTemplate = template.Must(template.New("kit").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>密码管理器应急包</title>
+<style>
+  body { font-family: sans-serif; max-width: 720px; margin: 40px auto; color: #222; }
+  h1 { border-bottom: 2px solid #222; padding-bottom: 8px; }
+  .key { font-family: monospace; font-size: 22px; letter-spacing: 2px; padding: 16px;
+         border: 2px dashed #222; text-align: center; margin: 16px 0; word-break: break-all; }
+  .field { border-bottom: 1px solid #888; height: 32px; margin: 8px 0 24px; }
+  dt { font-weight: bold; margin-top: 12px; }
+  @media print { body { margin: 0 auto; } }
+</style>
+</head>
+<body>
+<h1>密码管理器应急包</h1>
+<p>生成时间：{{.CreatedAt.Format "2006-01-02 15:04"}}</p>
+
+<dl>
+  <dt>密码库位置</dt>
+  <dd><code>{{.VaultPath}}</code></dd>
+  <dt>恢复密钥</dt>
+  <dd><div class="key">{{.RecoveryKey}}</div></dd>
+  <dt>主密码（可选，手写）</dt>
+  <dd><div class="field"></div></dd>
+</dl>
+
+<h2>如何使用</h2>
+<ol>
+  <li>忘记主密码时，启动密码管理器，在登录界面点击“忘记主密码”。</li>
+  <li>选择“使用恢复密钥”，输入上面的恢复密钥（不区分大小写，短横线可省略）。</li>
+  <li>按提示设置新的主密码。恢复密钥使用后立即失效，应用会生成新的恢复密钥，请打印新的应急包并销毁本页。</li>
+</ol>
+
+<h2>注意事项</h2>
+<ul>
+  <li>任何人拿到本页和密码库文件即可打开全部密码，请像保管护照一样保管本页，不要拍照或保存在云盘中。</li>
+  <li>重新生成恢复密钥后，本页上的恢复密钥失效。</li>
+  <li>更换电脑时请一并备份密码库文件（包括 ~/.password_tool/backups 中的自动备份）。</li>
+</ul>
+</body>
+</html>
+`))
+
+// emergencyKit 应急包中的内容
+type emergencyKit struct {
+	RecoveryKey string
+	VaultPath   string
+	CreatedAt   time.Time
+}
+
+// writeEmergencyKit 生成应急包 HTML
+func writeEmergencyKit(w io.Writer, recoveryKey, vaultPath string) error {
+	return emergencyKitTemplate.Execute(w, emergencyKit{
+		RecoveryKey: recoveryKey,
+		VaultPath:   vaultPath,
+		CreatedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,157 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+
+	"hank.com/password_tool/models"
+)
+
+// 即将过期的快速视图，在分类筛选下拉框和文件夹树中显示
+const (
+	expiringOption = "⏰ 即将过期"
+	expiringNode   = "expiring"
+)
+
+// 距离过期不超过该天数的条目视为即将过期
+const expiringSoonDays = 14
+
+// 解锁期间检查密码是否过期的间隔
+const expiryCheckInterval = time.Minute
+
+// expiryDateLayout 过期日期的输入和显示格式
+const expiryDateLayout = "2006-01-02"
+
+// expiringSoon 检查条目是否已过期或即将过期
+func expiringSoon(entry *models.PasswordEntry, now time.Time) bool {
+	return entry.ExpiresBefore(now.AddDate(0, 0, expiringSoonDays))
+}
+
+// expiryIcon 返回条目列表中标题前的过期标记，已过期为错误图标，即将过期为警告图标
+func expiryIcon(entry *models.PasswordEntry, now time.Time) fyne.Resource {
+	switch {
+	case entry.ExpiresBefore(now):
+		return theme.ErrorIcon()
+	case expiringSoon(entry, now):
+		return theme.WarningIcon()
+	}
+	return nil
+}
+
+// expiryText 返回条目详情中显示的过期信息
+func expiryText(entry *models.PasswordEntry, now time.Time) string {
+	if entry.ExpiresAt.IsZero() {
+		return "不过期"
+	}
+
+	date := entry.ExpiresAt.Local().Format(expiryDateLayout)
+	if entry.RotationDays > 0 {
+		date += fmt.Sprintf("（每 %d 天轮换）", entry.RotationDays)
+	}
+	if entry.ExpiresBefore(now) {
+		return date + "，已过期"
+	}
+	days := int(entry.ExpiresAt.Sub(now).Hours() / 24)
+	return fmt.Sprintf("%s，%d 天后过期", date, days)
+}
+
+// parseExpiry 解析条目对话框中输入的过期日期和轮换周期，日期为空表示不过期
+// 设置了轮换周期且日期为空时，保存时按轮换周期计算过期时间
+func parseExpiry(dateText, rotationText string) (time.Time, int, error) {
+	var expiresAt time.Time
+	if dateText = strings.TrimSpace(dateText); dateText != "" {
+		var err error
+		expiresAt, err = time.ParseInLocation(expiryDateLayout, dateText, time.Local)
+		if err != nil {
+			return time.Time{}, 0, fmt.Errorf("过期日期格式应为 YYYY-MM-DD")
+		}
+	}
+
+	rotationDays := 0
+	if rotationText = strings.TrimSpace(rotationText); rotationText != "" {
+		days, err := strconv.Atoi(rotationText)
+		if err != nil || days < 0 {
+			return time.Time{}, 0, fmt.Errorf("轮换周期应为天数")
+		}
+		rotationDays = days
+	}
+	return expiresAt, rotationDays, nil
+}
+
+// startExpiryWatch 解锁后定期检查密码是否过期，有条目在解锁期间过期时发送桌面通知
+// 解锁时已经过期的条目汇总为一条通知
+func (a *App) startExpiryWatch() {
+	a.stopExpiryWatch()
+
+	now := time.Now()
+	a.lastExpiryCheck = now
+	if entries, err := a.store.GetPasswordEntries(); err == nil {
+		expired := 0
+		for _, entry := range entries {
+			if entry.ExpiresBefore(now) {
+				expired++
+			}
+		}
+		if expired > 0 {
+			a.fyneApp.SendNotification(fyne.NewNotification("密码已过期",
+				fmt.Sprintf("%d 个密码已过期，请在「%s」中查看并更换", expired, expiringOption)))
+		}
+	}
+
+	a.expiryTicker = time.NewTicker(expiryCheckInterval)
+	a.expiryDone = make(chan struct{})
+	ticker, done := a.expiryTicker, a.expiryDone
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(a.checkExpiry)
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// stopExpiryWatch 锁定时停止过期检查，Stop 不会关闭 ticker.C，需要关闭 done 让 goroutine 退出
+func (a *App) stopExpiryWatch() {
+	if a.expiryTicker != nil {
+		a.expiryTicker.Stop()
+		a.expiryTicker = nil
+	}
+	if a.expiryDone != nil {
+		close(a.expiryDone)
+		a.expiryDone = nil
+	}
+}
+
+// checkExpiry 对上次检查之后过期的条目发送通知，并刷新列表中的过期标记
+func (a *App) checkExpiry() {
+	if a.isLocked {
+		return
+	}
+	entries, err := a.store.GetPasswordEntries()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	expired := false
+	for _, entry := range entries {
+		if entry.ExpiresBefore(now) && !entry.ExpiresBefore(a.lastExpiryCheck) {
+			a.fyneApp.SendNotification(fyne.NewNotification("密码已过期",
+				fmt.Sprintf("「%s」的密码已过期，请及时更换", entry.Title)))
+			expired = true
+		}
+	}
+	a.lastExpiryCheck = now
+
+	if expired {
+		a.loadEntries()
+	}
+}
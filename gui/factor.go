@@ -0,0 +1,174 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/factor"
+)
+
+// factorTokenPathSetting 上次使用的软件令牌文件路径，只保存路径不保存内容
+const factorTokenPathSetting = "factor_token_path"
+
+// loadSoftwareToken 读取软件令牌文件
+func loadSoftwareToken(path string) (factor.Responder, error) {
+	if path == "" {
+		return nil, fmt.Errorf("请选择令牌文件")
+	}
+	token, err := factor.LoadSoftwareToken(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取令牌文件: %v", err)
+	}
+	return token, nil
+}
+
+// showFactorDialog 显示第二因素设置对话框，启用后解锁需要令牌对挑战值的应答
+func (a *App) showFactorDialog() {
+	factorName, err := a.store.FactorRequired()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	savedPath, err := a.store.GetSetting(factorTokenPathSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	statusText := "未启用第二因素"
+	if factorName != "" {
+		statusText = fmt.Sprintf("已启用第二因素（%s）: %s", factorName, savedPath)
+	}
+	statusLabel := widget.NewLabel(statusText)
+	statusLabel.Wrapping = fyne.TextWrapBreak
+
+	helpLabel := widget.NewLabel("启用后密码库保存一个随机挑战值，解锁时由令牌计算应答并参与密钥派生，没有令牌无法解锁。" +
+		"目前支持软件令牌（HMAC-SHA1），请将令牌文件存放在U盘等与密码库不同的位置并做好备份。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetPlaceHolder("当前主密码")
+	pathEntry, picker := a.newFilePicker("令牌文件路径")
+
+	generateBtn := widget.NewButton("生成软件令牌", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			_, data, err := factor.GenerateSoftwareToken()
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if _, err := writer.Write(data); err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			pathEntry.SetText(writer.URI().Path())
+		}, a.window)
+		saveDialog.SetFileName("password_tool.token")
+		saveDialog.Show()
+	})
+
+	enableBtn := widget.NewButton("启用第二因素", func() {
+		// 启用功能将在对话框创建后设置
+	})
+	disableBtn := widget.NewButton("停用第二因素", func() {
+		// 停用功能将在对话框创建后设置
+	})
+	if factorName == "" {
+		disableBtn.Disable()
+	} else {
+		enableBtn.SetText("更换令牌")
+	}
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeBtn, widget.NewLabel("")),
+		nil, nil, nil,
+		container.NewVBox(
+			helpLabel,
+			statusLabel,
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, widget.NewLabel("主密码:"), nil, passwordEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("令牌文件:"), generateBtn, picker),
+			container.NewCenter(container.NewHBox(enableBtn, disableBtn)),
+		),
+	)
+
+	d := dialog.NewCustomWithoutButtons("第二因素", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	// changeFactor 验证当前凭据后更换或移除第二因素
+	changeFactor := func(responder factor.Responder) bool {
+		credentials, err := a.currentCredentials(passwordEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return false
+		}
+		if !a.verifyCredentials(credentials) {
+			return false
+		}
+
+		credentials.Factor = responder
+		if err := a.store.ChangeMasterPassword(credentials); err != nil {
+			dialog.ShowError(err, a.window)
+			return false
+		}
+		return true
+	}
+
+	enableBtn.OnTapped = func() {
+		token, err := loadSoftwareToken(pathEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		if !changeFactor(token) {
+			return
+		}
+		if err := a.store.SetSetting(factorTokenPathSetting, pathEntry.Text); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		a.removeDialog(d)
+		d.Hide()
+		message := "下次解锁时需要同时提供主密码和令牌文件"
+		if factorName == "" {
+			message += "\n\n" + rekeyNotice
+		}
+		dialog.ShowInformation("已启用", message, a.window)
+	}
+
+	disableBtn.OnTapped = func() {
+		if !changeFactor(nil) {
+			return
+		}
+
+		a.removeDialog(d)
+		d.Hide()
+		dialog.ShowInformation("已停用", "解锁时不再需要第二因素", a.window)
+	}
+
+	d.Resize(fyne.NewSize(600, 420))
+	d.Show()
+}
@@ -0,0 +1,97 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/models"
+)
+
+// 收藏的快速视图，在分类筛选下拉框和文件夹树中显示
+const (
+	favoritesOption = "★ 收藏"
+	favoritesNode   = "favorites"
+)
+
+// 主列表的排序方式
+const (
+	sortByTitle      = "按标题"
+	sortByRecent     = "最近使用"
+	sortByFrequent   = "最常使用"
+	sortByModified   = "修改时间"
+	entrySortSetting = "entry_sort"
+)
+
+// sortOptions 排序下拉框的选项
+var sortOptions = []string{sortByTitle, sortByRecent, sortByFrequent, sortByModified}
+
+// sortEntries 按排序方式排列条目，收藏的条目始终在最前面
+func sortEntries(entries []*models.PasswordEntry, mode string) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Favorite != b.Favorite {
+			return a.Favorite
+		}
+		switch mode {
+		case sortByRecent:
+			return a.LastUsedAt.After(b.LastUsedAt)
+		case sortByFrequent:
+			return a.UseCount > b.UseCount
+		case sortByModified:
+			return a.UpdatedAt.After(b.UpdatedAt)
+		}
+		// 数据库已按标题排序
+		return false
+	})
+}
+
+// createSortSelect 创建排序方式下拉框，选择的排序方式保存在设置中
+func (a *App) createSortSelect() *widget.Select {
+	if mode, err := a.store.GetSetting(entrySortSetting); err == nil && mode != "" {
+		a.sortMode = mode
+	}
+
+	sortSelect := widget.NewSelect(sortOptions, nil)
+	if a.sortMode == "" {
+		a.sortMode = sortByTitle
+	}
+	sortSelect.SetSelected(a.sortMode)
+	sortSelect.OnChanged = func(mode string) {
+		a.sortMode = mode
+		a.sortColumn = noSortColumn // 选择排序方式时取消按列排序
+		if err := a.store.SetSetting(entrySortSetting, mode); err != nil {
+			dialog.ShowError(err, a.window)
+		}
+		a.loadEntries()
+	}
+	return sortSelect
+}
+
+// favoriteLabel 返回收藏按钮的文字
+func favoriteLabel(entry *models.PasswordEntry) string {
+	if entry.Favorite {
+		return "★"
+	}
+	return "☆"
+}
+
+// setFavorite 收藏或取消收藏条目
+func (a *App) setFavorite(entry *models.PasswordEntry, favorite bool) {
+	if err := a.store.SetFavorite(entry.ID, favorite); err != nil {
+		dialog.ShowError(fmt.Errorf("无法修改收藏: %v", err), a.window)
+		return
+	}
+	a.loadEntries()
+}
+
+// recordUse 记录条目被查看或复制，用于最近使用和最常使用排序
+func (a *App) recordUse(entry *models.PasswordEntry) {
+	if err := a.store.RecordEntryUse(entry.ID); err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	a.loadEntries()
+}
@@ -0,0 +1,269 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/models"
+)
+
+// 文件夹树中的特殊节点
+const (
+	allFoldersNode    = "all"
+	uncategorizedNode = "none"
+)
+
+// folderNode 文件夹树中的节点，拖动文件夹节点可以移动文件夹
+type folderNode struct {
+	widget.Label
+	app     *App
+	uid     widget.TreeNodeID
+	lastPos fyne.Position
+}
+
+// newFolderNode 创建文件夹树节点
+func newFolderNode(a *App) *folderNode {
+	node := &folderNode{app: a}
+	node.Truncation = fyne.TextTruncateEllipsis
+	node.ExtendBaseWidget(node)
+	return node
+}
+
+// Dragged 记录拖动位置
+func (n *folderNode) Dragged(event *fyne.DragEvent) {
+	n.lastPos = event.AbsolutePosition
+}
+
+// DragEnd 将文件夹移动到放下位置的文件夹中，放到“全部分类”上时移动到顶层
+func (n *folderNode) DragEnd() {
+	id, err := strconv.Atoi(n.uid)
+	if err != nil {
+		return
+	}
+	target := n.app.folderNodeAt(n.lastPos)
+	if target == "" || target == n.uid || target == uncategorizedNode || target == favoritesNode || target == expiringNode {
+		return
+	}
+	parentID := 0
+	if target != allFoldersNode {
+		parentID, _ = strconv.Atoi(target)
+	}
+	if err := n.app.store.MoveCategory(id, parentID); err != nil {
+		dialog.ShowError(fmt.Errorf("无法移动文件夹: %v", err), n.app.window)
+		return
+	}
+	n.app.loadEntries()
+}
+
+// dragHandle 条目行中的拖动手柄，拖到文件夹树上可以移动条目
+type dragHandle struct {
+	widget.Icon
+	app     *App
+	entry   *models.PasswordEntry
+	lastPos fyne.Position
+}
+
+// newDragHandle 创建拖动手柄
+func newDragHandle(a *App) *dragHandle {
+	handle := &dragHandle{app: a}
+	handle.Resource = theme.MenuIcon()
+	handle.ExtendBaseWidget(handle)
+	return handle
+}
+
+// Dragged 记录拖动位置
+func (h *dragHandle) Dragged(event *fyne.DragEvent) {
+	h.lastPos = event.AbsolutePosition
+}
+
+// DragEnd 将条目移动到放下位置的文件夹中，放到收藏上时收藏条目
+func (h *dragHandle) DragEnd() {
+	if h.entry == nil {
+		return
+	}
+	target := h.app.folderNodeAt(h.lastPos)
+	if target == "" || target == allFoldersNode || target == expiringNode {
+		return
+	}
+	// 拖到收藏上时收藏条目，不改变文件夹
+	if target == favoritesNode {
+		h.app.setFavorite(h.entry, true)
+		return
+	}
+
+	moved := *h.entry
+	moved.Category = ""
+	moved.CategoryID = 0
+	if target != uncategorizedNode {
+		moved.CategoryID, _ = strconv.Atoi(target)
+	}
+	if err := h.app.store.UpdatePasswordEntry(&moved); err != nil {
+		dialog.ShowError(fmt.Errorf("无法移动条目: %v", err), h.app.window)
+		return
+	}
+	h.app.loadEntries()
+}
+
+// createFolderTree 创建文件夹树侧边栏，选择节点时筛选对应文件夹及其子文件夹中的条目
+func (a *App) createFolderTree() *widget.Tree {
+	a.folderNodes = nil
+	a.folderTree = widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			if uid == "" {
+				children := []widget.TreeNodeID{allFoldersNode, favoritesNode, expiringNode}
+				children = append(children, a.childFolders(0)...)
+				return append(children, uncategorizedNode)
+			}
+			id, err := strconv.Atoi(uid)
+			if err != nil {
+				return nil
+			}
+			return a.childFolders(id)
+		},
+		func(uid widget.TreeNodeID) bool {
+			if uid == "" {
+				return true
+			}
+			id, err := strconv.Atoi(uid)
+			return err == nil && len(a.childFolders(id)) > 0
+		},
+		func(branch bool) fyne.CanvasObject {
+			node := newFolderNode(a)
+			a.folderNodes = append(a.folderNodes, node)
+			return node
+		},
+		func(uid widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+			node := obj.(*folderNode)
+			node.uid = uid
+			switch uid {
+			case allFoldersNode:
+				node.SetText(allCategoriesOption)
+			case favoritesNode:
+				node.SetText(favoritesOption)
+			case expiringNode:
+				node.SetText(expiringOption)
+			case uncategorizedNode:
+				node.SetText(uncategorizedOption)
+			default:
+				if category := a.folderByUID(uid); category != nil {
+					node.SetText(fmt.Sprintf("%s (%d)", category.BaseName(), category.EntryCount))
+				}
+			}
+		},
+	)
+
+	a.folderTree.OnSelected = func(uid widget.TreeNodeID) {
+		switch uid {
+		case allFoldersNode:
+			a.categoryFilter.SetSelected(allCategoriesOption)
+		case favoritesNode:
+			a.categoryFilter.SetSelected(favoritesOption)
+		case expiringNode:
+			a.categoryFilter.SetSelected(expiringOption)
+		case uncategorizedNode:
+			a.categoryFilter.SetSelected(uncategorizedOption)
+		default:
+			if category := a.folderByUID(uid); category != nil {
+				a.categoryFilter.SetSelected(category.Name)
+			}
+		}
+	}
+	return a.folderTree
+}
+
+// childFolders 返回父文件夹下的子文件夹节点，parentID 为 0 时返回顶层文件夹
+func (a *App) childFolders(parentID int) []widget.TreeNodeID {
+	var children []widget.TreeNodeID
+	for _, category := range a.categories {
+		if category.ParentID == parentID {
+			children = append(children, strconv.Itoa(category.ID))
+		}
+	}
+	return children
+}
+
+// folderByUID 根据树节点返回文件夹
+func (a *App) folderByUID(uid widget.TreeNodeID) *models.Category {
+	id, err := strconv.Atoi(uid)
+	if err != nil {
+		return nil
+	}
+	for _, category := range a.categories {
+		if category.ID == id {
+			return category
+		}
+	}
+	return nil
+}
+
+// selectFolderNode 让文件夹树的选中节点与分类筛选下拉框保持一致
+func (a *App) selectFolderNode(selected string) {
+	if a.folderTree == nil {
+		return
+	}
+	switch selected {
+	case allCategoriesOption:
+		a.folderTree.Select(allFoldersNode)
+	case favoritesOption:
+		a.folderTree.Select(favoritesNode)
+	case expiringOption:
+		a.folderTree.Select(expiringNode)
+	case uncategorizedOption:
+		a.folderTree.Select(uncategorizedNode)
+	default:
+		for _, category := range a.categories {
+			if category.Name != selected {
+				continue
+			}
+			// 展开上级文件夹，使选中的节点可见
+			for parent := category.ParentID; parent != 0; {
+				uid := strconv.Itoa(parent)
+				a.folderTree.OpenBranch(uid)
+				parentFolder := a.folderByUID(uid)
+				if parentFolder == nil {
+					break
+				}
+				parent = parentFolder.ParentID
+			}
+			a.folderTree.Select(strconv.Itoa(category.ID))
+			return
+		}
+	}
+}
+
+// folderNodeAt 返回窗口坐标 pos 处的文件夹树节点，不在树上时返回空字符串
+func (a *App) folderNodeAt(pos fyne.Position) widget.TreeNodeID {
+	if a.folderTree == nil {
+		return ""
+	}
+	driver := a.fyneApp.Driver()
+	if !containsPosition(driver.AbsolutePositionForObject(a.folderTree), a.folderTree.Size(), pos) {
+		return ""
+	}
+	for _, node := range a.folderNodes {
+		if !node.Visible() || node.uid == "" {
+			continue
+		}
+		if containsPosition(driver.AbsolutePositionForObject(node), node.Size(), pos) {
+			return node.uid
+		}
+	}
+	return ""
+}
+
+// containsPosition 检查 pos 是否在以 origin 为左上角、大小为 size 的区域内
+func containsPosition(origin fyne.Position, size fyne.Size, pos fyne.Position) bool {
+	return pos.X >= origin.X && pos.X < origin.X+size.Width &&
+		pos.Y >= origin.Y && pos.Y < origin.Y+size.Height
+}
+
+// inFolder 检查条目是否属于文件夹 path 或其子文件夹，path 为空时只匹配未分类的条目
+func inFolder(entry *models.PasswordEntry, path string) bool {
+	return entry.Category == path || strings.HasPrefix(entry.Category, path+models.CategorySeparator)
+}
@@ -0,0 +1,243 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/database"
+)
+
+// keyFilePathSetting 上次使用的密钥文件路径，只保存路径不保存内容
+const keyFilePathSetting = "key_file_path"
+
+// rekeyNotice 新添加密钥文件或第二因素后密码库密钥重新生成的提示
+const rekeyNotice = "密码库密钥已重新生成，旧的自动备份、恢复密钥和恢复份额已失效，请重新生成恢复密钥和恢复份额。" +
+	"同步服务器的登录令牌已随之更换，下次同步时所有条目会用新密钥重新上传；" +
+	"其他同步的设备需要重新复制一次 passwords.db，在此之前它们的变更无法合并到本机。"
+
+// loadKeyFile 读取并解析密钥文件
+func loadKeyFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("请选择密钥文件")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取密钥文件: %v", err)
+	}
+	return crypto.ParseKeyFile(data)
+}
+
+// currentCredentials 使用输入的主密码以及上次使用的密钥文件和软件令牌组成当前凭据
+func (a *App) currentCredentials(password string) (database.Credentials, error) {
+	credentials := database.Credentials{Password: password}
+
+	keyFileRequired, err := a.store.KeyFileRequired()
+	if err != nil {
+		return credentials, err
+	}
+	if keyFileRequired {
+		path, err := a.store.GetSetting(keyFilePathSetting)
+		if err != nil {
+			return credentials, err
+		}
+		if credentials.KeyFile, err = loadKeyFile(path); err != nil {
+			return credentials, err
+		}
+	}
+
+	factorName, err := a.store.FactorRequired()
+	if err != nil {
+		return credentials, err
+	}
+	if factorName != "" {
+		path, err := a.store.GetSetting(factorTokenPathSetting)
+		if err != nil {
+			return credentials, err
+		}
+		if credentials.Factor, err = loadSoftwareToken(path); err != nil {
+			return credentials, err
+		}
+	}
+	return credentials, nil
+}
+
+// newFilePicker 创建文件路径输入框和选择按钮
+func (a *App) newFilePicker(placeHolder string) (*widget.Entry, fyne.CanvasObject) {
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder(placeHolder)
+
+	chooseBtn := widget.NewButton("选择", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+			pathEntry.SetText(reader.URI().Path())
+		}, a.window)
+	})
+
+	return pathEntry, container.NewBorder(nil, nil, nil, chooseBtn, pathEntry)
+}
+
+// showKeyFileDialog 显示密钥文件设置对话框，启用后解锁需要同时提供主密码和密钥文件
+func (a *App) showKeyFileDialog() {
+	required, err := a.store.KeyFileRequired()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	savedPath, err := a.store.GetSetting(keyFilePathSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	statusText := "未启用密钥文件"
+	if required {
+		statusText = "已启用密钥文件: " + savedPath
+	}
+	statusLabel := widget.NewLabel(statusText)
+	statusLabel.Wrapping = fyne.TextWrapBreak
+
+	helpLabel := widget.NewLabel("启用后解锁需要同时提供主密码和密钥文件，仅窃取密码库文件并偷看到主密码无法解锁。" +
+		"可以使用任意文件或 KeePass 的 XML 密钥文件。请备份密钥文件，丢失后只能通过恢复密钥或恢复份额重置。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetPlaceHolder("当前主密码")
+	pathEntry, picker := a.newFilePicker("密钥文件路径")
+
+	generateBtn := widget.NewButton("生成密钥文件", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			data, err := crypto.GenerateKeyFile()
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if _, err := writer.Write(data); err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			pathEntry.SetText(writer.URI().Path())
+		}, a.window)
+		saveDialog.SetFileName("password_tool.keyx")
+		saveDialog.Show()
+	})
+
+	// verifyCurrent 验证当前的凭据，返回可在其基础上修改的凭据
+	verifyCurrent := func() (database.Credentials, bool) {
+		current, err := a.currentCredentials(passwordEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return current, false
+		}
+
+		return current, a.verifyCredentials(current)
+	}
+
+	enableBtn := widget.NewButton("启用密钥文件", func() {
+		// 启用功能将在对话框创建后设置
+	})
+	disableBtn := widget.NewButton("停用密钥文件", func() {
+		// 停用功能将在对话框创建后设置
+	})
+	if !required {
+		disableBtn.Disable()
+	} else {
+		enableBtn.SetText("更换密钥文件")
+	}
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeBtn, widget.NewLabel("")),
+		nil, nil, nil,
+		container.NewVBox(
+			helpLabel,
+			statusLabel,
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, widget.NewLabel("主密码:"), nil, passwordEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("密钥文件:"), generateBtn, picker),
+			container.NewCenter(container.NewHBox(enableBtn, disableBtn)),
+		),
+	)
+
+	d := dialog.NewCustomWithoutButtons("密钥文件", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	enableBtn.OnTapped = func() {
+		credentials, ok := verifyCurrent()
+		if !ok {
+			return
+		}
+		keyFile, err := loadKeyFile(pathEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		credentials.KeyFile = keyFile
+		if err := a.store.ChangeMasterPassword(credentials); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		if err := a.store.SetSetting(keyFilePathSetting, pathEntry.Text); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		a.removeDialog(d)
+		d.Hide()
+		message := "下次解锁时需要同时提供主密码和密钥文件"
+		if !required {
+			message += "\n\n" + rekeyNotice
+		}
+		dialog.ShowInformation("已启用", message, a.window)
+	}
+
+	disableBtn.OnTapped = func() {
+		credentials, ok := verifyCurrent()
+		if !ok {
+			return
+		}
+
+		credentials.KeyFile = nil
+		if err := a.store.ChangeMasterPassword(credentials); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		a.removeDialog(d)
+		d.Hide()
+		dialog.ShowInformation("已停用", "解锁时只需要主密码", a.window)
+	}
+
+	d.Resize(fyne.NewSize(600, 420))
+	d.Show()
+}
@@ -0,0 +1,181 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/database"
+)
+
+// wipeAfterFailuresOptions 可选的清除上限，0 表示不清除
+var wipeAfterFailuresOptions = []int{0, 5, 10, 20, 50}
+
+// 解锁后的失败记录提醒中最多列出的次数
+const maxReportedFailures = 10
+
+// formatWait 将等待时间格式化为中文
+func formatWait(wait time.Duration) string {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 60 {
+		return fmt.Sprintf("%d 秒", seconds)
+	}
+	return fmt.Sprintf("%d 分 %d 秒", seconds/60, seconds%60)
+}
+
+// wipeOptionLabel 返回清除上限选项的显示文本
+func wipeOptionLabel(n int) string {
+	if n == 0 {
+		return "从不清除"
+	}
+	return fmt.Sprintf("连续输错 %d 次后清除", n)
+}
+
+// showUnlockError 显示解锁失败的原因，密码库被清除后回到设置主密码界面
+func (a *App) showUnlockError(err error) {
+	var lockout *database.LockoutError
+	switch {
+	case errors.As(err, &lockout):
+		dialog.ShowError(fmt.Errorf("尝试次数过多，请在 %s 后重试", formatWait(lockout.Wait)), a.window)
+	case errors.Is(err, database.ErrVaultWiped):
+		a.showSetMasterPasswordDialog()
+		dialog.ShowInformation("密码库已清除", "连续输错主密码的次数达到上限，密码库和自动备份已被清除", a.window)
+	default:
+		dialog.ShowError(err, a.window)
+	}
+}
+
+// verifyCredentials 验证已解锁时输入的当前凭据，输错时与解锁共用退避，但不计入清除上限
+func (a *App) verifyCredentials(credentials database.Credentials) bool {
+	valid, err := a.store.VerifyCredentials(credentials)
+	if err != nil {
+		a.showUnlockError(err)
+		return false
+	}
+	if !valid {
+		a.showWrongPasswordError(true)
+		return false
+	}
+	return true
+}
+
+// showWrongPasswordError 提示密码错误以及连续失败次数，解锁界面（unlocked 为 false）还提示清除前的剩余次数
+func (a *App) showWrongPasswordError(unlocked bool) {
+	message := "密码错误"
+
+	attempts, err := a.db.FailedLoginAttempts()
+	if err == nil && len(attempts) > 1 {
+		message += fmt.Sprintf("，已连续输错 %d 次", len(attempts))
+	}
+	if remaining, err := a.db.FailuresBeforeWipe(); err == nil && remaining > 0 && !unlocked {
+		message += fmt.Sprintf("，再输错 %d 次将清除密码库", remaining)
+	}
+	if wait, err := a.db.LoginWait(); err == nil && wait > 0 {
+		message += fmt.Sprintf("，请在 %s 后重试", formatWait(wait))
+	}
+
+	dialog.ShowError(errors.New(message), a.window)
+}
+
+// showFailedLoginReport 解锁后提醒上次解锁以来失败的解锁尝试
+func (a *App) showFailedLoginReport() {
+	attempts := a.db.FailedLoginsBeforeUnlock()
+	if len(attempts) == 0 {
+		return
+	}
+
+	var lines []string
+	start := 0
+	if len(attempts) > maxReportedFailures {
+		start = len(attempts) - maxReportedFailures
+	}
+	for _, attemptedAt := range attempts[start:] {
+		lines = append(lines, attemptedAt.Format("2006-01-02 15:04:05"))
+	}
+	if start > 0 {
+		lines = append([]string{"……"}, lines...)
+	}
+
+	message := fmt.Sprintf("自上次解锁以来有 %d 次失败的解锁尝试：\n\n%s\n\n如果不是您本人操作，请考虑修改主密码。",
+		len(attempts), strings.Join(lines, "\n"))
+	dialog.ShowInformation("解锁失败记录", message, a.window)
+}
+
+// showLoginProtectionDialog 显示登录保护设置对话框
+func (a *App) showLoginProtectionDialog() {
+	current, err := a.db.WipeAfterFailures()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	options := append([]int(nil), wipeAfterFailuresOptions...)
+	var labels []string
+	for _, n := range options {
+		labels = append(labels, wipeOptionLabel(n))
+	}
+	wipeSelect := widget.NewSelect(labels, nil)
+	wipeSelect.SetSelected(wipeOptionLabel(current))
+	if wipeSelect.SelectedIndex() < 0 {
+		// 保留通过其他方式设置的上限
+		wipeSelect.Options = append(wipeSelect.Options, wipeOptionLabel(current))
+		options = append(options, current)
+		wipeSelect.SetSelected(wipeOptionLabel(current))
+	}
+
+	helpLabel := widget.NewLabel("连续输错主密码 3 次后，每次重试前需要等待的时间成倍增加（最长 15 分钟），失败记录会在下次成功解锁后提示。" +
+		"启用清除后，连续输错达到上限时将清除密码库和所有自动备份，且无法撤销，请确保有其他途径的备份。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	saveBtn := widget.NewButton("保存", func() {
+		// 保存功能将在对话框创建后设置
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeBtn, widget.NewLabel("")),
+		nil, nil, nil,
+		container.NewVBox(
+			helpLabel,
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, widget.NewLabel("清除密码库:"), nil, wipeSelect),
+			container.NewCenter(saveBtn),
+		),
+	)
+
+	d := dialog.NewCustomWithoutButtons("登录保护", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	saveBtn.OnTapped = func() {
+		index := wipeSelect.SelectedIndex()
+		if index < 0 {
+			return
+		}
+		if err := a.db.SetWipeAfterFailures(options[index]); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		a.removeDialog(d)
+		d.Hide()
+		dialog.ShowInformation("已保存", wipeSelect.Selected, a.window)
+	}
+
+	d.Resize(fyne.NewSize(520, 300))
+	d.Show()
+}
@@ -0,0 +1,189 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/models"
+	"hank.com/password_tool/search"
+)
+
+// 命令面板最多显示的结果数
+const maxPaletteItems = 30
+
+// paletteItem 命令面板中的一项，可以是操作或条目
+type paletteItem struct {
+	text string
+	hint string // 操作的快捷键，或条目的用户名和文件夹
+	run  func()
+}
+
+// paletteCommands 返回命令面板中的操作
+func (a *App) paletteCommands() []paletteItem {
+	showView := func(option string) func() {
+		return func() {
+			a.categoryFilter.SetSelected(option)
+		}
+	}
+	return []paletteItem{
+		{"添加密码", shortcutHint(fyne.KeyN), a.showAddEntryDialog},
+		{"搜索", shortcutHint(fyne.KeyF), a.focusSearch},
+		{"复制当前条目的用户名", shortcutHint(fyne.KeyB), a.copyCurrentUsername},
+		{"复制当前条目的密码", shortcutHint(fyne.KeyC), a.copyCurrentPassword},
+		{"锁定", shortcutHint(fyne.KeyL), a.lockApplication},
+		{"显示全部条目", "", showView(allCategoriesOption)},
+		{"显示收藏", "", showView(favoritesOption)},
+		{"显示即将过期", "", showView(expiringOption)},
+		{"分类管理", "", a.showCategoryDialog},
+		{"SSH密钥", "", a.showSSHKeysDialog},
+		{"同步", "", a.showSyncDialog},
+		{"备份", "", a.showBackupsDialog},
+		{"回收站", "", a.showTrashDialog},
+		{"审计日志", "", a.showAuditLogDialog},
+		{"账户恢复", "", a.showRecoverySetupDialog},
+		{"密钥文件", "", a.showKeyFileDialog},
+		{"第二因素", "", a.showFactorDialog},
+		{"登录保护", "", a.showLoginProtectionDialog},
+	}
+}
+
+// entryPaletteItem 返回打开条目详情的命令面板项
+func (a *App) entryPaletteItem(entry *models.PasswordEntry) paletteItem {
+	hint := entry.Username
+	if entry.Category != "" {
+		hint = fmt.Sprintf("%s · %s", entry.Username, entry.Category)
+	}
+	return paletteItem{
+		text: "条目：" + entry.Title,
+		hint: hint,
+		run: func() {
+			a.setCurrentEntry(entry)
+			a.showEntryDetails(entry)
+		},
+	}
+}
+
+// paletteItems 返回匹配查询的操作和条目，操作在前，各自按匹配程度排列
+func (a *App) paletteItems(commands []paletteItem, entries []*models.PasswordEntry, query string) []paletteItem {
+	var items []paletteItem
+	if query == "" {
+		items = append(items, commands...)
+	} else {
+		type scored struct {
+			item  paletteItem
+			score int
+		}
+		var matched []scored
+		for _, command := range commands {
+			if score := search.MatchText(command.text, query); score > 0 {
+				matched = append(matched, scored{command, score})
+			}
+		}
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].score > matched[j].score
+		})
+		for _, m := range matched {
+			items = append(items, m.item)
+		}
+	}
+
+	for _, entry := range search.Search(entries, query) {
+		if len(items) >= maxPaletteItems {
+			break
+		}
+		items = append(items, a.entryPaletteItem(entry))
+	}
+	return items
+}
+
+// showCommandPalette 显示命令面板，输入时模糊匹配操作和条目，方向键选择，回车执行
+func (a *App) showCommandPalette() {
+	entries, err := a.store.GetPasswordEntries()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	commands := a.paletteCommands()
+	items := a.paletteItems(commands, entries, "")
+	selected := 0
+
+	var d *dialog.CustomDialog
+	run := func(item paletteItem) {
+		a.removeDialog(d)
+		d.Hide()
+		item.run()
+	}
+
+	itemList := widget.NewList(
+		func() int {
+			return len(items)
+		},
+		func() fyne.CanvasObject {
+			hintLabel := widget.NewLabel("")
+			hintLabel.Importance = widget.LowImportance
+			textLabel := widget.NewLabel("")
+			textLabel.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, nil, hintLabel, textLabel)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(items) {
+				return
+			}
+			row := obj.(*fyne.Container)
+			textLabel := row.Objects[0].(*widget.Label)
+			textLabel.SetText(items[id].text)
+			// 方向键选择的项突出显示
+			if id == selected {
+				textLabel.Importance = widget.HighImportance
+			} else {
+				textLabel.Importance = widget.MediumImportance
+			}
+			textLabel.Refresh()
+			row.Objects[1].(*widget.Label).SetText(items[id].hint)
+		},
+	)
+	itemList.OnSelected = func(id widget.ListItemID) {
+		itemList.UnselectAll()
+		if id < len(items) {
+			run(items[id])
+		}
+	}
+
+	queryEntry := newKeyEntry(a)
+	queryEntry.SetPlaceHolder("输入操作或条目名称，支持拼音首字母")
+	queryEntry.OnChanged = func(query string) {
+		items = a.paletteItems(commands, entries, query)
+		selected = 0
+		itemList.Refresh()
+		itemList.ScrollToTop()
+	}
+	queryEntry.OnSubmitted = func(string) {
+		if selected < len(items) {
+			run(items[selected])
+		}
+	}
+	queryEntry.onMove = func(delta int) {
+		if len(items) == 0 {
+			return
+		}
+		selected = (selected + delta + len(items)) % len(items)
+		itemList.Refresh()
+		itemList.ScrollTo(selected)
+	}
+	queryEntry.onEscape = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	content := container.NewBorder(queryEntry, nil, nil, nil, itemList)
+	d = dialog.NewCustomWithoutButtons("命令面板", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+	d.Resize(fyne.NewSize(520, 420))
+	d.Show()
+	a.window.Canvas().Focus(queryEntry)
+}
@@ -0,0 +1,383 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// showRecoverySetupDialog 显示账户恢复设置对话框：重新生成恢复密钥，或生成恢复份额
+func (a *App) showRecoverySetupDialog() {
+	counts := []string{"2", "3", "4", "5", "6", "7", "8", "9", "10"}
+
+	totalSelect := widget.NewSelect(counts, nil)
+	totalSelect.SetSelected("5")
+	thresholdSelect := widget.NewSelect(counts, nil)
+	thresholdSelect.SetSelected("3")
+
+	helpLabel := widget.NewLabel("将密码库密钥拆分为多个恢复份额，分别交给信任的人或存放在不同的地方。" +
+		"忘记主密码时，凑齐指定数量的份额即可恢复密码库并设置新主密码；少于该数量的份额无法得到任何信息。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	thresholdLabel := widget.NewLabel("")
+	if threshold, err := a.db.RecoveryThreshold(); err == nil && threshold > 0 {
+		thresholdLabel.SetText(fmt.Sprintf("已生成过恢复份额（需要 %d 份），旧份额在修改主密码后仍然有效。", threshold))
+		thresholdLabel.Wrapping = fyne.TextWrapWord
+	}
+
+	keyHelpLabel := widget.NewLabel("恢复密钥保存在可打印的应急包中，忘记主密码时可用它重置主密码。")
+	keyHelpLabel.Wrapping = fyne.TextWrapWord
+	keyStatus := "尚未生成恢复密钥"
+	if hasRecoveryKey, err := a.db.HasRecoveryKey(); err == nil && hasRecoveryKey {
+		keyStatus = "已生成恢复密钥，重新生成后旧的恢复密钥失效"
+	}
+	recoveryKeyBtn := widget.NewButton("生成新的恢复密钥", func() {
+		// 生成功能将在对话框创建后设置
+	})
+
+	generateBtn := widget.NewButton("生成恢复份额", func() {
+		// 生成功能将在对话框创建后设置
+	})
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeBtn, widget.NewLabel("")),
+		nil, nil, nil,
+		container.NewVBox(
+			keyHelpLabel,
+			container.NewBorder(nil, nil, nil, recoveryKeyBtn, widget.NewLabel(keyStatus)),
+			widget.NewSeparator(),
+			helpLabel,
+			thresholdLabel,
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, widget.NewLabel("份额总数:"), nil, totalSelect),
+			container.NewBorder(nil, nil, widget.NewLabel("恢复所需份额:"), nil, thresholdSelect),
+			container.NewCenter(generateBtn),
+		),
+	)
+
+	d := dialog.NewCustomWithoutButtons("账户恢复", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	recoveryKeyBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+		a.createAndShowRecoveryKey()
+	}
+
+	generateBtn.OnTapped = func() {
+		total, _ := strconv.Atoi(totalSelect.Selected)
+		threshold, _ := strconv.Atoi(thresholdSelect.Selected)
+		if threshold > total {
+			dialog.ShowError(fmt.Errorf("恢复所需份额不能多于份额总数"), a.window)
+			return
+		}
+
+		shares, err := a.db.CreateRecoveryShares(total, threshold)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		a.removeDialog(d)
+		d.Hide()
+		a.showRecoverySharesDialog(shares, threshold)
+	}
+
+	d.Resize(fyne.NewSize(550, 480))
+	d.Show()
+}
+
+// showRecoverySharesDialog 逐个显示恢复份额的单词和二维码，便于抄写或打印
+func (a *App) showRecoverySharesDialog(shares []string, threshold int) {
+	current := 0
+
+	titleLabel := widget.NewLabel("")
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+	wordsLabel := widget.NewLabel("")
+	wordsLabel.Wrapping = fyne.TextWrapWord
+	wordsLabel.TextStyle = fyne.TextStyle{Monospace: true}
+
+	qrImage := canvas.NewImageFromResource(nil)
+	qrImage.FillMode = canvas.ImageFillContain
+	qrImage.SetMinSize(fyne.NewSize(200, 200))
+
+	prevBtn := widget.NewButton("上一份", nil)
+	nextBtn := widget.NewButton("下一份", nil)
+	copyBtn := widget.NewButton("复制", func() {
+		a.window.Clipboard().SetContent(shares[current])
+	})
+
+	showShare := func() {
+		titleLabel.SetText(fmt.Sprintf("第 %d / %d 份（任意 %d 份可以恢复）", current+1, len(shares), threshold))
+		wordsLabel.SetText(shares[current])
+
+		png, err := qrcode.Encode(shares[current], qrcode.Medium, 256)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		qrImage.Resource = fyne.NewStaticResource(fmt.Sprintf("share-%d.png", current+1), png)
+		qrImage.Refresh()
+
+		if current == 0 {
+			prevBtn.Disable()
+		} else {
+			prevBtn.Enable()
+		}
+		if current == len(shares)-1 {
+			nextBtn.Disable()
+		} else {
+			nextBtn.Enable()
+		}
+	}
+	prevBtn.OnTapped = func() {
+		current--
+		showShare()
+	}
+	nextBtn.OnTapped = func() {
+		current++
+		showShare()
+	}
+
+	warningLabel := widget.NewLabel("请抄写或打印每一份后分开保管，关闭后无法再次查看这些份额。")
+	warningLabel.Wrapping = fyne.TextWrapWord
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeBtn, warningLabel),
+		container.NewCenter(container.NewHBox(prevBtn, copyBtn, nextBtn)),
+		nil, nil,
+		container.NewVBox(
+			titleLabel,
+			wordsLabel,
+			container.NewCenter(qrImage),
+		),
+	)
+
+	d := dialog.NewCustomWithoutButtons("恢复份额", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.showCustomConfirmDialog("确认关闭", "确定已保存所有恢复份额吗？", func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			a.removeDialog(d)
+			d.Hide()
+		})
+	}
+
+	showShare()
+	d.Resize(fyne.NewSize(550, 520))
+	d.Show()
+}
+
+// showRecoverWithSharesDialog 在登录界面使用恢复份额恢复密码库，成功后强制设置新主密码
+func (a *App) showRecoverWithSharesDialog(threshold int) {
+	shareEntries := make([]*widget.Entry, threshold)
+	form := container.NewVBox()
+	for i := range shareEntries {
+		entry := widget.NewMultiLineEntry()
+		entry.SetPlaceHolder("输入份额的单词，以空格分隔")
+		entry.Wrapping = fyne.TextWrapWord
+		entry.SetMinRowsVisible(2)
+		shareEntries[i] = entry
+		form.Add(widget.NewLabel(fmt.Sprintf("份额 %d:", i+1)))
+		form.Add(entry)
+	}
+
+	recoverBtn := widget.NewButton("恢复", func() {
+		// 恢复功能将在对话框创建后设置
+	})
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	helpLabel := widget.NewLabel(fmt.Sprintf("输入任意 %d 份恢复份额，恢复后需要设置新的主密码。", threshold))
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeBtn, helpLabel),
+		container.NewCenter(recoverBtn),
+		nil, nil,
+		container.NewVScroll(form),
+	)
+
+	d := dialog.NewCustomWithoutButtons("使用恢复份额", content, a.window)
+
+	closeBtn.OnTapped = func() {
+		d.Hide()
+	}
+
+	recoverBtn.OnTapped = func() {
+		shares := make([]string, len(shareEntries))
+		for i, entry := range shareEntries {
+			shares[i] = entry.Text
+		}
+
+		if err := a.db.RecoverWithShares(shares); err != nil {
+			dialog.ShowError(fmt.Errorf("恢复失败: %v", err), a.window)
+			return
+		}
+
+		d.Hide()
+		a.showSetMasterPasswordDialog()
+	}
+
+	d.Resize(fyne.NewSize(550, 450))
+	d.Show()
+}
+
+// showRecoveryKeyDialog 显示新生成的恢复密钥，并可保存为可打印的应急包
+func (a *App) showRecoveryKeyDialog(recoveryKey string) {
+	keyLabel := widget.NewLabel(recoveryKey)
+	keyLabel.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
+	keyLabel.Alignment = fyne.TextAlignCenter
+	keyLabel.Wrapping = fyne.TextWrapBreak
+
+	helpLabel := widget.NewLabel("忘记主密码时，可以在登录界面使用恢复密钥重置主密码。" +
+		"请保存应急包并打印后妥善保管，关闭后无法再次查看该恢复密钥。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	copyBtn := widget.NewButton("复制", func() {
+		a.window.Clipboard().SetContent(recoveryKey)
+	})
+
+	saveBtn := widget.NewButton("保存应急包", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			// 应急包包含恢复密钥，写入前只允许当前用户读写
+			if err := restrictToOwner(writer); err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if err := writeEmergencyKit(writer, recoveryKey, a.db.Path()); err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			dialog.ShowInformation("已保存", "请在浏览器中打开应急包并打印", a.window)
+		}, a.window)
+		saveDialog.SetFileName("password_tool-应急包.html")
+		saveDialog.Show()
+	})
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeBtn, widget.NewLabel("")),
+		container.NewCenter(container.NewHBox(copyBtn, saveBtn)),
+		nil, nil,
+		container.NewVBox(helpLabel, widget.NewSeparator(), keyLabel),
+	)
+
+	d := dialog.NewCustomWithoutButtons("恢复密钥", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.showCustomConfirmDialog("确认关闭", "确定已保存恢复密钥吗？", func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			a.removeDialog(d)
+			d.Hide()
+		})
+	}
+
+	d.Resize(fyne.NewSize(520, 300))
+	d.Show()
+}
+
+// createAndShowRecoveryKey 生成新的恢复密钥并显示
+func (a *App) createAndShowRecoveryKey() {
+	recoveryKey, err := a.db.CreateRecoveryKey()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	a.showRecoveryKeyDialog(recoveryKey)
+}
+
+// showForgotPasswordDialog 根据已启用的恢复方式选择恢复密钥或恢复份额
+func (a *App) showForgotPasswordDialog(hasRecoveryKey bool, threshold int) {
+	if !hasRecoveryKey {
+		a.showRecoverWithSharesDialog(threshold)
+		return
+	}
+	if threshold == 0 {
+		a.showRecoverWithKeyDialog()
+		return
+	}
+
+	var d dialog.Dialog
+	keyBtn := widget.NewButton("使用恢复密钥", func() {
+		d.Hide()
+		a.showRecoverWithKeyDialog()
+	})
+	sharesBtn := widget.NewButton("使用恢复份额", func() {
+		d.Hide()
+		a.showRecoverWithSharesDialog(threshold)
+	})
+
+	d = dialog.NewCustom("忘记主密码", "取消", container.NewVBox(keyBtn, sharesBtn), a.window)
+	d.Show()
+}
+
+// showRecoverWithKeyDialog 在登录界面使用恢复密钥恢复密码库，成功后强制设置新主密码
+func (a *App) showRecoverWithKeyDialog() {
+	keyEntry := widget.NewEntry()
+	keyEntry.SetPlaceHolder("XXXX-XXXX-XXXX-...")
+
+	var d dialog.Dialog
+	recoverFunc := func() {
+		if err := a.db.RecoverWithRecoveryKey(keyEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("恢复失败: %v", err), a.window)
+			return
+		}
+
+		// 恢复密钥使用后需要更换
+		a.rotateRecoveryKey = true
+		d.Hide()
+		a.showSetMasterPasswordDialog()
+	}
+	keyEntry.OnSubmitted = func(string) {
+		recoverFunc()
+	}
+
+	helpLabel := widget.NewLabel("输入应急包中的恢复密钥，恢复后需要设置新的主密码，并会生成新的恢复密钥。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	d = dialog.NewCustomConfirm("使用恢复密钥", "恢复", "取消", container.NewVBox(helpLabel, keyEntry), func(confirmed bool) {
+		if confirmed {
+			recoverFunc()
+		}
+	}, a.window)
+	d.Resize(fyne.NewSize(480, 200))
+	d.Show()
+}
@@ -0,0 +1,199 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/models"
+)
+
+// 复制等操作的提示在状态栏中显示的时间
+const statusDuration = 3 * time.Second
+
+// shortcutKey 返回 Ctrl（macOS 上为 Cmd）加按键的快捷键
+func shortcutKey(key fyne.KeyName) *desktop.CustomShortcut {
+	return &desktop.CustomShortcut{KeyName: key, Modifier: fyne.KeyModifierShortcutDefault}
+}
+
+// shortcutHint 返回快捷键的显示名称，如 Ctrl+N
+func shortcutHint(key fyne.KeyName) string {
+	if fyne.KeyModifierShortcutDefault == fyne.KeyModifierSuper {
+		return "Cmd+" + string(key)
+	}
+	return "Ctrl+" + string(key)
+}
+
+// registerShortcuts 注册主界面的全局快捷键
+func (a *App) registerShortcuts() {
+	a.shortcuts = make(map[string]func())
+	a.addShortcut(shortcutKey(fyne.KeyF), a.focusSearch)
+	a.addShortcut(shortcutKey(fyne.KeyN), a.showAddEntryDialog)
+	a.addShortcut(shortcutKey(fyne.KeyB), a.copyCurrentUsername)
+	// Ctrl+C 由驱动转换为复制快捷键，而不是自定义快捷键
+	a.addShortcut(&fyne.ShortcutCopy{}, a.copyCurrentPassword)
+	a.addShortcut(shortcutKey(fyne.KeyL), a.lockApplication)
+	a.addShortcut(shortcutKey(fyne.KeyK), a.showCommandPalette)
+}
+
+// addShortcut 在窗口上注册快捷键，锁定时不响应
+func (a *App) addShortcut(shortcut fyne.Shortcut, action func()) {
+	run := func() {
+		if a.isLocked {
+			return
+		}
+		a.resetAutoLockTimer()
+		action()
+	}
+	a.shortcuts[shortcut.ShortcutName()] = run
+	a.window.Canvas().AddShortcut(shortcut, func(fyne.Shortcut) {
+		run()
+	})
+}
+
+// keyEntry 支持方向键和 Esc 的输入框，用于搜索框和命令面板
+// 输入框获得焦点时快捷键会先交给输入框，这里把窗口的快捷键转交给对应的操作
+type keyEntry struct {
+	widget.Entry
+	app      *App
+	onMove   func(delta int) // 按上下方向键时调用
+	onEscape func()
+}
+
+// newKeyEntry 创建输入框
+func newKeyEntry(a *App) *keyEntry {
+	e := &keyEntry{app: a}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// TypedKey 处理方向键和 Esc，其他按键交给输入框
+func (e *keyEntry) TypedKey(key *fyne.KeyEvent) {
+	switch {
+	case key.Name == fyne.KeyUp && e.onMove != nil:
+		e.onMove(-1)
+	case key.Name == fyne.KeyDown && e.onMove != nil:
+		e.onMove(1)
+	case key.Name == fyne.KeyEscape && e.onEscape != nil:
+		e.onEscape()
+	default:
+		e.Entry.TypedKey(key)
+	}
+}
+
+// TypedShortcut 有选中的文字时 Ctrl+C 复制文字，否则执行窗口的快捷键
+func (e *keyEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	if _, ok := shortcut.(*fyne.ShortcutCopy); ok && e.SelectedText() != "" {
+		e.Entry.TypedShortcut(shortcut)
+		return
+	}
+	if action, ok := e.app.shortcuts[shortcut.ShortcutName()]; ok {
+		action()
+		return
+	}
+	e.Entry.TypedShortcut(shortcut)
+}
+
+// focusSearch 将焦点移到搜索框并选中已输入的内容
+func (a *App) focusSearch() {
+	if a.searchEntry == nil {
+		return
+	}
+	a.window.Canvas().Focus(a.searchEntry)
+	a.searchEntry.TypedShortcut(&fyne.ShortcutSelectAll{})
+}
+
+// currentEntry 返回快捷键操作的当前条目：最近点击或用方向键选择的条目，
+// 没有选择过条目或条目已不在列表中时返回 nil，复制等操作不执行，避免误复制其他条目的密码
+func (a *App) currentEntry() *models.PasswordEntry {
+	if a.currentID == 0 {
+		return nil
+	}
+	for _, entry := range a.entries {
+		if entry.ID == a.currentID {
+			return entry
+		}
+	}
+	return nil
+}
+
+// setCurrentEntry 设置当前条目并在表格中突出显示
+func (a *App) setCurrentEntry(entry *models.PasswordEntry) {
+	a.currentID = entry.ID
+	if a.entryTable != nil {
+		a.entryTable.Refresh()
+	}
+}
+
+// moveCurrentEntry 将当前条目向下（delta 为正）或向上移动，没有当前条目时选择第一个条目
+func (a *App) moveCurrentEntry(delta int) {
+	if len(a.entries) == 0 {
+		return
+	}
+	row := 0
+	if current := a.currentEntry(); current != nil {
+		for i, entry := range a.entries {
+			if entry == current {
+				row = i
+			}
+		}
+		row += delta
+	}
+	if row < 0 {
+		row = 0
+	}
+	if row >= len(a.entries) {
+		row = len(a.entries) - 1
+	}
+	a.setCurrentEntry(a.entries[row])
+	a.entryTable.ScrollTo(widget.TableCellID{Row: row, Col: colSelect})
+}
+
+// openCurrentEntry 显示当前条目的详情
+func (a *App) openCurrentEntry() {
+	if entry := a.currentEntry(); entry != nil {
+		a.showEntryDetails(entry)
+	}
+}
+
+// copyCurrentUsername 复制当前条目的用户名
+func (a *App) copyCurrentUsername() {
+	entry := a.currentEntry()
+	if entry == nil {
+		return
+	}
+	a.window.Clipboard().SetContent(entry.Username)
+	a.auditEntry(models.AuditCopy, entry, "用户名")
+	a.recordUse(entry)
+	a.showStatus(fmt.Sprintf("已复制「%s」的用户名", entry.Title))
+}
+
+// copyCurrentPassword 复制当前条目的密码
+func (a *App) copyCurrentPassword() {
+	entry := a.currentEntry()
+	if entry == nil {
+		return
+	}
+	a.window.Clipboard().SetContent(entry.Password)
+	a.auditEntry(models.AuditCopy, entry, "密码")
+	a.recordUse(entry)
+	a.showStatus(fmt.Sprintf("已复制「%s」的密码", entry.Title))
+}
+
+// showStatus 在状态栏中短暂显示提示，不打断键盘操作
+func (a *App) showStatus(text string) {
+	if a.statusLabel == nil {
+		return
+	}
+	a.statusLabel.SetText(text)
+	time.AfterFunc(statusDuration, func() {
+		fyne.Do(func() {
+			if a.statusLabel.Text == text {
+				a.statusLabel.SetText("")
+			}
+		})
+	})
+}
@@ -0,0 +1,230 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/vaultsync"
+)
+
+// syncOnUnlock 解锁后如已配置同步位置则自动同步一次
+func (a *App) syncOnUnlock() {
+	remotes, err := vaultsync.ConfiguredRemotes(a.db)
+	if err != nil || len(remotes) == 0 {
+		return
+	}
+
+	if _, err := vaultsync.SyncAll(a.db, remotes); err != nil {
+		dialog.ShowError(fmt.Errorf("同步失败: %v", err), a.window)
+		return
+	}
+	a.loadEntries()
+}
+
+// showSyncDialog 显示同步设置对话框
+func (a *App) showSyncDialog() {
+	folder, err := a.store.GetSetting(vaultsync.FolderSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	serverURL, err := a.store.GetSetting(vaultsync.ServerURLSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	serverUser, err := a.store.GetSetting(vaultsync.ServerUserSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	serverInvite, err := a.store.GetSecretSetting(vaultsync.ServerInviteSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	webdavURL, err := a.store.GetSetting(vaultsync.WebDAVURLSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	webdavUser, err := a.store.GetSetting(vaultsync.WebDAVUserSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	webdavPassword, err := a.store.GetSecretSetting(vaultsync.WebDAVPasswordSetting)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	folderLabel := widget.NewLabel("未设置")
+	if folder != "" {
+		folderLabel.SetText(folder)
+	}
+	folderLabel.Wrapping = fyne.TextWrapBreak
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	chooseBtn := widget.NewButton("选择文件夹", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if uri == nil {
+				return
+			}
+			if err := a.store.SetSetting(vaultsync.FolderSetting, uri.Path()); err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			folder = uri.Path()
+			folderLabel.SetText(folder)
+		}, a.window)
+	})
+
+	// 同步服务器设置，地址和用户名都为空时不使用服务器
+	serverURLEntry := widget.NewEntry()
+	serverURLEntry.SetPlaceHolder("http://127.0.0.1:8787")
+	serverURLEntry.SetText(serverURL)
+	serverUserEntry := widget.NewEntry()
+	serverUserEntry.SetPlaceHolder("用户名")
+	serverUserEntry.SetText(serverUser)
+	serverInviteEntry := widget.NewPasswordEntry()
+	serverInviteEntry.SetPlaceHolder("首次注册时填写服务器的邀请码")
+	serverInviteEntry.SetText(serverInvite)
+
+	// WebDAV 设置，地址为空时不使用 WebDAV
+	webdavURLEntry := widget.NewEntry()
+	webdavURLEntry.SetPlaceHolder("https://nas.local/dav/password_tool")
+	webdavURLEntry.SetText(webdavURL)
+	webdavUserEntry := widget.NewEntry()
+	webdavUserEntry.SetPlaceHolder("用户名")
+	webdavUserEntry.SetText(webdavUser)
+	webdavPasswordEntry := widget.NewPasswordEntry()
+	webdavPasswordEntry.SetPlaceHolder("密码")
+	webdavPasswordEntry.SetText(webdavPassword)
+
+	// saveSettings 保存服务器和 WebDAV 设置
+	saveSettings := func() error {
+		if (serverURLEntry.Text == "") != (serverUserEntry.Text == "") {
+			return fmt.Errorf("服务器地址和用户名需要同时填写")
+		}
+		settings := map[string]string{
+			vaultsync.ServerURLSetting:  serverURLEntry.Text,
+			vaultsync.ServerUserSetting: serverUserEntry.Text,
+			vaultsync.WebDAVURLSetting:  webdavURLEntry.Text,
+			vaultsync.WebDAVUserSetting: webdavUserEntry.Text,
+		}
+		for key, value := range settings {
+			if err := a.store.SetSetting(key, value); err != nil {
+				return err
+			}
+		}
+		if err := a.store.SetSecretSetting(vaultsync.ServerInviteSetting, serverInviteEntry.Text); err != nil {
+			return err
+		}
+		return a.store.SetSecretSetting(vaultsync.WebDAVPasswordSetting, webdavPasswordEntry.Text)
+	}
+
+	syncBtn := widget.NewButton("立即同步", func() {
+		if err := saveSettings(); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		remotes, err := vaultsync.ConfiguredRemotes(a.db)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		if len(remotes) == 0 {
+			dialog.ShowError(fmt.Errorf("请先选择同步文件夹或填写同步服务器、WebDAV"), a.window)
+			return
+		}
+
+		result, err := vaultsync.SyncAll(a.db, remotes)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		a.loadEntries()
+		status := fmt.Sprintf("同步完成：上传 %d 条变更，合并 %d 条变更，冲突 %d 个",
+			result.Pushed, result.Applied, result.Conflicts)
+		if result.Undecryptable > 0 {
+			status += fmt.Sprintf("；%d 条记录无法解密，来自使用其他密码库密钥的设备，需要在设备间重新复制 passwords.db", result.Undecryptable)
+		}
+		statusLabel.SetText(status)
+	})
+
+	backupBtn := widget.NewButton("备份密码库到WebDAV", func() {
+		if err := saveSettings(); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+
+		webdav, err := vaultsync.ConfiguredWebDAV(a.db)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		if webdav == nil {
+			dialog.ShowError(fmt.Errorf("请先填写WebDAV地址"), a.window)
+			return
+		}
+
+		if err := vaultsync.UploadBackup(a.db, webdav); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		statusLabel.SetText("已备份加密的密码库到WebDAV")
+	})
+
+	helpLabel := widget.NewLabel("选择一个在多台电脑间共享的文件夹（Syncthing、NAS、U盘等），" +
+		"或填写 password_tool serve 同步服务器、NAS 的 WebDAV 地址，远端只保存加密后的数据。" +
+		"各设备需使用同一个密码库：首次在新电脑上使用前，请先复制一次 passwords.db。" +
+		"冲突时保留最后修改的版本，另一版本保存为冲突副本。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeBtn, widget.NewLabel("")),
+		nil, nil, nil,
+		container.NewVBox(
+			helpLabel,
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, widget.NewLabel("同步文件夹:"), chooseBtn, folderLabel),
+			container.NewBorder(nil, nil, widget.NewLabel("同步服务器:"), nil, serverURLEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("用户名:"), nil, serverUserEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("邀请码:"), nil, serverInviteEntry),
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, widget.NewLabel("WebDAV:"), nil, webdavURLEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("用户名:"), nil, webdavUserEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("密码:"), nil, webdavPasswordEntry),
+			container.NewCenter(container.NewHBox(syncBtn, backupBtn)),
+			statusLabel,
+		),
+	)
+
+	d := dialog.NewCustomWithoutButtons("同步", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	d.Resize(fyne.NewSize(550, 600))
+	d.Show()
+}
@@ -0,0 +1,527 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/importer"
+	"hank.com/password_tool/models"
+)
+
+// 条目表格的列
+const (
+	colSelect = iota
+	colFavorite
+	colTitle
+	colUsername
+	colURL
+	colCategory
+	colTags
+	colExpires
+	colUpdated
+	colActions
+)
+
+// noSortColumn 表示没有按列排序，使用排序下拉框选择的排序方式
+const noSortColumn = -1
+
+// entryColumn 条目表格的列定义
+type entryColumn struct {
+	title string
+	width float32                               // 初始宽度，拖动标题行的分隔线可以调整
+	less  func(a, b *models.PasswordEntry) bool // 升序排列的比较函数，nil 表示不能按该列排序
+}
+
+// entryColumns 条目表格的全部列
+var entryColumns = []entryColumn{
+	colSelect: {title: "", width: 40},
+	colFavorite: {title: "★", width: 50, less: func(a, b *models.PasswordEntry) bool {
+		return a.Favorite && !b.Favorite
+	}},
+	colTitle:    {title: "标题", width: 180, less: byText(func(e *models.PasswordEntry) string { return e.Title })},
+	colUsername: {title: "用户名", width: 130, less: byText(func(e *models.PasswordEntry) string { return e.Username })},
+	colURL:      {title: "网址", width: 200, less: byText(func(e *models.PasswordEntry) string { return e.URL })},
+	colCategory: {title: "分类", width: 120, less: byText(func(e *models.PasswordEntry) string { return e.Category })},
+	colTags: {title: "标签", width: 120, less: byText(func(e *models.PasswordEntry) string {
+		return strings.Join(e.Tags, ",")
+	})},
+	colExpires: {title: "过期", width: 100, less: func(a, b *models.PasswordEntry) bool {
+		// 不过期的条目排在最后
+		if a.ExpiresAt.IsZero() || b.ExpiresAt.IsZero() {
+			return !a.ExpiresAt.IsZero() && b.ExpiresAt.IsZero()
+		}
+		return a.ExpiresAt.Before(b.ExpiresAt)
+	}},
+	colUpdated: {title: "修改时间", width: 140, less: func(a, b *models.PasswordEntry) bool {
+		return a.UpdatedAt.Before(b.UpdatedAt)
+	}},
+	colActions: {title: "操作", width: 240},
+}
+
+// byText 返回按文本排序（不区分大小写）的比较函数
+func byText(text func(*models.PasswordEntry) string) func(a, b *models.PasswordEntry) bool {
+	return func(a, b *models.PasswordEntry) bool {
+		return strings.ToLower(text(a)) < strings.ToLower(text(b))
+	}
+}
+
+// sortByColumn 按列排列条目
+func sortByColumn(entries []*models.PasswordEntry, col int, descending bool) {
+	less := entryColumns[col].less
+	sort.SliceStable(entries, func(i, j int) bool {
+		if descending {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+}
+
+// createEntryTable 创建条目表格，点击标题行按列排序，拖动标题行的分隔线调整列宽，勾选第一列多选条目
+func (a *App) createEntryTable() *widget.Table {
+	a.entryTable = widget.NewTable(
+		func() (int, int) {
+			return len(a.entries), len(entryColumns)
+		},
+		a.newEntryCell,
+		a.updateEntryCell,
+	)
+	a.entryTable.ShowHeaderRow = true
+	a.entryTable.CreateHeader = func() fyne.CanvasObject {
+		sortBtn := widget.NewButton("", nil)
+		sortBtn.Importance = widget.LowImportance
+		titleLabel := widget.NewLabel("")
+		titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+		return container.NewStack(widget.NewCheck("", nil), sortBtn, titleLabel)
+	}
+	a.entryTable.UpdateHeader = a.updateEntryHeader
+	for col, column := range entryColumns {
+		a.entryTable.SetColumnWidth(col, column.width)
+	}
+
+	// 点击文字单元格将条目设为当前条目并显示详情，立即取消选中，确保再次点击同一单元格时能再次触发
+	a.entryTable.OnSelected = func(id widget.TableCellID) {
+		a.entryTable.UnselectAll()
+		if id.Row >= 0 && id.Row < len(a.entries) {
+			a.setCurrentEntry(a.entries[id.Row])
+			a.showEntryDetails(a.entries[id.Row])
+		}
+	}
+	return a.entryTable
+}
+
+// updateEntryHeader 更新标题行，第一列为全选框，可排序的列显示当前的排序方向
+func (a *App) updateEntryHeader(id widget.TableCellID, obj fyne.CanvasObject) {
+	if id.Col < 0 || id.Col >= len(entryColumns) {
+		return
+	}
+	header := obj.(*fyne.Container)
+	selectAll := header.Objects[0].(*widget.Check)
+	sortBtn := header.Objects[1].(*widget.Button)
+	titleLabel := header.Objects[2].(*widget.Label)
+	for _, o := range header.Objects {
+		o.Hide()
+	}
+
+	col := id.Col
+	column := entryColumns[col]
+	switch {
+	case col == colSelect:
+		selectAll.OnChanged = nil // 设置状态时不触发全选
+		selectAll.SetChecked(len(a.entries) > 0 && len(a.selectedIDs) == len(a.entries))
+		selectAll.OnChanged = a.selectAllEntries
+		selectAll.Show()
+	case column.less == nil:
+		titleLabel.SetText(column.title)
+		titleLabel.Show()
+	default:
+		text := column.title
+		if a.sortColumn == col {
+			if a.sortDescending {
+				text += " ▼"
+			} else {
+				text += " ▲"
+			}
+		}
+		sortBtn.SetText(text)
+		sortBtn.OnTapped = func() {
+			a.sortEntriesByColumn(col)
+		}
+		sortBtn.Show()
+	}
+}
+
+// sortEntriesByColumn 按列排序，再次点击同一列时切换升序和降序
+func (a *App) sortEntriesByColumn(col int) {
+	if a.sortColumn == col {
+		a.sortDescending = !a.sortDescending
+	} else {
+		a.sortColumn, a.sortDescending = col, false
+	}
+	a.loadEntries()
+}
+
+// newEntryCell 创建单元格模板，包含所有列需要的组件，更新时只显示对应列的组件
+func (a *App) newEntryCell() fyne.CanvasObject {
+	textLabel := widget.NewLabel("")
+	textLabel.Truncation = fyne.TextTruncateEllipsis
+
+	// 标题前显示过期标记
+	titleLabel := widget.NewLabel("")
+	titleLabel.Truncation = fyne.TextTruncateEllipsis
+	titleBox := container.NewBorder(nil, nil, widget.NewIcon(nil), nil, titleLabel)
+
+	favoriteBtn := widget.NewButton("☆", nil)
+	favoriteBtn.Importance = widget.LowImportance
+
+	actions := container.NewHBox(
+		widget.NewButton("复制", nil),
+		widget.NewButton("编辑", nil),
+		widget.NewButton("删除", nil),
+		newDragHandle(a), // 拖到左侧文件夹树上可以移动条目
+	)
+
+	return container.NewStack(
+		textLabel,
+		widget.NewCheck("", nil),
+		titleBox,
+		favoriteBtn,
+		container.NewStack(), // 网址
+		actions,
+	)
+}
+
+// updateEntryCell 用条目的数据更新单元格
+func (a *App) updateEntryCell(id widget.TableCellID, obj fyne.CanvasObject) {
+	if id.Row >= len(a.entries) {
+		return
+	}
+	entry := a.entries[id.Row]
+	cell := obj.(*fyne.Container)
+	for _, o := range cell.Objects {
+		o.Hide()
+	}
+
+	// 快捷键操作的当前条目突出显示
+	importance := widget.MediumImportance
+	if entry == a.currentEntry() {
+		importance = widget.HighImportance
+	}
+
+	textLabel := cell.Objects[0].(*widget.Label)
+	showText := func(text string) {
+		textLabel.Importance = importance
+		textLabel.SetText(text)
+		textLabel.Show()
+	}
+
+	switch id.Col {
+	case colSelect:
+		check := cell.Objects[1].(*widget.Check)
+		check.OnChanged = nil // 设置状态时不触发选择
+		check.SetChecked(a.selectedIDs[entry.ID])
+		check.OnChanged = func(checked bool) {
+			a.setEntrySelected(entry, checked)
+		}
+		check.Show()
+	case colFavorite:
+		favoriteBtn := cell.Objects[3].(*widget.Button)
+		favoriteBtn.SetText(favoriteLabel(entry))
+		favoriteBtn.OnTapped = func() {
+			a.setFavorite(entry, !entry.Favorite)
+		}
+		favoriteBtn.Show()
+	case colTitle:
+		titleBox := cell.Objects[2].(*fyne.Container)
+		titleLabel := titleBox.Objects[0].(*widget.Label)
+		titleLabel.Importance = importance
+		titleLabel.SetText(entry.Title)
+		icon := titleBox.Objects[1].(*widget.Icon)
+		if resource := expiryIcon(entry, time.Now()); resource != nil {
+			icon.SetResource(resource)
+			icon.Show()
+		} else {
+			icon.Hide()
+		}
+		titleBox.Show()
+	case colUsername:
+		showText(entry.Username)
+	case colURL:
+		urlBox := cell.Objects[4].(*fyne.Container)
+		urlWidget := a.createURLWidget(entry.URL)
+		switch w := urlWidget.(type) {
+		case *widget.Hyperlink:
+			w.Truncation = fyne.TextTruncateEllipsis
+		case *widget.Label:
+			w.Truncation = fyne.TextTruncateEllipsis
+		}
+		urlBox.Objects = []fyne.CanvasObject{urlWidget}
+		urlBox.Refresh()
+		urlBox.Show()
+	case colCategory:
+		showText(entry.Category)
+	case colTags:
+		showText(strings.Join(entry.Tags, ", "))
+	case colExpires:
+		if entry.ExpiresAt.IsZero() {
+			showText("")
+		} else {
+			showText(entry.ExpiresAt.Local().Format(expiryDateLayout))
+		}
+	case colUpdated:
+		showText(entry.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	case colActions:
+		actions := cell.Objects[5].(*fyne.Container)
+		actions.Objects[0].(*widget.Button).OnTapped = func() {
+			a.copyCredentials(entry)
+		}
+		actions.Objects[1].(*widget.Button).OnTapped = func() {
+			a.showEntryDialog(entry)
+		}
+		actions.Objects[2].(*widget.Button).OnTapped = func() {
+			a.confirmDeleteEntry(entry)
+		}
+		actions.Objects[3].(*dragHandle).entry = entry
+		actions.Show()
+	}
+}
+
+// copyCredentials 将条目的账号和密码复制到剪切板
+func (a *App) copyCredentials(entry *models.PasswordEntry) {
+	// 格式化复制内容：账号和密码换行显示
+	// entry.Password 已经是解密后的明文密码，无需再次解密
+	copyContent := fmt.Sprintf("账号: %s\n密码: %s", entry.Username, entry.Password)
+
+	// 复制到剪切板
+	a.window.Clipboard().SetContent(copyContent)
+	a.auditEntry(models.AuditCopy, entry, "")
+	a.recordUse(entry)
+
+	// 显示复制成功提示
+	dialog.ShowInformation("复制成功", "账号和密码已复制到剪切板", a.window)
+}
+
+// confirmDeleteEntry 确认后将条目移入回收站
+func (a *App) confirmDeleteEntry(entry *models.PasswordEntry) {
+	a.showCustomConfirmDialog("确认删除", "确定要将这个密码条目移入回收站吗？", func(confirmed bool) {
+		if confirmed {
+			if err := a.store.DeletePasswordEntry(entry.ID); err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			a.loadEntries()
+		}
+	})
+}
+
+// setEntrySelected 勾选或取消勾选条目
+func (a *App) setEntrySelected(entry *models.PasswordEntry, selected bool) {
+	if selected {
+		a.selectedIDs[entry.ID] = true
+	} else {
+		delete(a.selectedIDs, entry.ID)
+	}
+	a.refreshSelection()
+}
+
+// selectAllEntries 勾选或取消勾选列表中的全部条目
+func (a *App) selectAllEntries(selected bool) {
+	a.selectedIDs = make(map[int]bool)
+	if selected {
+		for _, entry := range a.entries {
+			a.selectedIDs[entry.ID] = true
+		}
+	}
+	a.refreshSelection()
+}
+
+// selectedEntries 按列表中的顺序返回勾选的条目
+func (a *App) selectedEntries() []*models.PasswordEntry {
+	var selected []*models.PasswordEntry
+	for _, entry := range a.entries {
+		if a.selectedIDs[entry.ID] {
+			selected = append(selected, entry)
+		}
+	}
+	return selected
+}
+
+// pruneSelection 去掉已不在列表中的勾选条目，批量操作只作用于列表中能看到的条目
+func (a *App) pruneSelection() {
+	visible := make(map[int]bool, len(a.entries))
+	for _, entry := range a.entries {
+		visible[entry.ID] = true
+	}
+	for id := range a.selectedIDs {
+		if !visible[id] {
+			delete(a.selectedIDs, id)
+		}
+	}
+}
+
+// refreshSelection 更新勾选数量和批量操作按钮的状态
+func (a *App) refreshSelection() {
+	if a.selectionLabel != nil {
+		a.selectionLabel.SetText(fmt.Sprintf("已选择 %d 项", len(a.selectedIDs)))
+	}
+	for _, btn := range a.bulkButtons {
+		if len(a.selectedIDs) == 0 {
+			btn.Disable()
+		} else {
+			btn.Enable()
+		}
+	}
+	if a.entryTable != nil {
+		a.entryTable.Refresh()
+	}
+}
+
+// createBulkActionBar 创建批量操作栏，对勾选的条目移动分类、添加标签、删除或导出
+func (a *App) createBulkActionBar() fyne.CanvasObject {
+	a.selectionLabel = widget.NewLabel("")
+	a.bulkButtons = []*widget.Button{
+		widget.NewButton("移动到分类", a.showBulkMoveDialog),
+		widget.NewButton("添加标签", a.showBulkTagDialog),
+		widget.NewButton("删除", a.confirmBulkDelete),
+		widget.NewButton("导出所选", a.exportSelection),
+		widget.NewButton("取消选择", func() {
+			a.selectAllEntries(false)
+		}),
+	}
+
+	bar := container.NewHBox(a.selectionLabel)
+	for _, btn := range a.bulkButtons {
+		bar.Add(btn)
+	}
+	a.refreshSelection()
+
+	// 右侧为复制等操作的提示
+	a.statusLabel = widget.NewLabel("")
+	a.statusLabel.Alignment = fyne.TextAlignTrailing
+	a.statusLabel.Truncation = fyne.TextTruncateEllipsis
+	return container.NewBorder(nil, nil, bar, nil, a.statusLabel)
+}
+
+// updateEntries 逐个更新条目，出错时停止并显示已更新的数量
+func (a *App) updateEntries(entries []*models.PasswordEntry, change func(entry *models.PasswordEntry)) {
+	for i, entry := range entries {
+		updated := *entry
+		change(&updated)
+		if err := a.store.UpdatePasswordEntry(&updated); err != nil {
+			dialog.ShowError(fmt.Errorf("已更新 %d 个条目，「%s」更新失败: %v", i, entry.Title, err), a.window)
+			break
+		}
+	}
+	a.loadEntries()
+}
+
+// showBulkMoveDialog 将勾选的条目移动到选择的分类
+func (a *App) showBulkMoveDialog() {
+	entries := a.selectedEntries()
+	categorySelect := widget.NewSelect(append([]string{uncategorizedOption}, a.categoryNames()...), nil)
+	categorySelect.SetSelectedIndex(0)
+
+	message := widget.NewLabel(fmt.Sprintf("将选中的 %d 个条目移动到：", len(entries)))
+	d := dialog.NewCustomConfirm("移动到分类", "移动", "取消", container.NewVBox(message, categorySelect), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		category := selectedCategoryName(categorySelect)
+		a.updateEntries(entries, func(entry *models.PasswordEntry) {
+			entry.Category = category
+			entry.CategoryID = 0
+		})
+	}, a.window)
+	d.Resize(fyne.NewSize(400, 200))
+	d.Show()
+}
+
+// showBulkTagDialog 为勾选的条目添加标签
+func (a *App) showBulkTagDialog() {
+	entries := a.selectedEntries()
+	tagEditor := newTagEditor(a, nil)
+
+	message := widget.NewLabel(fmt.Sprintf("为选中的 %d 个条目添加标签：", len(entries)))
+	d := dialog.NewCustomConfirm("添加标签", "添加", "取消", container.NewVBox(message, tagEditor.Content()), func(confirmed bool) {
+		tags := tagEditor.Tags()
+		if !confirmed || len(tags) == 0 {
+			return
+		}
+		a.updateEntries(entries, func(entry *models.PasswordEntry) {
+			entry.Tags = append(append([]string(nil), entry.Tags...), tags...)
+		})
+	}, a.window)
+	d.Resize(fyne.NewSize(450, 250))
+	d.Show()
+}
+
+// confirmBulkDelete 确认后将勾选的条目移入回收站
+func (a *App) confirmBulkDelete() {
+	entries := a.selectedEntries()
+	message := fmt.Sprintf("确定要将选中的 %d 个密码条目移入回收站吗？", len(entries))
+	a.showCustomConfirmDialog("确认删除", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		for _, entry := range entries {
+			if err := a.store.DeletePasswordEntry(entry.ID); err != nil {
+				dialog.ShowError(err, a.window)
+				break
+			}
+		}
+		a.loadEntries()
+	})
+}
+
+// exportSelection 将勾选的条目导出为 KeePass XML 文件，导出前提示文件包含明文密码
+func (a *App) exportSelection() {
+	entries := a.selectedEntries()
+	message := fmt.Sprintf("导出的 %d 个条目将以明文保存密码，请妥善保管导出的文件。是否继续？", len(entries))
+	a.showCustomConfirmDialog("导出所选", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, a.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			// 导出文件包含明文密码，写入前只允许当前用户读写
+			if err := restrictToOwner(writer); err != nil {
+				dialog.ShowError(fmt.Errorf("导出失败: %v", err), a.window)
+				return
+			}
+			if err := importer.WriteKeePassXML(writer, entries); err != nil {
+				dialog.ShowError(fmt.Errorf("导出失败: %v", err), a.window)
+				return
+			}
+			for _, entry := range entries {
+				a.auditEntry(models.AuditExport, entry, writer.URI().Name())
+			}
+			dialog.ShowInformation("导出成功", fmt.Sprintf("已导出 %d 个条目，可以用 KeePass 或 import 命令导入", len(entries)), a.window)
+		}, a.window)
+		saveDialog.SetFileName("password_tool_export.xml")
+		saveDialog.Show()
+	})
+}
+
+// restrictToOwner 将保存对话框创建的本地文件权限改为 0600，保存敏感内容时在写入前调用
+// 保存对话框按默认权限（通常为 0644）创建文件
+func restrictToOwner(writer fyne.URIWriteCloser) error {
+	if writer.URI().Scheme() != "file" {
+		return nil
+	}
+	return os.Chmod(writer.URI().Path(), 0600)
+}
@@ -0,0 +1,229 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// 标签输入框下方最多显示的补全建议数
+const maxTagSuggestions = 6
+
+// refreshTags 重新加载标签，并去掉已不存在的已选标签
+func (a *App) refreshTags() {
+	tags, err := a.store.GetTags()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	a.tags = tags
+
+	existing := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		existing[strings.ToLower(tag.Name)] = true
+	}
+	for key := range a.selectedTags {
+		if !existing[key] {
+			delete(a.selectedTags, key)
+		}
+	}
+
+	if a.tagList != nil {
+		a.tagList.Refresh()
+	}
+}
+
+// tagNames 返回所有标签的名称
+func (a *App) tagNames() []string {
+	names := make([]string, len(a.tags))
+	for i, tag := range a.tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// hasSelectedTags 检查条目是否带有标签筛选中选择的全部标签
+func (a *App) hasSelectedTags(entry *models.PasswordEntry) bool {
+	for key := range a.selectedTags {
+		if !entry.HasTag(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// createTagFilter 创建标签筛选列表，勾选多个标签时只显示同时带有这些标签的条目，与分类筛选同时生效
+func (a *App) createTagFilter() fyne.CanvasObject {
+	if a.selectedTags == nil {
+		a.selectedTags = make(map[string]bool)
+	}
+
+	a.tagList = widget.NewList(
+		func() int {
+			return len(a.tags)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewCheck("标签", nil)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(a.tags) {
+				return
+			}
+			tag := a.tags[id]
+			key := strings.ToLower(tag.Name)
+			check := obj.(*widget.Check)
+			check.OnChanged = nil // 设置状态时不触发筛选
+			check.SetText(fmt.Sprintf("%s (%d)", tag.Name, tag.EntryCount))
+			check.SetChecked(a.selectedTags[key])
+			check.OnChanged = func(checked bool) {
+				if checked {
+					a.selectedTags[key] = true
+				} else {
+					delete(a.selectedTags, key)
+				}
+				a.loadEntries()
+			}
+		},
+	)
+
+	clearBtn := widget.NewButton("清除", func() {
+		a.selectedTags = make(map[string]bool)
+		a.loadEntries()
+	})
+	clearBtn.Importance = widget.LowImportance
+
+	titleLabel := widget.NewLabel("标签")
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	return container.NewBorder(
+		container.NewBorder(nil, nil, nil, clearBtn, titleLabel),
+		nil, nil, nil,
+		a.tagList,
+	)
+}
+
+// tagEditor 条目编辑对话框中的标签编辑器，输入时在下方显示匹配的已有标签供选择
+type tagEditor struct {
+	app         *App
+	tags        []string
+	chips       *fyne.Container
+	input       *widget.Entry
+	suggestions *fyne.Container
+}
+
+// newTagEditor 创建标签编辑器
+func newTagEditor(a *App, tags []string) *tagEditor {
+	e := &tagEditor{
+		app:         a,
+		tags:        append([]string(nil), tags...),
+		chips:       container.NewHBox(),
+		input:       widget.NewEntry(),
+		suggestions: container.NewHBox(),
+	}
+	e.input.SetPlaceHolder("输入标签后回车，多个标签用逗号分隔")
+	e.input.OnChanged = func(string) {
+		e.refreshSuggestions()
+	}
+	e.input.OnSubmitted = func(text string) {
+		e.add(strings.Split(text, ",")...)
+	}
+	e.refreshChips()
+	return e
+}
+
+// Content 返回编辑器的界面
+func (e *tagEditor) Content() fyne.CanvasObject {
+	addBtn := widget.NewButton("添加", func() {
+		e.add(strings.Split(e.input.Text, ",")...)
+	})
+	return container.NewVBox(
+		container.NewHScroll(e.chips),
+		container.NewBorder(nil, nil, nil, addBtn, e.input),
+		e.suggestions,
+	)
+}
+
+// Tags 返回编辑后的标签
+func (e *tagEditor) Tags() []string {
+	// 输入框中还没有添加的标签也一并保存
+	return database.NormalizeTags(append(e.tags, strings.Split(e.input.Text, ",")...))
+}
+
+// add 添加标签并清空输入框
+func (e *tagEditor) add(tags ...string) {
+	e.tags = database.NormalizeTags(append(e.tags, tags...))
+	e.input.SetText("")
+	e.refreshChips()
+}
+
+// remove 移除标签
+func (e *tagEditor) remove(tag string) {
+	var remaining []string
+	for _, t := range e.tags {
+		if t != tag {
+			remaining = append(remaining, t)
+		}
+	}
+	e.tags = remaining
+	e.refreshChips()
+}
+
+// refreshChips 更新已添加的标签，点击标签可以移除
+func (e *tagEditor) refreshChips() {
+	e.chips.RemoveAll()
+	if len(e.tags) == 0 {
+		e.chips.Add(widget.NewLabel("无标签"))
+	}
+	for _, tag := range e.tags {
+		tag := tag
+		chip := widget.NewButtonWithIcon(tag, theme.CancelIcon(), func() {
+			e.remove(tag)
+		})
+		chip.Importance = widget.LowImportance
+		e.chips.Add(chip)
+	}
+	e.chips.Refresh()
+	e.refreshSuggestions()
+}
+
+// refreshSuggestions 根据输入的内容显示以其开头（不区分大小写）且尚未添加的已有标签
+func (e *tagEditor) refreshSuggestions() {
+	e.suggestions.RemoveAll()
+
+	// 逗号分隔时只补全最后一个标签
+	parts := strings.Split(e.input.Text, ",")
+	prefix := strings.ToLower(strings.TrimSpace(parts[len(parts)-1]))
+	if prefix != "" {
+		for _, name := range e.app.tagNames() {
+			if len(e.suggestions.Objects) >= maxTagSuggestions {
+				break
+			}
+			if !strings.HasPrefix(strings.ToLower(name), prefix) || e.has(name) {
+				continue
+			}
+			name := name
+			e.suggestions.Add(widget.NewButton(name, func() {
+				e.add(append(parts[:len(parts)-1], name)...)
+			}))
+		}
+	}
+	e.suggestions.Refresh()
+}
+
+// has 检查标签是否已添加
+func (e *tagEditor) has(tag string) bool {
+	for _, t := range e.tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
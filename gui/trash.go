@@ -0,0 +1,183 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"hank.com/password_tool/models"
+)
+
+// trashRetentionOptions 可选的回收站保留天数，0 表示不自动清除
+var trashRetentionOptions = []int{7, 30, 90, 0}
+
+// trashRetentionLabel 返回保留天数选项的显示文本
+func trashRetentionLabel(days int) string {
+	if days == 0 {
+		return "永久保留"
+	}
+	return fmt.Sprintf("保留 %d 天", days)
+}
+
+// showTrashDialog 显示回收站，可以恢复或永久删除条目
+func (a *App) showTrashDialog() {
+	entries, err := a.store.GetDeletedPasswordEntries()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	retention, err := a.store.TrashRetentionDays()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	statusLabel := widget.NewLabel("")
+
+	var trashList *widget.List
+
+	// reload 重新加载回收站和主列表
+	reload := func() {
+		updated, err := a.store.GetDeletedPasswordEntries()
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		entries = updated
+		trashList.Refresh()
+		statusLabel.SetText(fmt.Sprintf("回收站中有 %d 个条目", len(entries)))
+		a.loadEntries()
+	}
+
+	trashList = widget.NewList(
+		func() int {
+			return len(entries)
+		},
+		func() fyne.CanvasObject {
+			titleLabel := widget.NewLabel("标题")
+			titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+			titleLabel.Truncation = fyne.TextTruncateEllipsis
+			deletedLabel := widget.NewLabel("删除时间")
+
+			restoreBtn := widget.NewButton("恢复", func() {
+				// 恢复功能将在更新时设置
+			})
+			purgeBtn := widget.NewButton("永久删除", func() {
+				// 删除功能将在更新时设置
+			})
+			purgeBtn.Importance = widget.DangerImportance
+
+			return container.NewBorder(
+				nil, nil, nil,
+				container.NewHBox(deletedLabel, restoreBtn, purgeBtn), // 右侧：删除时间和操作
+				titleLabel,
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(entries) {
+				return
+			}
+			entry := entries[id]
+			borderContainer := obj.(*fyne.Container)
+
+			titleText := entry.Title
+			if entry.Username != "" {
+				titleText += " - " + entry.Username
+			}
+			borderContainer.Objects[0].(*widget.Label).SetText(titleText)
+
+			actionContainer := borderContainer.Objects[1].(*fyne.Container)
+			actionContainer.Objects[0].(*widget.Label).SetText(entry.DeletedAt.Local().Format("2006-01-02 15:04"))
+			actionContainer.Objects[1].(*widget.Button).OnTapped = func() {
+				if err := a.store.RestorePasswordEntry(entry.ID); err != nil {
+					dialog.ShowError(err, a.window)
+					return
+				}
+				reload()
+			}
+			actionContainer.Objects[2].(*widget.Button).OnTapped = func() {
+				a.purgeEntries([]*models.PasswordEntry{entry}, reload)
+			}
+		},
+	)
+
+	retentionLabels := make([]string, len(trashRetentionOptions))
+	for i, days := range trashRetentionOptions {
+		retentionLabels[i] = trashRetentionLabel(days)
+	}
+	retentionSelect := widget.NewSelect(retentionLabels, nil)
+	retentionSelect.SetSelected(trashRetentionLabel(retention))
+	retentionSelect.OnChanged = func(string) {
+		days := trashRetentionOptions[retentionSelect.SelectedIndex()]
+		if err := a.store.SetTrashRetentionDays(days); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		if _, err := a.store.PurgeExpiredTrash(); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		reload()
+	}
+
+	emptyBtn := widget.NewButton("清空回收站", func() {
+		if len(entries) > 0 {
+			a.purgeEntries(entries, reload)
+		}
+	})
+	emptyBtn.Importance = widget.DangerImportance
+
+	closeBtn := widget.NewButton("关闭", func() {
+		// 关闭功能将在对话框创建后设置
+	})
+
+	helpLabel := widget.NewLabel("删除的条目会先移入回收站，超过保留天数后在解锁时自动永久删除。")
+	helpLabel.Wrapping = fyne.TextWrapWord
+	statusLabel.SetText(fmt.Sprintf("回收站中有 %d 个条目", len(entries)))
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, closeBtn, container.NewHBox(emptyBtn, retentionSelect)),
+			helpLabel,
+			statusLabel,
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		trashList,
+	)
+
+	d := dialog.NewCustomWithoutButtons("回收站", content, a.window)
+	a.openDialogs = append(a.openDialogs, d)
+
+	closeBtn.OnTapped = func() {
+		a.removeDialog(d)
+		d.Hide()
+	}
+
+	d.Resize(fyne.NewSize(650, 480))
+	d.Show()
+}
+
+// purgeEntries 确认后永久删除回收站中的条目
+func (a *App) purgeEntries(entries []*models.PasswordEntry, done func()) {
+	message := fmt.Sprintf("确定要永久删除「%s」吗？此操作无法撤销。", entries[0].Title)
+	if len(entries) > 1 {
+		message = fmt.Sprintf("确定要永久删除回收站中的 %d 个条目吗？此操作无法撤销。", len(entries))
+	}
+
+	a.showCustomConfirmDialog("永久删除", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		for _, entry := range entries {
+			if err := a.store.PurgePasswordEntry(entry.ID); err != nil {
+				dialog.ShowError(err, a.window)
+				break
+			}
+		}
+		done()
+	})
+}
@@ -0,0 +1,263 @@
+package importer
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// keePassFile KeePass 2 导出的 XML 文件结构，只解析导入需要的部分
+type keePassFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    struct {
+		RecycleBinUUID string `xml:"RecycleBinUUID"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keePassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// keePassGroup KeePass 的组，对应密码库中的文件夹
+type keePassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+	Groups  []keePassGroup `xml:"Group"`
+}
+
+// keePassEntry KeePass 的条目，History 中的历史版本不解析
+type keePassEntry struct {
+	UUID    string          `xml:"UUID"`
+	Strings []keePassString `xml:"String"`
+	Tags    string          `xml:"Tags"` // 以分号或逗号分隔
+	Times   struct {
+		Expires    string `xml:"Expires"`
+		ExpiryTime string `xml:"ExpiryTime"`
+	} `xml:"Times"`
+}
+
+// keePassString 条目中的键值对
+type keePassString struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Text      string `xml:",chardata"`
+		Protected string `xml:"Protected,attr,omitempty"`
+	} `xml:"Value"`
+}
+
+// ParseKeePassXML 解析 KeePass 2 导出的 XML 文件（文件 → 导出 → KeePass XML (2.x)）
+// 组映射为文件夹路径，根组本身不作为文件夹，条目的标签和过期时间一并导入，回收站中的条目和条目的历史版本不导入
+func ParseKeePassXML(r io.Reader) ([]*models.PasswordEntry, error) {
+	var file keePassFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse KeePass XML: %v", err)
+	}
+	if len(file.Root.Groups) == 0 {
+		return nil, fmt.Errorf("no groups found, not a KeePass 2 XML export")
+	}
+
+	var entries []*models.PasswordEntry
+	var walk func(group keePassGroup, path string) error
+	walk = func(group keePassGroup, path string) error {
+		if file.Meta.RecycleBinUUID != "" && group.UUID == file.Meta.RecycleBinUUID {
+			return nil
+		}
+		for _, e := range group.Entries {
+			entry, err := convertKeePassEntry(e)
+			if err != nil {
+				return err
+			}
+			entry.Category = path
+			entries = append(entries, entry)
+		}
+		for _, child := range group.Groups {
+			// 组名中的 / 会被当作文件夹分隔符，替换为 -
+			name := strings.ReplaceAll(strings.TrimSpace(child.Name), models.CategorySeparator, "-")
+			childPath := path
+			if name != "" {
+				childPath = strings.TrimPrefix(path+models.CategorySeparator+name, models.CategorySeparator)
+			}
+			if err := walk(child, childPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range file.Root.Groups {
+		if err := walk(root, ""); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// convertKeePassEntry 将 KeePass 条目转换为密码条目
+func convertKeePassEntry(e keePassEntry) (*models.PasswordEntry, error) {
+	entry := &models.PasswordEntry{
+		Tags: strings.FieldsFunc(e.Tags, func(r rune) bool {
+			return r == ';' || r == ','
+		}),
+	}
+	if strings.EqualFold(e.Times.Expires, "True") {
+		if expiresAt, err := time.Parse(time.RFC3339, e.Times.ExpiryTime); err == nil {
+			entry.ExpiresAt = expiresAt
+		}
+	}
+	var custom []string
+	for _, s := range e.Strings {
+		if strings.EqualFold(s.Value.Protected, "True") {
+			return nil, fmt.Errorf("field %q is encrypted, export the database as KeePass XML (2.x) instead of using the KDBX inner XML", s.Key)
+		}
+		value := s.Value.Text
+		switch s.Key {
+		case "Title":
+			entry.Title = value
+		case "UserName":
+			entry.Username = value
+		case "Password":
+			entry.Password = value
+		case "URL":
+			entry.URL = value
+		case "Notes":
+			entry.Notes = value
+		default:
+			// 自定义字段追加到备注
+			if value != "" {
+				custom = append(custom, s.Key+": "+value)
+			}
+		}
+	}
+
+	if len(custom) > 0 {
+		if entry.Notes != "" {
+			entry.Notes += "\n\n"
+		}
+		entry.Notes += strings.Join(custom, "\n")
+	}
+	if entry.Title == "" {
+		entry.Title = entry.URL
+	}
+	if entry.Title == "" {
+		entry.Title = "未命名"
+	}
+	return entry, nil
+}
+
+// Import 将解析出的条目添加到密码库，folder 非空时所有条目放在该文件夹下
+// 同一文件夹中标题和用户名都相同的条目视为已导入，跳过以便重复导入同一文件
+func Import(store database.VaultStore, entries []*models.PasswordEntry, folder string) (imported, skipped int, err error) {
+	folder = database.NormalizeCategoryPath(folder)
+
+	existing, err := store.GetPasswordEntries()
+	if err != nil {
+		return 0, 0, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, entry := range existing {
+		seen[importKey(entry)] = true
+	}
+
+	for _, entry := range entries {
+		if folder != "" {
+			entry.Category = strings.TrimSuffix(folder+models.CategorySeparator+entry.Category, models.CategorySeparator)
+		}
+		key := importKey(entry)
+		if seen[key] {
+			skipped++
+			continue
+		}
+		if err := store.AddPasswordEntry(entry); err != nil {
+			return imported, skipped, fmt.Errorf("failed to import %q: %v", entry.Title, err)
+		}
+		seen[key] = true
+		imported++
+	}
+	return imported, skipped, nil
+}
+
+// importKey 判断条目是否重复时使用的键
+func importKey(entry *models.PasswordEntry) string {
+	return database.NormalizeCategoryPath(entry.Category) + "\x00" + entry.Title + "\x00" + entry.Username
+}
+
+// WriteKeePassXML 将条目导出为 KeePass 2 XML 文件，文件夹导出为组，可以用 ParseKeePassXML 或 KeePass 重新导入
+// 导出的文件包含明文密码
+func WriteKeePassXML(w io.Writer, entries []*models.PasswordEntry) error {
+	var file keePassFile
+	root := &keePassGroup{UUID: newKeePassUUID(), Name: "密码库"}
+
+	for _, entry := range entries {
+		group := root
+		if entry.Category != "" {
+			for _, name := range strings.Split(entry.Category, models.CategorySeparator) {
+				group = childGroup(group, name)
+			}
+		}
+		group.Entries = append(group.Entries, exportKeePassEntry(entry))
+	}
+	file.Root.Groups = []keePassGroup{*root}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(&file); err != nil {
+		return fmt.Errorf("failed to write KeePass XML: %v", err)
+	}
+	return nil
+}
+
+// childGroup 返回名为 name 的子组，不存在时创建
+func childGroup(parent *keePassGroup, name string) *keePassGroup {
+	for i := range parent.Groups {
+		if parent.Groups[i].Name == name {
+			return &parent.Groups[i]
+		}
+	}
+	parent.Groups = append(parent.Groups, keePassGroup{UUID: newKeePassUUID(), Name: name})
+	return &parent.Groups[len(parent.Groups)-1]
+}
+
+// exportKeePassEntry 将密码条目转换为 KeePass 条目
+func exportKeePassEntry(entry *models.PasswordEntry) keePassEntry {
+	e := keePassEntry{
+		UUID: newKeePassUUID(),
+		Tags: strings.Join(entry.Tags, ";"),
+	}
+	for _, field := range []struct{ key, value string }{
+		{"Title", entry.Title},
+		{"UserName", entry.Username},
+		{"Password", entry.Password},
+		{"URL", entry.URL},
+		{"Notes", entry.Notes},
+	} {
+		s := keePassString{Key: field.key}
+		s.Value.Text = field.value
+		e.Strings = append(e.Strings, s)
+	}
+
+	e.Times.Expires = "False"
+	if !entry.ExpiresAt.IsZero() {
+		e.Times.Expires = "True"
+		e.Times.ExpiryTime = entry.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return e
+}
+
+// newKeePassUUID 生成 KeePass 使用的 base64 编码的 UUID
+func newKeePassUUID() string {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(uuid)
+}
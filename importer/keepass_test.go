@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"bytes"
+	"testing"
+
+	"hank.com/password_tool/database/memstore"
+	"hank.com/password_tool/models"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source := []*models.PasswordEntry{
+		{Title: "github", Username: "octocat", Password: "p1", URL: "https://github.com", Notes: "多行\n备注", Category: "工作/git"},
+		{Title: "wifi", Password: "p2"},
+	}
+	var buf bytes.Buffer
+	if err := WriteKeePassXML(&buf, source); err != nil {
+		t.Fatal(err)
+	}
+	exported := buf.Bytes()
+
+	parse := func() []*models.PasswordEntry {
+		t.Helper()
+		entries, err := ParseKeePassXML(bytes.NewReader(exported))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+
+	store := memstore.New()
+	if err := store.SetMasterPassword("pw"); err != nil {
+		t.Fatal(err)
+	}
+	imported, skipped, err := Import(store, parse(), "导入")
+	if err != nil || imported != 2 || skipped != 0 {
+		t.Fatalf("Import() = %d, %d, %v", imported, skipped, err)
+	}
+
+	entries, err := store.GetPasswordEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]*models.PasswordEntry{}
+	for _, entry := range entries {
+		got[entry.Title] = entry
+	}
+	if e := got["github"]; e == nil || e.Username != "octocat" || e.Password != "p1" || e.URL != "https://github.com" ||
+		e.Notes != "多行\n备注" || e.Category != "导入/工作/git" {
+		t.Errorf("github = %+v", e)
+	}
+	if e := got["wifi"]; e == nil || e.Password != "p2" || e.Category != "导入" {
+		t.Errorf("wifi = %+v", e)
+	}
+
+	// 重复导入同一文件时跳过已导入的条目
+	imported, skipped, err = Import(store, parse(), "导入")
+	if err != nil || imported != 0 || skipped != 2 {
+		t.Errorf("second Import() = %d, %d, %v", imported, skipped, err)
+	}
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"os"
+
+	"hank.com/password_tool/cli"
 	"hank.com/password_tool/gui"
 )
 
 func main() {
+	// 带子命令启动时以命令行模式运行
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	app := gui.NewApp()
 	app.Run()
 }
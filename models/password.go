@@ -1,26 +1,73 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
 // PasswordEntry 表示一个密码条目
 type PasswordEntry struct {
-	ID          int       `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Username    string    `json:"username" db:"username"`
-	Password    string    `json:"password" db:"password"`
-	URL         string    `json:"url" db:"url"`
-	Notes       string    `json:"notes" db:"notes"`
-	Category    string    `json:"category" db:"category"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
-}
-
-// Category 表示密码分类
+	ID           int       `json:"id" db:"id"`
+	UID          string    `json:"uid" db:"uid"`
+	Title        string    `json:"title" db:"title"`
+	Username     string    `json:"username" db:"username"`
+	Password     string    `json:"password" db:"password"`
+	URL          string    `json:"url" db:"url"`
+	Notes        string    `json:"notes" db:"notes"`
+	CategoryID   int       `json:"-" db:"category_id"`                         // 所属分类的 ID，0 表示未分类，不同设备上的 ID 不同
+	Category     string    `json:"category" db:"category"`                     // 分类名称
+	Tags         []string  `json:"tags,omitempty" db:"-"`                      // 标签，一个条目可以有多个标签
+	Favorite     bool      `json:"favorite" db:"favorite"`                     // 收藏的条目在列表中置顶
+	LastUsedAt   time.Time `json:"-" db:"last_used_at"`                        // 最近一次查看或复制的时间，只在本机记录
+	UseCount     int       `json:"-" db:"use_count"`                           // 查看或复制的次数，只在本机记录
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`                 // 密码的过期时间，零值表示不过期
+	RotationDays int       `json:"rotation_days,omitempty" db:"rotation_days"` // 轮换周期（天），修改密码后自动顺延过期时间，0 表示不轮换
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	DeletedAt    time.Time `json:"-" db:"deleted_at"` // 移入回收站的时间，仅回收站中的条目有值
+}
+
+// HasTag 检查条目是否有指定的标签，不区分大小写
+func (e *PasswordEntry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpiresBefore 检查条目的密码是否在 t 之前（含）过期
+func (e *PasswordEntry) ExpiresBefore(t time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !e.ExpiresAt.After(t)
+}
+
+// CategorySeparator 分类（文件夹）路径中各级之间的分隔符
+const CategorySeparator = "/"
+
+// Category 表示密码分类，分类可以嵌套，作为树形的文件夹使用
 type Category struct {
-	ID   int    `json:"id" db:"id"`
-	Name string `json:"name" db:"name"`
+	ID         int    `json:"id" db:"id"`
+	Name       string `json:"name" db:"name"`           // 完整路径，例如 work/aws/prod
+	ParentID   int    `json:"parent_id" db:"parent_id"` // 上级文件夹的 ID，0 表示顶层
+	EntryCount int    `json:"entry_count" db:"-"`       // 回收站以外直接属于该分类的条目数
+}
+
+// BaseName 返回路径的最后一级名称
+func (c *Category) BaseName() string {
+	return c.Name[strings.LastIndex(c.Name, CategorySeparator)+1:]
+}
+
+// Depth 返回文件夹的层级，顶层为 0
+func (c *Category) Depth() int {
+	return strings.Count(c.Name, CategorySeparator)
+}
+
+// Tag 表示条目的标签
+type Tag struct {
+	ID         int    `json:"id" db:"id"`
+	Name       string `json:"name" db:"name"`
+	EntryCount int    `json:"entry_count" db:"-"` // 回收站以外带有该标签的条目数
 }
 
 // MasterPassword 表示主密码配置
@@ -28,4 +75,84 @@ type MasterPassword struct {
 	ID           int    `json:"id" db:"id"`
 	PasswordHash string `json:"password_hash" db:"password_hash"`
 	Salt         string `json:"salt" db:"salt"`
-}
\ No newline at end of file
+}
+
+// SSHKey 表示一个SSH密钥条目，私钥在数据库中加密存储
+type SSHKey struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	PublicKey string    `json:"public_key" db:"public_key"`
+	Comment   string    `json:"comment" db:"comment"`
+	Confirm   bool      `json:"confirm" db:"confirm"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SyncRecord 表示一条条目变更记录，加密后在设备之间交换
+type SyncRecord struct {
+	UID       string         `json:"uid"`
+	Revisions []string       `json:"revisions"` // 修订历史，最后一个为当前修订
+	Device    string         `json:"device"`
+	Deleted   bool           `json:"deleted"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Folder    string         `json:"folder,omitempty"`     // 条目所在文件夹的完整路径，空字符串表示未分类
+	MovedFrom string         `json:"moved_from,omitempty"` // 条目因文件夹移动、合并或删除而离开的文件夹路径
+	Entry     *PasswordEntry `json:"entry,omitempty"`
+}
+
+// Revision 返回记录的当前修订
+func (r *SyncRecord) Revision() string {
+	if len(r.Revisions) == 0 {
+		return ""
+	}
+	return r.Revisions[len(r.Revisions)-1]
+}
+
+// HasRevision 检查修订是否在记录的历史中
+func (r *SyncRecord) HasRevision(revision string) bool {
+	for _, rev := range r.Revisions {
+		if rev == revision {
+			return true
+		}
+	}
+	return false
+}
+
+// Backup 密码库的备份快照
+type Backup struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 审计日志记录的操作
+const (
+	AuditUnlock  = "unlock"
+	AuditView    = "view"
+	AuditCopy    = "copy"
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+	AuditSign    = "sign"
+	AuditExport  = "export"
+)
+
+// 审计日志记录的客户端
+const (
+	AuditClientGUI   = "GUI"
+	AuditClientCLI   = "CLI"
+	AuditClientAgent = "agent"
+)
+
+// AuditRecord 审计日志中的一条记录，加密后保存
+type AuditRecord struct {
+	ID      int       `json:"-"`
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Client  string    `json:"client"`
+	EntryID int       `json:"entry_id,omitempty"`
+	Subject string    `json:"subject,omitempty"` // 条目标题或密钥名称
+	Detail  string    `json:"detail,omitempty"`
+}
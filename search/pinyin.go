@@ -0,0 +1,126 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// pinyinInitials 汉字到拼音首字母的映射，多音字对应多个首字母
+var pinyinInitials = buildPinyinInitials()
+
+// pinyinHeteronyms 常见多音字的全部读音首字母，生成的表中多音字只有一个读音
+var pinyinHeteronyms = map[rune]string{
+	'行': "xh",
+	'重': "zc",
+	'长': "cz",
+	'乐': "ly",
+	'便': "bp",
+	'调': "dt",
+	'传': "cz",
+	'藏': "cz",
+	'朝': "cz",
+	'单': "dsc",
+	'会': "hk",
+	'厦': "sx",
+	'曾': "cz",
+	'解': "jx",
+	'省': "sx",
+	'参': "cs",
+	'率': "ls",
+	'系': "xj",
+	'查': "cz",
+	'区': "qo",
+	'仇': "cq",
+	'盛': "sc",
+	'翟': "zd",
+	'校': "xj",
+}
+
+// buildPinyinInitials 由生成的分组表构建汉字到首字母的映射
+func buildPinyinInitials() map[rune]string {
+	initials := make(map[rune]string, 7000)
+	for letter, chars := range pinyinInitialChars {
+		for _, r := range chars {
+			initials[r] = string(letter)
+		}
+	}
+	for r, letters := range pinyinHeteronyms {
+		initials[r] = letters
+	}
+	return initials
+}
+
+// hasHan 检查文本是否包含汉字
+func hasHan(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// initialSets 将文本转换为每个字符可能的首字母，汉字为拼音首字母，其他字符为小写形式
+// 空白被忽略，因此 "zfb" 可以匹配 "支付 宝"
+func initialSets(text string) []string {
+	var sets []string
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if letters, ok := pinyinInitials[r]; ok {
+			sets = append(sets, letters)
+		} else {
+			sets = append(sets, string(r))
+		}
+	}
+	return sets
+}
+
+// matchInitialsAt 检查从位置 start 开始的首字母是否依次与 query 的字符匹配
+func matchInitialsAt(sets []string, start int, query []rune) bool {
+	if start+len(query) > len(sets) {
+		return false
+	}
+	for i, r := range query {
+		if !strings.ContainsRune(sets[start+i], r) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPinyin 用拼音首字母匹配含汉字的文本，返回是否匹配以及是否从开头匹配
+// 例如 "zfb" 匹配 "支付宝"，"yh" 匹配 "招商银行"
+func matchPinyin(text, query string) (matched, prefix bool) {
+	if !hasHan(text) {
+		return false, false
+	}
+	sets := initialSets(text)
+	q := []rune(query)
+	for start := 0; start < len(sets); start++ {
+		if matchInitialsAt(sets, start, q) {
+			return true, start == 0
+		}
+	}
+	return false, false
+}
+
+// matchPinyinFuzzy 拼音首字母按顺序出现即视为匹配，例如 "zb" 匹配 "支付宝"
+func matchPinyinFuzzy(text, query string) bool {
+	if !hasHan(text) {
+		return false
+	}
+	sets := initialSets(text)
+	i := 0
+	for _, r := range query {
+		for i < len(sets) && !strings.ContainsRune(sets[i], r) {
+			i++
+		}
+		if i == len(sets) {
+			return false
+		}
+		i++
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package search
+
+// 由 Perl Unicode::Collate::CJK::Pinyin 的拼音排序数据生成，包含 GB2312 中的全部汉字，多音字只取其常用读音
+
+// pinyinInitialChars 按拼音首字母分组的汉字
+var pinyinInitialChars = map[rune]string{
+	'a': "阿呵锕嗄啊哎哀唉埃挨嗳锿捱皑癌矮蔼霭艾爱砹隘嗌嫒碍暧瑷安桉氨庵谙鹌鞍俺埯铵揞犴岸按案胺暗黯肮昂盎凹敖嗷廒遨熬獒翱聱螯鳌鏖" +
+		"拗袄媪岙坳傲奥骜懊澳鏊",
+	'b': "八扒岜芭疤捌粑拔茇菝跋魃把钯靶坝爸耙鲅霸灞巴叭吧笆罢掰擘白百佰柏捭摆败拜稗扳班般颁斑搬瘢癍阪坂板版钣舨办半伴拌绊瓣扮邦帮" +
+		"梆浜绑榜膀蚌傍棒谤蒡磅镑勹包孢苞胞煲龅褒雹薄宝饱保鸨堡葆褓报抱豹趵鲍暴爆陂卑杯悲碑鹎北贝孛狈邶备背钡倍悖被惫焙辈碚蓓褙鞴" +
+		"鐾呗奔贲锛本苯畚坌笨崩嘣甭绷泵迸甏蹦逼荸鼻匕比吡妣彼秕俾笔舭鄙币必毕闭庇畀哔毖荜陛毙狴铋婢庳敝萆弼愎筚滗痹蓖裨跸弊碧箅蔽" +
+		"壁嬖篦薜避濞臂髀璧襞边砭笾编煸蝙鳊鞭贬扁窆匾碥褊卞弁忭汴苄变便缏遍辨辩辫灬杓标飑髟彪骠膘瘭镖飙飚镳表婊裱鳔憋鳖别蹩瘪玢宾" +
+		"彬傧斌滨缤槟镔濒豳摈殡膑髌鬓冫冰兵丙邴秉柄炳饼摒禀并病拨波玻剥钵饽菠播伯驳帛勃亳钹铂脖舶博渤鹁搏箔踣礴跛簸檗卜啵膊逋晡醭" +
+		"卟补哺捕不布步怖钚埔部钸埠瓿簿",
+	'c': "嚓擦礤猜才材财裁采彩睬踩菜蔡参骖餐残蚕惭惨黪灿掺孱粲璨仓伧沧苍舱藏操糙曹嘈漕槽艚螬草艹册侧厕恻测策岑涔噌层曾蹭叉杈插馇锸" +
+		"查茬茶搽猹槎察碴檫衩镲汊岔诧姹差拆钗侪柴豺虿瘥觇搀婵谗禅馋缠蝉廛潺澶镡蟾躔产谄铲阐蒇骣冁忏颤羼伥昌娼猖菖阊鲳肠苌尝偿常徜" +
+		"嫦厂场昶惝氅怅畅倡鬯唱敞抄怊钞焯超晁巢朝嘲潮吵炒耖车砗扯屮彻坼掣撤澈抻郴琛嗔尘臣忱沈沉辰陈宸谌碜衬龀趁榇谶晨柽称蛏撑瞠丞" +
+		"成呈承枨诚城乘埕晟铖惩程裎塍酲澄橙逞骋秤吃哧蚩鸱眵笞嗤媸痴螭魑弛池驰迟坻茌持墀踟篪尺侈齿耻褫彳叱斥赤饬炽翅敕啻傺瘛充冲忡" +
+		"茺舂憧艟虫崇宠铳抽瘳仇俦帱惆绸畴愁稠筹踌雠丑瞅臭酬出初樗刍除厨滁锄蜍雏橱躇蹰杵础储楮褚亍处怵绌畜搐触憷黜矗楚揣搋啜嘬膪踹" +
+		"巛川氚穿传舡船遄椽舛喘串钏疮窗床幢闯创怆吹炊垂陲捶棰椎槌锤春椿蝽纯唇莼淳醇蠢鹑踔戳辶绰辍龊呲疵词祠茈茨瓷慈辞磁雌鹚糍此次" +
+		"伺刺赐匆囱苁枞葱骢璁聪从丛淙琮凑腠辏粗徂殂促猝酢蔟醋簇蹙蹴汆撺镩蹿窜篡爨崔催摧榱璀脆啐悴淬萃毳瘁粹翠村皴存忖寸搓磋撮蹉嵯" +
+		"痤矬鹾脞厝挫措锉错",
+	'd': "哒耷嗒搭褡达妲怛沓笪答靼鞑打大瘩呆呔歹逮傣代岱甙绐迨骀带待怠殆玳贷埭袋戴黛丹单担眈耽郸聃殚瘅箪儋胆疸掸赕旦但诞啖弹惮淡萏" +
+		"蛋氮澹当裆挡党谠凼宕砀荡档菪铛刀刂叨忉氘导岛捣祷蹈到倒悼焘盗道稻纛锝德地的得灯登噔簦蹬等戥邓凳嶝瞪磴镫氐低羝堤滴镝狄籴迪" +
+		"敌涤荻笛觌嘀嫡翟诋邸底抵柢砥骶弟帝娣递第谛棣睇缔蒂碲嗲甸掂滇颠巅癫典点碘踮电佃阽坫店垫玷钿惦淀奠殿靛癜簟刁叼凋貂碉雕鲷吊" +
+		"钓调掉铞铫爹跌迭垤瓞谍喋堞揲耋叠牒碟蝶蹀鲽丁仃叮玎疔盯钉耵酊顶鼎订定啶铤腚碇锭丢铥东冬咚岽氡鸫董懂动冻侗垌峒恫栋洞胨胴硐" +
+		"都兜蔸篼抖陡蚪斗豆逗痘窦嘟督毒独读渎椟牍犊碡黩髑笃堵赌睹芏妒杜肚度渡镀蠹端短段断缎椴煅锻簖堆队对兑怼碓憝镦吨敦墩礅蹲盹趸" +
+		"囤沌炖盾砘钝顿遁多咄哆掇裰夺铎踱哚垛缍躲剁柁堕舵惰跺朵",
+	'e': "婀屙钶讹俄娥峨莪锇鹅蛾额厄呃扼苊轭垩恶饿掠略谔鄂阏愕萼遏腭锷鹗颚噩鳄诶恩蒽摁儿而鸸鲕尔耳迩洱饵珥铒二佴贰",
+	'f': "发乏伐垡罚阀砝筏法珐帆番幡蕃翻藩凡矾钒烦樊燔繁蹯蘩反返犯泛饭范贩畈梵匚方邡芳枋钫防妨房肪鲂仿访彷纺舫放坊飞妃非啡绯菲扉蜚" +
+		"霏鲱肥淝腓匪诽悱斐榧翡篚吠芾废沸狒肺费痱镄分吩纷芬氛酚坟汾棼焚鼢粉份奋忿偾愤粪鲼瀵丰风沣枫封疯砜峰烽葑锋蜂酆冯逢讽唪凤奉" +
+		"俸缝缶否呋肤趺麸稃跗孵敷弗伏凫佛孚扶芙怫拂服绂绋苻俘氟祓罘茯郛浮砩莩蚨匐桴涪符艴菔幅福蜉辐幞蝠黻呒抚府拊斧俯釜辅腑滏腐黼" +
+		"阝父讣付妇负附阜驸复赴副富赋缚腹鲋赙蝮鳆覆馥夫甫咐袱傅",
+	'g': "旮呷嘎钆尜噶尕尬该陔垓赅改丐钙盖溉戤概甘杆肝坩泔矸苷柑竿疳酐乾尴秆赶敢感澉橄擀干旰绀淦赣冈刚杠纲肛缸钢罡岗港筻戆皋羔高槔" +
+		"睾膏篙糕杲搞缟槁稿镐藁告诰郜锆戈仡圪纥疙咯哥胳袼鸽割搁歌阁革格鬲葛隔嗝塥搿膈镉骼哿舸个各虼硌铬给根跟哏艮亘茛庚耕赓羹哽埂" +
+		"绠耿梗鲠更工弓公功攻供肱宫恭躬龚觥廾巩汞拱珙共贡蚣勾佝沟钩缑篝鞲岣狗苟枸笱构诟购垢够媾彀遘觏估呱姑孤沽轱鸪菰蛄觚辜酤箍古" +
+		"汩诂谷股牯骨罟钴蛊鹄毂鼓嘏鹘臌瞽固故顾崮梏牿雇痼锢鲴咕菇瓜刮胍栝鸹聒剐寡卦诖挂褂乖掴拐怪关观官冠倌棺鳏莞馆管贯惯掼涫盥灌" +
+		"鹳罐光咣桄胱广犷逛归圭妫龟规皈闺傀硅瑰鲑宄轨庋匦诡癸鬼晷簋刽刿柜炔贵桂桧跪鳜丨衮绲辊滚磙鲧棍呙埚郭崞锅蝈国帼虢馘果猓椁蜾" +
+		"裹过",
+	'h': "哈铪蛤咳嗨还孩骸海胲醢亥骇害氦顸蚶酣憨鼾邗含邯函晗涵焓寒韩罕喊阚汉汗旱悍捍焊菡颔撖憾撼翰瀚夯杭绗珩航颃沆蒿嚆薅蚝毫嗥貉豪" +
+		"嚎壕濠好郝号昊浩耗皓颢灏诃喝嗬禾合何劾和河曷阂核盍荷涸盒菏蚵颌阖翮贺褐赫鹤壑黑嘿痕很狠恨亨哼恒桁横衡蘅轰哄訇烘薨弘红宏闳" +
+		"泓洪荭虹鸿蕻黉讧侯喉猴瘊篌糇骺吼后厚後逅堠鲎候虍呼忽烀轷唿惚滹囫弧狐胡壶斛湖猢葫煳瑚鹕槲蝴醐觳虎浒琥互户冱护沪岵怙戽祜笏" +
+		"扈瓠鹱乎唬糊花哗华骅铧滑猾化划画话桦怀徊淮槐踝坏獾环郇洹桓萑锾圜寰缳鬟缓幻奂宦唤换浣涣患焕逭痪豢漶鲩擐欢肓荒慌皇凰隍黄徨" +
+		"惶湟遑煌潢璜篁蝗癀磺簧蟥鳇恍谎幌晃灰诙咴恢挥虺晖珲辉麾徽隳回洄茴蛔悔毁卉汇会讳哕浍绘荟诲恚烩贿彗晦秽喙惠缋慧蕙蟪昏荤婚阍" +
+		"浑馄魂诨混溷耠锪劐豁攉活火伙钬夥或货砉获祸惑霍镬嚯藿蠖",
+	'j': "丌讥击叽饥乩圾机玑肌芨矶鸡咭迹剞唧姬屐积笄基绩嵇犄缉赍畸跻箕畿稽齑墼激羁及吉岌汲级即极亟佶诘急笈疾脊戢棘殛集嫉楫蒺瘠蕺藉" +
+		"籍几己虮挤掎戟嵴麂彐计记伎纪妓忌技芰际剂季哜既洎济荠继觊偈寂寄悸祭蓟暨跽霁鲚稷鲫冀髻骥辑加夹伽佳茄迦枷浃珈家痂笳袈葭跏嘉" +
+		"镓郏荚恝戛袷铗蛱颊甲岬胛贾钾假瘕价驾架嫁稼戋奸尖坚歼间肩艰兼监笺菅湔犍缄搛煎缣蒹鲣鹣鞯囝拣枧俭柬茧捡笕减剪检趼睑硷裥锏简" +
+		"谫戬碱翦謇蹇见件建饯剑牮荐贱健涧舰渐谏楗毽溅腱践鉴键僭箭踺江姜将茳浆豇僵缰礓疆讲奖桨蒋耩降洚绛酱犟糨匠艽交郊姣娇浇茭骄胶" +
+		"椒焦蛟跤僬鲛蕉礁鹪角佼侥挢狡绞饺皎矫脚铰搅湫剿敫徼缴叫峤轿较教窖酵噍醮阶疖皆接秸喈嗟揭街卩孑节讦劫杰拮洁结桀婕捷颉睫截碣" +
+		"竭鲒羯解介戒芥届界疥诫借蚧骱姐巾今斤钅金津矜衿筋襟仅尽卺紧堇谨锦廑馑槿瑾劲妗近进荩晋浸烬赆禁缙靳觐噤京泾经茎荆惊旌菁晶腈" +
+		"粳兢精鲸井阱刭肼颈景儆憬警净弪径迳胫痉竞婧竟敬靓靖境獍静镜睛冂扃炅迥炯窘纠究鸠赳阄啾揪鬏九久灸玖韭酒旧臼咎疚柩桕厩救就舅" +
+		"僦鹫居拘狙苴驹疽掬菹椐琚趄锔裾雎鞠鞫局桔菊橘咀沮举莒榉榘龃踽巨句讵拒苣具炬钜俱倨剧惧据距犋飓锯窭聚屦踞遽醵矩娟捐涓鹃镌蠲" +
+		"卷锩倦桊狷绢隽眷鄄噘撅孓决诀抉珏绝觉倔崛掘桷觖厥劂谲獗蕨噱橛爵镢蹶嚼矍爝攫军君均钧皲菌麇俊郡峻捃浚骏竣",
+	'k': "咔咖喀卡佧胩开揩锎凯剀垲恺铠慨蒈楷锴忾刊勘龛堪戡坎侃砍莰槛看瞰闶康慷糠扛亢伉抗炕钪尻考拷栲烤铐犒靠苛柯珂科轲疴棵颏嗑稞窠" +
+		"颗瞌磕蝌髁壳可坷岢渴克刻客恪课氪骒缂溘锞肯垦恳啃龈裉吭坑铿空倥崆箜孔恐控抠芤眍口叩扣寇筘蔻刳枯哭堀窟骷苦库绔喾裤酷夸侉垮" +
+		"挎胯跨蒯块快侩郐哙狯脍筷宽髋款匡诓哐框筐狂诳夼邝圹纩况旷矿贶眶亏岿悝盔窥奎逵隗馗喹揆葵暌魁睽蝰夔跬匮喟愦愧溃蒉馈篑聩坤昆" +
+		"琨锟髡醌鲲悃捆阃困扩括蛞阔廓",
+	'l': "垃拉邋旯剌砬喇腊瘌蜡辣啦来崃徕涞莱铼赉睐赖濑癞籁兰岚拦栏婪阑蓝谰澜褴斓篮镧览揽缆榄漤罱懒烂滥啷郎狼阆廊琅榔稂锒螂朗浪莨蒗" +
+		"捞劳牢唠崂痨铹醪老佬姥栳铑潦涝烙耢酪肋仂乐叻泐鳓了勒雷嫘缧擂檑镭羸耒诔垒磊蕾儡泪类累酹嘞塄棱楞冷愣厘离骊梨犁喱鹂漓缡蓠蜊" +
+		"嫠璃鲡黎篱罹藜黧蠡礼里俚娌逦理锂鲤澧醴鳢力历厉立吏丽利励呖坜沥苈例戾枥疠隶俐俪栎疬荔轹郦栗猁砺砾莅莉唳笠粒粝蛎傈痢詈跞雳" +
+		"溧篥李哩狸俩奁连帘怜涟莲联裢廉鲢濂臁镰蠊敛琏脸裣蔹练炼恋殓链楝潋良凉梁椋粮粱墚踉两魉亮谅辆晾量撩辽疗聊僚寥嘹寮獠缭燎鹩钌" +
+		"蓼尥料廖撂镣列劣冽洌埒烈捩猎裂趔躐鬣咧拎邻林临啉淋琳粼嶙遴辚霖瞵磷鳞麟凛廪懔檩吝赁蔺膦躏灵囹泠苓柃玲瓴凌铃陵棂绫羚翎聆菱" +
+		"蛉零龄鲮酃岭领令另呤伶溜熘刘浏流留琉硫旒遛馏骝榴瘤镏鎏柳绺锍六鹨龙咙泷茏栊珑胧砻笼聋隆癃陇垄垅拢窿娄偻蒌楼耧蝼髅嵝搂篓陋" +
+		"漏瘘镂喽噜撸卢庐芦垆泸炉栌胪轳鸬舻颅鲈卤虏掳鲁橹镥陆录赂辂渌逯鹿禄碌路漉戮辘潞璐簏鹭麓露氇驴闾榈吕侣捋旅稆铝屡缕膂褛履律" +
+		"虑率绿氯滤娈孪峦挛栾鸾脔滦銮卵乱锊抡仑伦囵沦纶轮论罗猡脶萝逻椤锣箩骡镙螺倮裸瘰蠃泺洛络荦骆珞落摞漯雒",
+	'm': "妈嬷麻马玛码蚂犸杩骂唛吗嘛蟆埋霾买荬劢迈麦卖脉颟蛮谩馒瞒鞔鳗满螨曼墁幔慢漫缦蔓熳镘邙忙芒氓盲茫硭莽漭蟒猫毛矛牦茅茆旄锚髦" +
+		"蝥蟊卯峁泖昴铆茂冒贸耄袤帽瑁瞀貌懋么没枚玫眉莓梅媒嵋湄猸楣煤酶镅鹛霉每美浼镁妹昧袂媚寐魅门扪钔闷焖懑们虻萌盟蒙甍瞢朦檬礞" +
+		"艨勐猛锰艋蜢懵蠓孟梦咪眯弥祢迷猕谜醚糜縻麋靡蘼米芈弭敉脒冖糸汨宓泌觅秘密幂谧嘧蜜宀眠绵棉免沔黾勉眄娩冕渑湎缅腼面喵苗描瞄" +
+		"鹋杪眇秒淼渺缈藐邈妙庙乜咩灭蔑篾蠛民岷苠珉缗皿闵抿泯闽悯敏愍鳘名明鸣茗冥铭溟暝瞑螟酩命谬摸谟嫫馍摹模膜麽摩磨蘑魔抹末殁沫" +
+		"茉陌秣莫寞漠蓦貊瘼镆墨默貘耱哞牟侔眸谋蛑缪鍪某毪母亩牡坶姆木仫目沐牧苜钼募墓幕睦慕暮穆拇",
+	'n': "嗯拿镎哪那纳肭娜衲钠捺乃奶艿氖奈柰耐萘鼐囡男南难喃楠赧腩蝻囔囊馕曩攮孬呶挠硇铙猱蛲垴恼脑瑙闹淖疒讷呐呢馁内恁嫩能妮尼坭怩" +
+		"泥倪铌猊霓鲵你拟旎伲昵逆匿溺睨腻拈蔫年鲇鲶黏捻辇辗撵碾廿念埝酿娘鸟茑袅嬲尿脲捏陧涅聂臬啮嗫镊镍颞蹑孽蘖您宁咛拧狞柠聍甯凝" +
+		"佞泞妞牛忸扭狃纽钮农侬哝浓脓弄耨奴孥驽努弩胬怒女钕恧衄暖疟虐挪傩诺喏搦锘懦糯",
+	'o': "喔噢哦讴沤欧殴瓯鸥呕偶耦藕怄",
+	'p': "趴啪葩杷爬琶筢帕怕拍俳徘排牌哌派湃蒎潘攀爿盘磐蹒蟠判拚泮叛盼畔袢襻乓滂庞逄旁螃耪胖抛脬刨咆庖狍袍匏跑泡炮疱呸胚醅陪培赔锫" +
+		"裴沛佩帔旆配辔霈喷盆湓怦抨砰烹嘭澎朋堋彭棚硼蓬鹏膨蟛捧碰篷丕批纰邳坯披砒铍劈噼霹皮芘枇毗疲蚍郫陴啤埤琵脾罴蜱貔鼙匹庀疋仳" +
+		"圮痞擗癖屁淠媲睥辟僻甓譬偏犏篇翩骈胼蹁谝片骗剽缥飘螵嫖瓢殍瞟票嘌漂氕撇瞥丿苤姘拼贫嫔频颦品榀牝聘乒俜娉平评凭坪苹屏枰瓶萍" +
+		"鲆钋坡泊颇婆鄱皤叵钷笸迫珀破粕魄泼剖掊裒仆攴扑噗匍莆脯菩葡蒲璞濮镤朴圃浦普溥谱氆镨蹼铺瀑曝",
+	'q': "七沏妻柒凄栖桤萋期欺嘁漆槭蹊亓祁齐圻岐芪其奇歧祈俟耆脐颀崎淇畦萁骐骑棋琦琪祺蛴旗綦蜞蕲鳍麒乞企屺岂芑启杞起绮綮气讫汔迄弃" +
+		"汽泣契砌葺碛器憩戚掐葜恰洽髂千仟阡扦芊迁佥岍钎牵悭铅谦愆签骞搴褰前钤虔钱钳掮箝潜黔凵浅肷遣谴缱欠芡茜倩堑嵌椠慊歉呛羌戕戗" +
+		"枪跄腔蜣锖锵镪丬强墙嫱蔷樯抢羟襁炝悄硗跷劁敲锹橇缲乔侨荞桥谯憔鞒樵瞧巧愀俏诮峭窍翘撬鞘且切妾怯郄窃挈惬箧锲亲侵钦衾芩芹秦" +
+		"琴禽勤嗪溱噙擒檎螓锓寝吣沁揿青氢轻倾卿圊清蜻鲭情晴氰擎檠黥苘顷请庆箐磬罄謦芎邛穷穹茕筇琼蛩跫銎丘邱秋蚯楸鳅囚犰求虬泅俅酋" +
+		"逑球赇巯遒裘蝤鼽糗区曲岖诎驱屈祛蛆躯蛐趋麴黢劬朐鸲渠蕖磲璩瞿蘧氍癯衢蠼取娶龋去阒觑趣悛圈全权诠泉荃拳辁痊铨筌蜷醛鬈颧犬畎" +
+		"绻劝券犭缺阙瘸却悫雀确阕榷鹊逡裙群",
+	'r': "蚺然髯燃冉苒染禳瓤穰嚷壤攘让娆荛饶桡扰绕惹热人亻仁壬忍荏稔刃认仞任纫妊轫韧饪衽葚扔仍日茸戎肜狨绒荣容嵘溶蓉榕熔蝾融冗柔揉" +
+		"糅蹂鞣肉如茹铷儒嚅孺濡薷襦蠕颥汝乳辱入洳溽缛蓐褥阮朊软蕤蕊芮枘蚋锐瑞睿闰润若偌弱箬",
+	's': "仨挲撒洒卅飒脎萨塞腮噻鳃赛三叁毵伞糁馓霰散桑嗓搡磉颡丧搔骚缫臊鳋扫嫂埽瘙色涩啬铯瑟穑森僧杀沙纱刹砂莎铩痧煞裟鲨傻唼啥厦歃" +
+		"霎筛酾晒山彡删杉芟姗苫衫钐埏珊舢跚煽潸膻闪陕讪汕疝剡扇善骟鄯缮嬗擅膳赡蟮鳝伤殇商觞墒熵垧晌赏上尚绱裳捎烧梢稍筲艄蛸勺芍苕" +
+		"韶少劭邵绍哨潲奢猞赊畲舌佘蛇舍厍设社射涉赦慑摄滠歙麝申伸身呻绅诜娠砷莘深什甚神审哂矧谂婶渖肾胂渗慎椹蜃升生声牲笙甥绳省眚" +
+		"圣胜盛剩嵊尸失师虱诗施狮湿蓍鲺十饣石时实炻蚀食埘莳鲥史矢豕使始驶屎士氏礻世仕市示似式事侍势视试饰室恃拭是柿贳适舐轼逝铈豉" +
+		"弑谥释嗜筮誓噬螫识拾匙收手守首艏寿受狩兽售授绶瘦扌书殳抒纾叔枢姝倏殊梳淑菽疏舒摅毹输蔬秫孰赎塾熟属暑黍署蜀鼠薯曙术戍束沭" +
+		"述树竖恕庶数腧墅漱澍刷唰耍衰摔甩帅蟀闩拴栓涮双霜孀爽谁水税睡氵吮顺舜瞬说妁烁朔铄硕搠蒴槊厶纟丝司私咝思鸶斯缌蛳厮锶嘶撕澌" +
+		"死巳四寺汜兕姒祀泗饲驷笥耜嗣肆忪松凇崧淞菘嵩怂悚耸竦讼宋诵送颂嗖搜溲馊飕锼艘螋叟嗾瞍擞薮嗽苏酥稣俗夙肃涑素速宿粟谡嗉塑愫" +
+		"溯僳蔌觫簌诉狻酸蒜算攵虽荽眭睢濉绥隋随髓岁祟谇遂碎隧燧穗邃孙狲荪飧损笋隼榫唆娑桫梭睃嗍羧蓑缩所唢索琐锁嗦",
+	't': "他它她趿铊塌溻塔獭鳎拓挞闼遢榻踏蹋胎台邰抬苔炱跆鲐薹太汰态肽钛泰酞坍贪摊滩瘫坛昙谈郯覃痰锬谭潭檀忐坦袒钽毯叹炭探碳汤铴耥" +
+		"羰镗饧唐堂棠塘搪溏瑭樘膛糖螗螳醣帑倘淌傥躺烫趟涛绦掏滔韬饕洮逃桃陶啕淘萄鼗讨套忑忒特铽慝疼腾誊滕藤剔梯锑踢荑绨啼提缇鹈题" +
+		"蹄醍体剃倜悌涕逖惕替裼嚏屉天添田恬畋甜填阗忝殄腆舔掭佻挑祧条迢笤龆蜩髫鲦窕眺粜跳帖贴萜铁餮厅汀听町烃廷亭庭莛停婷葶蜓霆挺" +
+		"梃艇通嗵仝同佟彤茼桐砼铜童酮僮潼瞳统捅桶筒恸痛偷亠头投骰钭透凸秃突图徒荼途屠菟酴土吐钍兔堍涂湍团抟疃彖推颓腿退煺蜕褪吞暾" +
+		"屯饨豚臀氽乇托拖脱驮佗陀坨沱沲砣鸵跎酡橐鼍妥庹椭柝唾箨驼",
+	'w': "挖洼娲蛙娃瓦佤袜腽哇歪崴外弯剜湾蜿豌丸纨芄完玩顽烷宛挽婉惋晚绾脘菀琬皖畹碗万腕汪亡王网往罔惘辋魍妄忘旺望枉危威偎萎逶隈葳" +
+		"微煨薇巍囗韦圩围帏沩违闱桅涠唯帷惟维嵬潍伟伪尾纬苇委炜玮洧娓诿猥痿艉韪鲔卫为未位味畏胃軎尉谓喂渭蔚慰魏猬温瘟文纹玟闻蚊阌" +
+		"雯刎吻紊稳问汶璺翁嗡蓊瓮蕹挝倭涡莴窝蜗我沃肟卧幄握渥硪斡龌乌圬污邬呜巫屋诬钨无毋吴吾芜唔浯梧蜈鼯五午仵妩庑忤怃武侮捂牾鹉" +
+		"舞兀勿戊阢坞杌芴迕物误悟晤焐婺痦骛雾寤鹜鋈务伍",
+	'x': "夕兮吸汐希昔析穸郗唏奚浠牺悉惜欷淅烯硒菥晰犀稀粞翕舾溪皙锡僖熄熙蜥嘻嬉膝樨熹羲螅蟋醯曦鼷习席袭觋媳隰檄洗玺徙铣喜葸屣蓰禧" +
+		"戏系饩矽细阋舄隙禊西息虾瞎匣侠狎峡柙狭硖遐暇瑕辖霞黠下吓夏罅先纤氙祆籼莶掀跹酰锨鲜暹闲弦贤咸涎娴舷衔痫鹇嫌冼显险猃蚬筅跣" +
+		"藓燹县岘苋现线限宪陷馅羡献腺仙乡芗相香厢湘缃葙箱襄骧镶详庠祥翔享响饷飨想鲞向巷项象像橡蟓枭哓枵骁哮宵消绡逍萧硝销潇箫霄魈" +
+		"嚣崤淆小晓筱孝肖效校笑啸些楔歇蝎协邪胁挟偕斜谐携勰撷缬鞋写泄泻绁卸屑械亵渫谢榍榭廨懈獬薤邂燮瀣蟹躞心忻芯辛昕欣锌新歆薪馨" +
+		"鑫囟信衅忄星惺猩腥刑行邢形陉型荥硎醒擤兴杏姓幸性荇悻凶兄匈汹胸雄熊休修咻庥羞鸺貅馐髹朽秀岫绣袖锈嗅溴吁戌盱胥须顼虚嘘墟需" +
+		"徐许诩栩糈醑旭序叙恤洫勖绪续酗婿溆絮煦蓄蓿轩宣谖喧揎萱暄煊儇玄痃悬旋漩璇选癣泫炫绚眩铉渲楦碹镟削靴薛穴学泶踅雪鳕血谑勋埙" +
+		"熏窨獯薰曛醺寻旬巡驯询峋恂洵浔荀荨循鲟讯汛迅徇逊殉巽蕈训",
+	'y': "丫压吖押垭鸦桠鸭牙伢岈芽琊蚜崖涯睚衙哑痖雅轧亚讶迓娅砑氩揠呀恹烟胭崦淹焉菸阉湮腌鄢嫣讠延严妍芫言岩沿炎研盐阎筵蜒颜檐兖奄" +
+		"俨衍偃厣掩眼郾琰罨演魇鼹厌闫咽彦砚唁宴晏艳验谚堰焰焱雁滟酽谳餍燕赝央泱殃秧鸯鞅扬羊阳杨炀佯疡徉洋烊蛘仰养氧痒怏恙样漾幺夭" +
+		"吆妖腰邀爻尧肴姚轺珧窑谣徭摇遥瑶繇鳐杳咬窈舀崾药要钥鹞曜耀掖椰噎耶揶铘也冶野业叶曳页邺夜晔烨液谒腋靥爷一伊衣医依咿猗铱壹" +
+		"揖欹漪噫黟仪圯夷沂诒怡迤饴咦姨贻眙胰痍移遗颐疑嶷彝乙已以钇矣苡舣蚁倚酏椅旖义亿弋刈忆艺议亦屹异佚呓役抑译邑佾峄怿易绎诣驿" +
+		"奕弈疫羿轶悒挹益谊埸翊翌逸意溢缢肄裔瘗蜴毅熠镒劓殪薏翳翼臆癔镱懿衤宜因阴姻洇茵荫音殷氤铟喑堙吟垠狺寅淫银鄞夤霪廴尹引吲饮" +
+		"蚓隐瘾印茚胤应英莺婴瑛嘤撄缨罂樱璎鹦膺鹰迎茔盈荧莹萤营萦楹滢蓥潆嬴赢瀛郢颍颖影瘿映硬媵蝇哟唷佣拥痈邕庸雍墉慵壅镛臃鳙饔喁" +
+		"永甬咏泳俑勇涌恿蛹踊用优忧攸呦幽悠尢尤由犹邮油疣莜莸铀蚰游鱿猷蝣有卣酉莠铕牖黝又右幼佑侑囿宥柚诱蚴釉鼬友纡迂淤瘀于余妤欤" +
+		"於盂臾鱼俞禺竽舁娱狳谀馀渔萸隅雩嵛愉揄渝腴逾愚榆瑜虞觎窬舆蝓与予伛宇屿羽雨俣禹语圄圉庾瘐窳龉肀玉驭聿芋妪饫育郁昱狱峪浴钰" +
+		"预域欲谕阈喻寓御裕遇鹆愈煜蓣誉毓蜮豫燠鹬鬻鸢冤眢鸳渊箢元员园沅垣爰原圆袁援缘鼋塬源猿辕橼螈远苑怨院垸媛掾瑗愿曰约月刖岳悦" +
+		"钺阅跃粤越樾龠瀹晕氲云匀纭芸昀郧耘筠允狁陨殒孕运郓恽酝愠韫韵熨蕴",
+	'z': "匝咂拶杂砸咋灾甾哉栽宰崽再在载糌簪咱昝攒趱暂赞錾瓒赃臧驵奘脏葬遭糟凿早枣蚤澡藻灶皂唣造噪燥躁则择泽责迮啧帻笮舴箦赜仄昃贼" +
+		"怎谮增憎缯罾锃甑赠扎吒哳喳揸渣楂齄札闸铡眨砟乍诈咤柞栅炸痄蚱榨斋摘宅窄债砦寨瘵沾毡旃粘詹谵瞻斩展盏崭搌占战栈站绽湛蘸张章" +
+		"鄣嫜彰漳獐樟璋蟑仉长涨掌丈仗帐杖胀账障嶂幛瘴钊招昭啁爪找沼召兆诏赵笊棹照罩肇蜇遮折哲辄蛰谪摺磔辙者锗赭褶这柘浙鹧着著蔗贞" +
+		"针侦浈珍胗桢真砧祯斟甄蓁榛箴臻诊枕轸畛疹缜稹圳阵鸩振朕赈镇震争征怔诤峥挣狰钲睁铮筝蒸徵拯整正证郑帧政症之支卮汁芝吱枝知织" +
+		"肢栀祗胝脂蜘执侄直值埴职植殖絷跖摭踯夂止只旨址纸芷祉咫指枳轵趾黹酯至志忮豸制帙帜治炙质郅峙栉陟挚桎秩致贽轾掷痔窒鸷彘智滞" +
+		"痣蛭骘稚置雉膣觯踬中忠终盅钟舯衷锺螽肿种冢踵仲众重州舟诌周洲粥妯轴肘纣咒宙绉昼胄荮皱酎骤籀帚朱侏诛邾洙茱株珠诸猪铢蛛槠潴" +
+		"橥竹竺烛逐舳瘃躅丶主拄渚煮嘱麈瞩伫住助苎杼注贮驻柱炷祝疰蛀筑铸箸翥抓拽专砖颛转啭赚撰篆馔妆庄桩装壮状撞隹追骓锥坠惴缒赘缀" +
+		"肫窀谆准卓拙倬捉桌涿灼茁斫浊浞诼酌啄禚擢濯镯孜兹咨姿赀资淄缁谘孳嵫滋粢辎觜訾趑锱龇髭鲻仔姊秭籽耔笫梓紫滓字自恣渍眦子宗综" +
+		"棕腙踪鬃总偬纵粽邹驺诹陬鄹鲰走奏揍楱租足卒族镞诅阻组俎祖钻躜缵纂攥嘴最罪蕞醉尊遵樽鳟撙昨琢左佐作坐阼怍祚胙唑座做",
+}
@@ -0,0 +1,272 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"hank.com/password_tool/models"
+)
+
+// 查询中可以限定的字段
+const (
+	FieldTitle    = "title"
+	FieldUsername = "user"
+	FieldURL      = "url"
+	FieldNotes    = "notes"
+	FieldTag      = "tag"
+	FieldFolder   = "folder"
+)
+
+// fieldAliases 查询中字段前缀的写法
+var fieldAliases = map[string]string{
+	"title":    FieldTitle,
+	"标题":       FieldTitle,
+	"user":     FieldUsername,
+	"username": FieldUsername,
+	"用户名":      FieldUsername,
+	"url":      FieldURL,
+	"网址":       FieldURL,
+	"notes":    FieldNotes,
+	"note":     FieldNotes,
+	"备注":       FieldNotes,
+	"tag":      FieldTag,
+	"标签":       FieldTag,
+	"folder":   FieldFolder,
+	"category": FieldFolder,
+	"分类":       FieldFolder,
+}
+
+// fieldWeights 各字段匹配时得分的权重，标题最高，备注（包括导入的自定义字段）最低
+var fieldWeights = map[string]int{
+	FieldTitle:    4,
+	FieldTag:      3,
+	FieldUsername: 3,
+	FieldURL:      2,
+	FieldFolder:   2,
+	FieldNotes:    1,
+}
+
+// fuzzyFields 允许模糊匹配（字符按顺序出现）的字段，备注较长，模糊匹配几乎总会命中
+var fuzzyFields = map[string]bool{
+	FieldTitle:    true,
+	FieldUsername: true,
+	FieldURL:      true,
+}
+
+// 单个值的匹配得分，从完全相同到模糊匹配依次降低
+const (
+	scoreExact          = 100
+	scorePrefix         = 80
+	scoreWordPrefix     = 60
+	scoreSubstring      = 40
+	scorePinyinPrefix   = 50
+	scorePinyin         = 30
+	scoreFuzzy          = 10
+	scorePinyinFuzzy    = 5
+	minFuzzyQueryLength = 2
+)
+
+// Term 查询中的一个条件
+type Term struct {
+	Field  string // 限定的字段，为空表示任意字段
+	Text   string // 小写的查询文本
+	Negate bool   // 以 - 开头，条目不能匹配该条件
+}
+
+// Query 解析后的查询，条目需满足全部条件
+type Query []Term
+
+// Parse 解析查询，条件之间用空白分隔，双引号包围的内容作为一个条件，
+// field:值 限定字段（如 user:alice url:github tag:work），-条件 排除匹配的条目
+func Parse(query string) Query {
+	var terms Query
+	for _, token := range tokenize(query) {
+		var term Term
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			term.Negate = true
+			token = token[1:]
+		}
+		if idx := strings.Index(token, ":"); idx > 0 {
+			if field, ok := fieldAliases[strings.ToLower(token[:idx])]; ok {
+				term.Field = field
+				token = token[idx+1:]
+			}
+		}
+		term.Text = strings.ToLower(strings.Trim(token, `"`))
+		if term.Text == "" {
+			continue
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// tokenize 按空白拆分查询，双引号中的空白不拆分
+func tokenize(query string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// entryFields 返回条目参与搜索的字段值
+func entryFields(entry *models.PasswordEntry) map[string][]string {
+	return map[string][]string{
+		FieldTitle:    {entry.Title},
+		FieldUsername: {entry.Username},
+		FieldURL:      {entry.URL},
+		FieldNotes:    {entry.Notes},
+		FieldTag:      entry.Tags,
+		FieldFolder:   {entry.Category},
+	}
+}
+
+// Score 返回条目与查询的相关度，不匹配时返回 0；空查询匹配所有条目
+func (q Query) Score(entry *models.PasswordEntry) int {
+	fields := entryFields(entry)
+	total := 0
+	for _, term := range q {
+		score := term.score(fields)
+		if term.Negate {
+			if score > 0 {
+				return 0
+			}
+			continue
+		}
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	if total == 0 {
+		// 只有排除条件时仍需返回正数表示匹配
+		return 1
+	}
+	return total
+}
+
+// score 返回条件在各字段中的最高得分
+func (t Term) score(fields map[string][]string) int {
+	best := 0
+	for field, values := range fields {
+		if t.Field != "" && t.Field != field {
+			continue
+		}
+		for _, value := range values {
+			if s := matchValue(value, t.Text, fuzzyFields[field]) * fieldWeights[field]; s > best {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+// matchValue 返回 query 与字段值的匹配得分，不区分大小写，含汉字的值还可以用拼音首字母匹配
+func matchValue(value, query string, fuzzy bool) int {
+	if value == "" {
+		return 0
+	}
+	lower := strings.ToLower(value)
+	switch idx := strings.Index(lower, query); {
+	case lower == query:
+		return scoreExact
+	case idx == 0:
+		return scorePrefix
+	case idx > 0:
+		if r, _ := utf8.DecodeLastRuneInString(lower[:idx]); !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return scoreWordPrefix
+		}
+		return scoreSubstring
+	}
+
+	if matched, prefix := matchPinyin(value, query); matched {
+		if prefix {
+			return scorePinyinPrefix
+		}
+		return scorePinyin
+	}
+
+	if !fuzzy || utf8.RuneCountInString(query) < minFuzzyQueryLength {
+		return 0
+	}
+	if isSubsequence(lower, query) {
+		return scoreFuzzy
+	}
+	if matchPinyinFuzzy(value, query) {
+		return scorePinyinFuzzy
+	}
+	return 0
+}
+
+// isSubsequence 检查 query 的字符是否按顺序出现在 text 中
+func isSubsequence(text, query string) bool {
+	q := []rune(query)
+	i := 0
+	for _, r := range text {
+		if i < len(q) && r == q[i] {
+			i++
+		}
+	}
+	return i == len(q)
+}
+
+// Search 返回匹配查询的条目，按相关度从高到低排列，相关度相同时保持原有顺序
+func Search(entries []*models.PasswordEntry, query string) []*models.PasswordEntry {
+	q := Parse(query)
+	if len(q) == 0 {
+		return entries
+	}
+
+	type result struct {
+		entry *models.PasswordEntry
+		score int
+	}
+	var results []result
+	for _, entry := range entries {
+		if score := q.Score(entry); score > 0 {
+			results = append(results, result{entry, score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	matched := make([]*models.PasswordEntry, len(results))
+	for i, r := range results {
+		matched[i] = r.entry
+	}
+	return matched
+}
+
+// MatchText 返回文本与查询的匹配得分，查询中的每个词都要匹配，允许模糊匹配和拼音首字母匹配，不匹配时返回 0
+// 用于命令名称等不是条目的文本
+func MatchText(text, query string) int {
+	total := 0
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		score := matchValue(text, word, true)
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	return total
+}
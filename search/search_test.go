@@ -0,0 +1,141 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"hank.com/password_tool/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Query
+	}{
+		{"", nil},
+		{"  GitHub  ", Query{{Text: "github"}}},
+		{`user:Alice 标签:工作 -url:example`, Query{{Field: FieldUsername, Text: "alice"}, {Field: FieldTag, Text: "工作"}, {Field: FieldURL, Text: "example", Negate: true}}},
+		{`"my bank" -old`, Query{{Text: "my bank"}, {Text: "old", Negate: true}}},
+		{`notes:"two words"`, Query{{Field: FieldNotes, Text: "two words"}}},
+		// 未知的字段前缀作为普通文本
+		{"foo:bar", Query{{Text: "foo:bar"}}},
+		{`- "" user:`, Query{{Text: "-"}}},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.query)
+		if len(got) != len(tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Parse(%q)[%d] = %+v, want %+v", tt.query, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestMatchValueOrder(t *testing.T) {
+	// 匹配方式从好到差依次排列
+	tests := []struct {
+		value, query string
+		want         int
+	}{
+		{"GitHub", "github", scoreExact},
+		{"GitHub Enterprise", "git", scorePrefix},
+		{"my-github", "git", scoreWordPrefix},
+		{"招商银行", "zsyh", scorePinyinPrefix},
+		{"legit", "git", scoreSubstring},
+		{"中国招商银行", "zsyh", scorePinyin},
+		{"github", "gthb", scoreFuzzy},
+		{"支付宝", "zb", scorePinyinFuzzy},
+		{"github", "x", 0},
+		{"", "git", 0},
+	}
+	for _, tt := range tests {
+		if got := matchValue(tt.value, tt.query, true); got != tt.want {
+			t.Errorf("matchValue(%q, %q) = %d, want %d", tt.value, tt.query, got, tt.want)
+		}
+	}
+
+	// 不允许模糊匹配的字段和单个字符的查询不做模糊匹配
+	if got := matchValue("github", "gthb", false); got != 0 {
+		t.Errorf("matchValue() without fuzzy = %d", got)
+	}
+	if got := matchValue("支付宝", "zb", false); got != 0 {
+		t.Errorf("matchValue() pinyin without fuzzy = %d", got)
+	}
+	if got := matchValue("abc", "c", true); got != scoreSubstring {
+		t.Errorf("matchValue() of a single character = %d", got)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	entries := []*models.PasswordEntry{
+		{Title: "notes only", Notes: "deploy with git"},
+		{Title: "legit"},
+		{Title: "my-git"},
+		{Title: "server", Username: "git"},
+		{Title: "GitHub"},
+		{Title: "GitHub Enterprise"},
+		{Title: "unrelated"},
+	}
+	var titles []string
+	for _, entry := range Search(entries, "git") {
+		titles = append(titles, entry.Title)
+	}
+	// 标题前缀 > 用户名完全相同 > 标题单词前缀 > 标题子串 > 备注，得分相同时保持原有顺序
+	want := "GitHub,GitHub Enterprise,server,my-git,legit,notes only"
+	if got := strings.Join(titles, ","); got != want {
+		t.Errorf("Search() = %s, want %s", got, want)
+	}
+
+	if got := Search(entries, "  "); len(got) != len(entries) {
+		t.Errorf("Search() with an empty query = %d entries", len(got))
+	}
+}
+
+func TestSearchFieldsAndNegation(t *testing.T) {
+	entries := []*models.PasswordEntry{
+		{Title: "alice mail", Username: "bob", URL: "https://mail.example.com"},
+		{Title: "work vpn", Username: "alice", Tags: []string{"work"}, Category: "工作/网络"},
+		{Title: "old vpn", Username: "alice", Tags: []string{"work", "old"}},
+	}
+	search := func(query string) string {
+		var titles []string
+		for _, entry := range Search(entries, query) {
+			titles = append(titles, entry.Title)
+		}
+		return strings.Join(titles, ",")
+	}
+
+	for query, want := range map[string]string{
+		"user:alice":             "work vpn,old vpn",
+		"alice":                  "alice mail,work vpn,old vpn",
+		"user:alice -tag:old":    "work vpn",
+		"-vpn":                   "alice mail",
+		"url:example":            "alice mail",
+		"分类:工作":                  "work vpn",
+		"folder:wl":              "work vpn",
+		"tag:work vpn":           "work vpn,old vpn",
+		`"work vpn"`:             "work vpn",
+		"notes:alice":            "",
+		"user:alice tag:missing": "",
+	} {
+		if got := search(query); got != want {
+			t.Errorf("Search(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestMatchText(t *testing.T) {
+	if MatchText("新建条目", "xj") == 0 {
+		t.Error("MatchText() did not match pinyin initials")
+	}
+	if MatchText("Lock vault", "lock va") <= MatchText("Lock vault", "lck") {
+		t.Error("MatchText() ranked a fuzzy match above prefixes")
+	}
+	if MatchText("Lock vault", "lock sync") != 0 {
+		t.Error("MatchText() matched when one word does not")
+	}
+}
@@ -0,0 +1,241 @@
+package sshagent
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"hank.com/password_tool/models"
+)
+
+var (
+	errLocked       = errors.New("agent is locked")
+	errReadOnly     = errors.New("keys are managed by password_tool")
+	errKeyNotFound  = errors.New("key not found")
+	errUseDenied    = errors.New("key use denied")
+	errNotSupported = errors.New("operation not supported")
+)
+
+// KeyStore 提供SSH密钥的存储，私钥仅在需要签名时解密
+type KeyStore interface {
+	GetSSHKeys() ([]*models.SSHKey, error)
+	GetSSHPrivateKey(id int) ([]byte, error)
+}
+
+// ConfirmFunc 在使用需要确认的密钥前调用，返回 false 表示拒绝
+type ConfirmFunc func(key *models.SSHKey) bool
+
+// Agent 基于密码库的 ssh-agent 实现，密钥只读
+type Agent struct {
+	store   KeyStore
+	confirm ConfirmFunc
+
+	mu         sync.Mutex
+	passphrase []byte // 非空表示已被 ssh-add -x 锁定
+}
+
+// New 创建新的 SSH agent
+func New(store KeyStore, confirm ConfirmFunc) *Agent {
+	return &Agent{store: store, confirm: confirm}
+}
+
+// List 返回所有可用的公钥
+func (a *Agent) List() ([]*agent.Key, error) {
+	if a.isLocked() {
+		return nil, nil
+	}
+
+	keys, err := a.store.GetSSHKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*agent.Key
+	for _, key := range keys {
+		pub, err := parsePublicKey(key.PublicKey)
+		if err != nil {
+			continue
+		}
+		result = append(result, &agent.Key{
+			Format:  pub.Type(),
+			Blob:    pub.Marshal(),
+			Comment: key.Comment,
+		})
+	}
+
+	return result, nil
+}
+
+// Sign 使用指定公钥对应的私钥签名
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags 使用指定公钥对应的私钥签名，支持 RSA SHA-2 标志
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if a.isLocked() {
+		return nil, errLocked
+	}
+
+	entry, err := a.findKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Confirm && (a.confirm == nil || !a.confirm(entry)) {
+		return nil, errUseDenied
+	}
+
+	pemData, err := a.store.GetSSHPrivateKey(entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(pemData)
+	wipe(pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags == 0 {
+		return signer.Sign(nil, data)
+	}
+
+	algoSigner, ok := signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, fmt.Errorf("signer does not support flags %d", flags)
+	}
+
+	algorithm := ""
+	switch {
+	case flags&agent.SignatureFlagRsaSha256 != 0:
+		algorithm = ssh.KeyAlgoRSASHA256
+	case flags&agent.SignatureFlagRsaSha512 != 0:
+		algorithm = ssh.KeyAlgoRSASHA512
+	default:
+		return nil, fmt.Errorf("unsupported signature flags %d", flags)
+	}
+
+	return algoSigner.SignWithAlgorithm(nil, data, algorithm)
+}
+
+// Add 不允许通过 ssh-add 添加密钥，密钥只能在应用中管理
+func (a *Agent) Add(key agent.AddedKey) error {
+	return errReadOnly
+}
+
+// Remove 不允许通过 ssh-add 删除密钥
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	return errReadOnly
+}
+
+// RemoveAll 不允许通过 ssh-add 删除密钥
+func (a *Agent) RemoveAll() error {
+	return errReadOnly
+}
+
+// Lock 使用口令锁定 agent
+func (a *Agent) Lock(passphrase []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.passphrase != nil {
+		return errLocked
+	}
+	a.passphrase = append([]byte{}, passphrase...)
+	return nil
+}
+
+// Unlock 使用口令解锁 agent
+func (a *Agent) Unlock(passphrase []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.passphrase == nil {
+		return errors.New("agent is not locked")
+	}
+	if subtle.ConstantTimeCompare(passphrase, a.passphrase) != 1 {
+		return errors.New("incorrect passphrase")
+	}
+	a.passphrase = nil
+	return nil
+}
+
+// Signers 不对外提供签名器，避免私钥长期驻留内存
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	return nil, errNotSupported
+}
+
+// Extension 不支持任何扩展
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// isLocked 检查 agent 是否被锁定
+func (a *Agent) isLocked() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.passphrase != nil
+}
+
+// findKey 查找与公钥匹配的密钥条目
+func (a *Agent) findKey(key ssh.PublicKey) (*models.SSHKey, error) {
+	keys, err := a.store.GetSSHKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	blob := key.Marshal()
+	for _, entry := range keys {
+		pub, err := parsePublicKey(entry.PublicKey)
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(pub.Marshal(), blob) {
+			return entry, nil
+		}
+	}
+
+	return nil, errKeyNotFound
+}
+
+// ParsePrivateKey 解析PEM格式私钥，返回 authorized_keys 格式的公钥
+func ParsePrivateKey(pemData []byte) (string, error) {
+	signer, err := ssh.ParsePrivateKey(pemData)
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return "", errors.New("私钥受口令保护，请先使用 ssh-keygen -p 去除口令")
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))), nil
+}
+
+// Fingerprint 返回公钥的 SHA256 指纹
+func Fingerprint(publicKey string) string {
+	pub, err := parsePublicKey(publicKey)
+	if err != nil {
+		return ""
+	}
+	return ssh.FingerprintSHA256(pub)
+}
+
+// parsePublicKey 解析 authorized_keys 格式的公钥
+func parsePublicKey(publicKey string) (ssh.PublicKey, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	return pub, err
+}
+
+// wipe 清除内存中的敏感数据
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,126 @@
+package sshagent
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var _ agent.ExtendedAgent = (*Agent)(nil)
+
+// Server 在 Unix socket 上提供 ssh-agent 协议服务
+type Server struct {
+	agent    *Agent
+	path     string
+	listener net.Listener
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// DefaultSocketPath 返回默认的 agent socket 路径
+func DefaultSocketPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".password_tool", "agent.sock"), nil
+}
+
+// Listen 在指定路径创建 socket 并开始提供服务
+// socket 先在权限为 0700 的临时目录中创建并设置为 0600，再移动到 path，
+// 其他用户在任何时刻都无法连接，不依赖 path 所在目录的权限
+func Listen(path string, a *Agent) (*Server, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	// 清理上次异常退出遗留的 socket 文件
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	privateDir, err := os.MkdirTemp(filepath.Dir(path), ".agent-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(privateDir)
+
+	privatePath := filepath.Join(privateDir, filepath.Base(path))
+	listener, err := net.Listen("unix", privatePath)
+	if err != nil {
+		return nil, err
+	}
+	// 移动后由 Close 删除 path，监听器不再删除原来的路径
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(privatePath, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(privatePath, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	s := &Server{
+		agent:    a,
+		path:     path,
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Path 返回 socket 路径
+func (s *Server) Path() string {
+	return s.path
+}
+
+// serve 接受连接并为每个连接提供服务
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go func() {
+			agent.ServeAgent(s.agent, conn)
+			conn.Close()
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close 停止服务，断开所有连接并删除 socket 文件
+// 正在等待确认的签名请求会在确认结束后自行退出
+func (s *Server) Close() error {
+	err := s.listener.Close()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	os.Remove(s.path)
+	return err
+}
@@ -0,0 +1,108 @@
+package sshagent
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"hank.com/password_tool/models"
+)
+
+// memoryKeyStore 保存在内存中的测试密钥
+type memoryKeyStore struct {
+	key     *models.SSHKey
+	private []byte
+}
+
+func (s *memoryKeyStore) GetSSHKeys() ([]*models.SSHKey, error) {
+	return []*models.SSHKey{s.key}, nil
+}
+
+func (s *memoryKeyStore) GetSSHPrivateKey(id int) ([]byte, error) {
+	return append([]byte{}, s.private...), nil
+}
+
+func newMemoryKeyStore(t *testing.T) *memoryKeyStore {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	private := pem.EncodeToMemory(block)
+	public, err := ParsePrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &memoryKeyStore{
+		key:     &models.SSHKey{ID: 1, Name: "test", PublicKey: public, Comment: "test"},
+		private: private,
+	}
+}
+
+func TestListenSignAndClose(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "agent.sock")
+	store := newMemoryKeyStore(t)
+
+	server, err := Listen(path, New(store, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %v, want socket with 0600", info.Mode())
+	}
+	// 创建 socket 用的临时目录已删除
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("directory contains %d files, want only the socket", len(files))
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := agent.NewClient(conn)
+	keys, err := client.List()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("List() = %v, %v", keys, err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(store.key.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := client.Sign(pub, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Verify([]byte("data"), signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	conn.Close()
+
+	if err := server.Close(); err != nil && !strings.Contains(err.Error(), "closed") {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after Close: %v", err)
+	}
+}
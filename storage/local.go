@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Local 使用本地目录作为存储，适用于已挂载的共享目录
+type Local struct {
+	root string
+	mu   sync.Mutex
+}
+
+// NewLocal 创建本地目录存储
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// Name 返回目录路径
+func (l *Local) Name() string {
+	return "local:" + l.root
+}
+
+// List 列出目录下的对象
+func (l *Local) List(dir string) ([]ObjectInfo, error) {
+	entries, err := os.ReadDir(l.path(dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var objects []ObjectInfo
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, ObjectInfo{
+			Name:    entry.Name(),
+			IsDir:   entry.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+// Get 读取文件内容，ETag 为内容的哈希
+func (l *Local) Get(name string) ([]byte, string, error) {
+	data, err := os.ReadFile(l.path(name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+	return data, contentETag(data), nil
+}
+
+// Put 写入文件，先写临时文件再重命名
+func (l *Local) Put(name string, data []byte, etag string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	path := l.path(name)
+	if etag != AnyVersion {
+		existing, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			if etag != "" {
+				return "", ErrPreconditionFailed
+			}
+		case err != nil:
+			return "", err
+		case etag == "" || contentETag(existing) != etag:
+			return "", ErrPreconditionFailed
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return contentETag(data), nil
+}
+
+// Delete 删除文件
+func (l *Local) Delete(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := os.Remove(l.path(name))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path 返回对象在本地的路径
+func (l *Local) path(name string) string {
+	return filepath.Join(l.root, filepath.FromSlash(name))
+}
+
+// contentETag 以内容哈希作为 ETag
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
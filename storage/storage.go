@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("object not found")
+	// ErrPreconditionFailed 对象已被其他设备修改（ETag 不匹配）或已存在
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// AnyVersion 作为 Put 的 etag 参数时表示无条件覆盖
+const AnyVersion = "*"
+
+// ObjectInfo 对象的元数据
+type ObjectInfo struct {
+	Name    string // 相对于所在目录的名称
+	IsDir   bool
+	Size    int64
+	ETag    string
+	ModTime time.Time
+}
+
+// Backend 远端存储的抽象，用于同步变更记录和备份加密的密码库
+//
+// Put 的 etag 参数用于乐观并发控制：
+// 为空表示只在对象不存在时创建；为 AnyVersion 表示无条件覆盖；
+// 否则只在对象当前的 ETag 与之相同时覆盖，不满足条件时返回 ErrPreconditionFailed。
+type Backend interface {
+	// Name 返回存储位置的唯一名称
+	Name() string
+	// List 列出目录下的对象，目录不存在时返回空列表
+	List(dir string) ([]ObjectInfo, error)
+	// Get 读取对象内容及其 ETag
+	Get(name string) ([]byte, string, error)
+	// Put 写入对象并返回新的 ETag，需要时自动创建上级目录
+	Put(name string, data []byte, etag string) (string, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(name string) error
+}
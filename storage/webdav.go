@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebDAV 通过 WebDAV 协议访问 NAS 等远端存储
+type WebDAV struct {
+	baseURL  *url.URL
+	user     string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAV 创建 WebDAV 存储，baseURL 为存放数据的目录地址
+func NewWebDAV(baseURL, user, password string) (*WebDAV, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported WebDAV URL %q", baseURL)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	return &WebDAV{
+		baseURL:  u,
+		user:     user,
+		password: password,
+		client:   &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+// Name 返回 WebDAV 地址
+func (w *WebDAV) Name() string {
+	return "webdav:" + w.baseURL.String()
+}
+
+// propfind 请求的属性
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:">
+  <d:prop>
+    <d:resourcetype/>
+    <d:getcontentlength/>
+    <d:getetag/>
+    <d:getlastmodified/>
+  </d:prop>
+</d:propfind>`
+
+// multistatus PROPFIND 的响应
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ContentLength string `xml:"getcontentlength"`
+				ETag          string `xml:"getetag"`
+				LastModified  string `xml:"getlastmodified"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// List 使用 PROPFIND（Depth: 1）列出目录
+func (w *WebDAV) List(dir string) ([]ObjectInfo, error) {
+	dirURL := w.url(strings.TrimSuffix(dir, "/") + "/")
+	req, err := w.newRequest("PROPFIND", dirURL, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, statusError("PROPFIND", dirURL, resp)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+
+	dirPath := strings.TrimSuffix(mustParse(dirURL).Path, "/")
+	var objects []ObjectInfo
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+
+		// 响应中包含目录自身，需要跳过
+		hrefPath := strings.TrimSuffix(href.Path, "/")
+		if hrefPath == dirPath {
+			continue
+		}
+
+		object := ObjectInfo{Name: path.Base(hrefPath)}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			object.IsDir = ps.Prop.ResourceType.Collection != nil
+			object.ETag = ps.Prop.ETag
+			object.Size, _ = strconv.ParseInt(ps.Prop.ContentLength, 10, 64)
+			object.ModTime, _ = http.ParseTime(ps.Prop.LastModified)
+		}
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// Get 使用 GET 读取对象
+func (w *WebDAV) Get(name string) ([]byte, string, error) {
+	objectURL := w.url(name)
+	req, err := w.newRequest(http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", statusError("GET", objectURL, resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// Put 使用 PUT 写入对象，通过 If-Match / If-None-Match 实现乐观并发控制
+func (w *WebDAV) Put(name string, data []byte, etag string) (string, error) {
+	newETag, status, err := w.put(name, data, etag)
+	if err != nil {
+		return "", err
+	}
+
+	// 上级目录不存在时服务器返回 409，创建目录后重试
+	if status == http.StatusConflict {
+		if err := w.mkdirAll(path.Dir(name)); err != nil {
+			return "", err
+		}
+		newETag, status, err = w.put(name, data, etag)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch status {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+	case http.StatusPreconditionFailed:
+		return "", ErrPreconditionFailed
+	default:
+		return "", fmt.Errorf("PUT %s: unexpected status %d", name, status)
+	}
+
+	// 部分服务器在 PUT 响应中不返回 ETag，需要再查询一次
+	if newETag == "" {
+		newETag, err = w.stat(name)
+		if err != nil {
+			return "", err
+		}
+	}
+	return newETag, nil
+}
+
+// put 发送一次 PUT 请求
+func (w *WebDAV) put(name string, data []byte, etag string) (string, int, error) {
+	req, err := w.newRequest(http.MethodPut, w.url(name), bytes.NewReader(data))
+	if err != nil {
+		return "", 0, err
+	}
+	req.ContentLength = int64(len(data))
+	switch etag {
+	case "":
+		req.Header.Set("If-None-Match", "*")
+	case AnyVersion:
+	default:
+		req.Header.Set("If-Match", etag)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return resp.Header.Get("ETag"), resp.StatusCode, nil
+}
+
+// Delete 使用 DELETE 删除对象
+func (w *WebDAV) Delete(name string) error {
+	objectURL := w.url(name)
+	req, err := w.newRequest(http.MethodDelete, objectURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return statusError("DELETE", objectURL, resp)
+	}
+}
+
+// stat 通过列出上级目录查询单个对象的 ETag
+func (w *WebDAV) stat(name string) (string, error) {
+	dir, base := path.Split(name)
+	objects, err := w.List(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, object := range objects {
+		if object.Name == base {
+			return object.ETag, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// mkdirAll 使用 MKCOL 创建目录，上级目录不存在（409）时先递归创建上级目录
+func (w *WebDAV) mkdirAll(dir string) error {
+	u := *w.baseURL
+	u.Path = path.Join(w.baseURL.Path, dir)
+	return w.mkcol(&u)
+}
+
+// mkcol 创建 u 指向的目录
+func (w *WebDAV) mkcol(u *url.URL) error {
+	if u.Path == "" || u.Path == "/" {
+		return nil
+	}
+
+	dirURL := *u
+	dirURL.Path = strings.TrimSuffix(u.Path, "/") + "/"
+	status, err := w.sendMkcol(dirURL.String())
+	if err != nil {
+		return err
+	}
+	if status == http.StatusConflict {
+		parent := *u
+		parent.Path = path.Dir(strings.TrimSuffix(u.Path, "/"))
+		if err := w.mkcol(&parent); err != nil {
+			return err
+		}
+		if status, err = w.sendMkcol(dirURL.String()); err != nil {
+			return err
+		}
+	}
+
+	// 405 表示目录已存在
+	if status != http.StatusCreated && status != http.StatusMethodNotAllowed {
+		return fmt.Errorf("MKCOL %s: unexpected status %d", dirURL.String(), status)
+	}
+	return nil
+}
+
+// sendMkcol 发送一次 MKCOL 请求
+func (w *WebDAV) sendMkcol(rawURL string) (int, error) {
+	req, err := w.newRequest("MKCOL", rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// newRequest 创建带认证信息的请求
+func (w *WebDAV) newRequest(method, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if w.user != "" || w.password != "" {
+		req.SetBasicAuth(w.user, w.password)
+	}
+	return req, nil
+}
+
+// url 返回对象的完整地址
+func (w *WebDAV) url(name string) string {
+	u := *w.baseURL
+	u.Path = path.Join(w.baseURL.Path, name)
+	if strings.HasSuffix(name, "/") {
+		u.Path += "/"
+	}
+	return u.String()
+}
+
+// mustParse 解析内部生成的地址
+func mustParse(rawURL string) *url.URL {
+	u, _ := url.Parse(rawURL)
+	return u
+}
+
+// statusError 将非预期的响应转换为错误
+func statusError(method, rawURL string, resp *http.Response) error {
+	return fmt.Errorf("%s %s: %s", method, rawURL, resp.Status)
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// newWebDAVServer 启动内存中的 WebDAV 服务器，x/net/webdav 不检查 If-Match / If-None-Match，由这里补充
+func newWebDAVServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "nas" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPut {
+			head := httptest.NewRecorder()
+			handler.ServeHTTP(head, httptest.NewRequest(http.MethodHead, r.URL.Path, nil))
+			exists := head.Code == http.StatusOK
+			if r.Header.Get("If-None-Match") == "*" && exists {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			if match := r.Header.Get("If-Match"); match != "" && (!exists || match != head.Header().Get("ETag")) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestWebDAV(t *testing.T, password string) *WebDAV {
+	t.Helper()
+	w, err := NewWebDAV(newWebDAVServer(t).URL+"/dav/vault", "nas", password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestWebDAVPutGetList(t *testing.T) {
+	w := newTestWebDAV(t, "secret")
+
+	// 目录不存在时返回空列表
+	objects, err := w.List("records")
+	if err != nil || len(objects) != 0 {
+		t.Fatalf("List() on missing directory = %v, %v", objects, err)
+	}
+
+	// 上级目录不存在时自动创建
+	etag, err := w.Put("records/device-a/1.json", []byte("one"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag == "" {
+		t.Fatal("Put() returned an empty ETag")
+	}
+
+	data, gotETag, err := w.Get("records/device-a/1.json")
+	if err != nil || string(data) != "one" || gotETag != etag {
+		t.Fatalf("Get() = %q, %q, %v, want %q, %q", data, gotETag, err, "one", etag)
+	}
+	if _, _, err := w.Get("records/device-a/missing.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of missing object error = %v, want ErrNotFound", err)
+	}
+
+	objects, err = w.List("records")
+	if err != nil || len(objects) != 1 || objects[0].Name != "device-a" || !objects[0].IsDir {
+		t.Fatalf("List(records) = %+v, %v", objects, err)
+	}
+	objects, err = w.List("records/device-a/")
+	if err != nil || len(objects) != 1 {
+		t.Fatalf("List(records/device-a) = %+v, %v", objects, err)
+	}
+	if object := objects[0]; object.Name != "1.json" || object.IsDir || object.Size != 3 || object.ETag != etag || object.ModTime.IsZero() {
+		t.Errorf("object = %+v", object)
+	}
+}
+
+func TestWebDAVConditionalPut(t *testing.T) {
+	w := newTestWebDAV(t, "secret")
+
+	etag, err := w.Put("vault.db", []byte("v1"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只在不存在时创建
+	if _, err := w.Put("vault.db", []byte("other"), ""); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("create over existing object error = %v, want ErrPreconditionFailed", err)
+	}
+
+	// If-Match 与当前 ETag 相同时覆盖
+	newETag, err := w.Put("vault.db", []byte("v2 from a"), etag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newETag == etag {
+		t.Error("ETag did not change after overwrite")
+	}
+
+	// 另一台设备使用旧的 ETag 写入时冲突（412）
+	if _, err := w.Put("vault.db", []byte("v2 from b"), etag); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("stale If-Match error = %v, want ErrPreconditionFailed", err)
+	}
+	data, _, err := w.Get("vault.db")
+	if err != nil || string(data) != "v2 from a" {
+		t.Fatalf("Get() after conflict = %q, %v", data, err)
+	}
+
+	// AnyVersion 无条件覆盖
+	if _, err := w.Put("vault.db", []byte("forced"), AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := w.Get("vault.db"); err != nil || string(data) != "forced" {
+		t.Fatalf("Get() after forced put = %q, %v", data, err)
+	}
+}
+
+func TestWebDAVDelete(t *testing.T) {
+	w := newTestWebDAV(t, "secret")
+	if _, err := w.Put("records/device-a/1.json", []byte("one"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Delete("records/device-a/1.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := w.Get("records/device-a/1.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+	// 删除不存在的对象不是错误
+	if err := w.Delete("records/device-a/1.json"); err != nil {
+		t.Errorf("Delete() of missing object = %v", err)
+	}
+}
+
+func TestWebDAVAuthFailure(t *testing.T) {
+	w := newTestWebDAV(t, "wrong")
+	if _, err := w.List(""); err == nil {
+		t.Error("List() succeeded with a wrong password")
+	}
+	if _, err := w.Put("vault.db", []byte("x"), ""); err == nil {
+		t.Error("Put() succeeded with a wrong password")
+	}
+}
@@ -0,0 +1,326 @@
+package syncserver
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// 单次请求体的大小上限
+	maxRequestBody = 32 << 20
+	// 单次拉取返回的记录数上限
+	pullPageSize = 500
+)
+
+// 用户名和记录名允许的字符
+var (
+	validUser = regexp.MustCompile(`^[A-Za-z0-9._@-]{1,64}$`)
+	validName = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+)
+
+// Record 服务器保存的一条加密变更记录，服务器无法解密内容
+// 同一设备上传 Key 相同的新记录时，旧的记录被取代并删除，Key 为空的记录一直保留
+type Record struct {
+	Seq    int64  `json:"seq"`
+	Device string `json:"device"`
+	Name   string `json:"name"`
+	Key    string `json:"key,omitempty"`
+	Data   []byte `json:"data"`
+}
+
+// RegisterRequest 注册请求
+// 新用户需要提供服务器的邀请码；PreviousToken 为已注册的旧令牌时（客户端重新生成密码库密钥），将其换成 Token
+type RegisterRequest struct {
+	User          string `json:"user"`
+	Token         string `json:"token"`
+	Invite        string `json:"invite,omitempty"`
+	PreviousToken string `json:"previous_token,omitempty"`
+}
+
+// PushRequest 上传请求
+type PushRequest struct {
+	Device  string   `json:"device"`
+	Records []Record `json:"records"`
+}
+
+// PullResponse 拉取响应，Cursor 为下次拉取时使用的游标，More 表示还有更多记录
+type PullResponse struct {
+	Records []Record `json:"records"`
+	Cursor  string   `json:"cursor"`
+	More    bool     `json:"more"`
+}
+
+// Server 零知识同步服务器，只保存密文和修订元数据
+type Server struct {
+	conn   *sql.DB
+	invite string
+}
+
+// Open 打开或创建服务器数据目录
+func Open(dataDir string) (*Server, error) {
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, err
+	}
+
+	conn, err := sql.Open("sqlite3", filepath.Join(dataDir, "server.db"))
+	if err != nil {
+		return nil, err
+	}
+
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS users (
+			name TEXT PRIMARY KEY,
+			token_hash TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS records (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			user TEXT NOT NULL,
+			device TEXT NOT NULL,
+			name TEXT NOT NULL,
+			key TEXT NOT NULL DEFAULT '',
+			data BLOB NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (user, device, name)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_records_key ON records (user, device, key)`,
+	}
+	for _, query := range queries {
+		if _, err := conn.Exec(query); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return &Server{conn: conn}, nil
+}
+
+// SetInvite 设置注册新用户所需的邀请码，为空时不接受新用户注册
+func (s *Server) SetInvite(code string) {
+	s.invite = code
+}
+
+// Close 关闭服务器数据库
+func (s *Server) Close() error {
+	return s.conn.Close()
+}
+
+// Handler 返回服务器的 HTTP 处理器
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/register", s.handleRegister)
+	mux.HandleFunc("/v1/records", s.handleRecords)
+	return mux
+}
+
+// ListenAndServe 在指定地址提供服务
+func (s *Server) ListenAndServe(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      time.Minute,
+	}
+	return server.ListenAndServe()
+}
+
+// handleRegister 注册用户；同一用户以相同令牌重复注册视为成功，新用户需要正确的邀请码
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req RegisterRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if !validUser.MatchString(req.User) || req.Token == "" {
+		http.Error(w, "invalid user or token", http.StatusBadRequest)
+		return
+	}
+
+	if s.authenticate(req.User, req.Token) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// 使用旧令牌认证后换成新令牌
+	if req.PreviousToken != "" {
+		result, err := s.conn.Exec("UPDATE users SET token_hash=? WHERE name=? AND token_hash=?",
+			hashToken(req.Token), req.User, hashToken(req.PreviousToken))
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if rows, err := result.RowsAffected(); err == nil && rows == 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	var exists bool
+	if err := s.conn.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE name=?)", req.User).Scan(&exists); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		http.Error(w, "user already exists", http.StatusConflict)
+		return
+	}
+
+	if s.invite == "" {
+		http.Error(w, "registration closed", http.StatusForbidden)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(req.Invite), []byte(s.invite)) != 1 {
+		http.Error(w, "invalid invite", http.StatusForbidden)
+		return
+	}
+
+	result, err := s.conn.Exec("INSERT OR IGNORE INTO users (name, token_hash) VALUES (?, ?)", req.User, hashToken(req.Token))
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		http.Error(w, "user already exists", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRecords 上传或拉取变更记录，使用 HTTP Basic 认证
+func (s *Server) handleRecords(w http.ResponseWriter, r *http.Request) {
+	user, token, ok := r.BasicAuth()
+	if !ok || !s.authenticate(user, token) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="password_tool"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.pull(w, r, user)
+	case http.MethodPut:
+		s.push(w, r, user)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// push 保存上传的记录，重复上传同名记录时忽略，并删除被新记录取代的旧记录
+func (s *Server) push(w http.ResponseWriter, r *http.Request, user string) {
+	var req PushRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if !validName.MatchString(req.Device) {
+		http.Error(w, "invalid device", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := s.conn.Begin()
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	for _, record := range req.Records {
+		if !validName.MatchString(record.Name) || (record.Key != "" && !validName.MatchString(record.Key)) {
+			http.Error(w, "invalid record name", http.StatusBadRequest)
+			return
+		}
+		_, err := tx.Exec("INSERT OR IGNORE INTO records (user, device, name, key, data) VALUES (?, ?, ?, ?, ?)",
+			user, req.Device, record.Name, record.Key, record.Data)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if record.Key != "" {
+			_, err := tx.Exec("DELETE FROM records WHERE user=? AND device=? AND key=? AND name!=?",
+				user, req.Device, record.Key, record.Name)
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pull 返回游标之后其他设备上传的记录
+func (s *Server) pull(w http.ResponseWriter, r *http.Request, user string) {
+	since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	device := r.URL.Query().Get("device")
+
+	rows, err := s.conn.Query(`
+		SELECT seq, device, name, data FROM records
+		WHERE user=? AND seq>? ORDER BY seq LIMIT ?`,
+		user, since, pullPageSize)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	resp := PullResponse{Records: []Record{}}
+	cursor := since
+	scanned := 0
+	for rows.Next() {
+		scanned++
+		var record Record
+		if err := rows.Scan(&record.Seq, &record.Device, &record.Name, &record.Data); err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		cursor = record.Seq
+		if record.Device != device {
+			resp.Records = append(resp.Records, record)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp.Cursor = strconv.FormatInt(cursor, 10)
+	resp.More = scanned == pullPageSize
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// authenticate 校验用户令牌
+func (s *Server) authenticate(user, token string) bool {
+	var tokenHash string
+	if err := s.conn.QueryRow("SELECT token_hash FROM users WHERE name=?", user).Scan(&tokenHash); err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(tokenHash), []byte(hashToken(token))) == 1
+}
+
+// hashToken 计算令牌的哈希，令牌由客户端从主密钥派生，熵足够高
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
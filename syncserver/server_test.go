@@ -0,0 +1,239 @@
+package syncserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T, invite string) *httptest.Server {
+	t.Helper()
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	s.SetInvite(invite)
+
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func register(t *testing.T, server *httptest.Server, req RegisterRequest) int {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(server.URL+"/v1/register", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func push(t *testing.T, server *httptest.Server, user, token string, req PushRequest) int {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq, err := http.NewRequest(http.MethodPut, server.URL+"/v1/records", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq.SetBasicAuth(user, token)
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func pull(t *testing.T, server *httptest.Server, user, token, device, since string) (PullResponse, int) {
+	t.Helper()
+	httpReq, err := http.NewRequest(http.MethodGet, server.URL+"/v1/records?device="+device+"&since="+since, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq.SetBasicAuth(user, token)
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var page PullResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return page, resp.StatusCode
+}
+
+func TestRegisterRequiresInvite(t *testing.T) {
+	closed := newTestServer(t, "")
+	if code := register(t, closed, RegisterRequest{User: "alice", Token: "t1", Invite: "anything"}); code != http.StatusForbidden {
+		t.Errorf("register without server invite = %d, want 403", code)
+	}
+
+	server := newTestServer(t, "join-me")
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "t1"}); code != http.StatusForbidden {
+		t.Errorf("register without invite = %d, want 403", code)
+	}
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "t1", Invite: "wrong"}); code != http.StatusForbidden {
+		t.Errorf("register with wrong invite = %d, want 403", code)
+	}
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "t1", Invite: "join-me"}); code != http.StatusNoContent {
+		t.Fatalf("register with invite = %d, want 204", code)
+	}
+
+	// 已注册的用户以相同令牌重复注册时不需要邀请码
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "t1"}); code != http.StatusNoContent {
+		t.Errorf("repeated register = %d, want 204", code)
+	}
+	// 邀请码不能用来抢占已注册的用户名
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "t2", Invite: "join-me"}); code != http.StatusConflict {
+		t.Errorf("register taken user = %d, want 409", code)
+	}
+	if code := register(t, server, RegisterRequest{User: "bad/user", Token: "t1", Invite: "join-me"}); code != http.StatusBadRequest {
+		t.Errorf("register invalid user = %d, want 400", code)
+	}
+}
+
+func TestRegisterReplacesPreviousToken(t *testing.T) {
+	server := newTestServer(t, "join-me")
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "old", Invite: "join-me"}); code != http.StatusNoContent {
+		t.Fatalf("register = %d", code)
+	}
+
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "new", PreviousToken: "wrong"}); code != http.StatusConflict {
+		t.Errorf("replace with wrong previous token = %d, want 409", code)
+	}
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "new", PreviousToken: "old"}); code != http.StatusNoContent {
+		t.Fatalf("replace token = %d, want 204", code)
+	}
+
+	if _, code := pull(t, server, "alice", "old", "a", "0"); code != http.StatusUnauthorized {
+		t.Errorf("pull with replaced token = %d, want 401", code)
+	}
+	if _, code := pull(t, server, "alice", "new", "a", "0"); code != http.StatusOK {
+		t.Errorf("pull with new token = %d, want 200", code)
+	}
+}
+
+func TestPushPullRoundTrip(t *testing.T) {
+	server := newTestServer(t, "join-me")
+	for _, user := range []string{"alice", "bob"} {
+		if code := register(t, server, RegisterRequest{User: user, Token: user + "-token", Invite: "join-me"}); code != http.StatusNoContent {
+			t.Fatalf("register %s = %d", user, code)
+		}
+	}
+
+	if code := push(t, server, "alice", "bob-token", PushRequest{Device: "a"}); code != http.StatusUnauthorized {
+		t.Errorf("push with another user's token = %d, want 401", code)
+	}
+	if code := push(t, server, "alice", "alice-token", PushRequest{Device: "a", Records: []Record{{Name: "../x", Data: []byte("x")}}}); code != http.StatusBadRequest {
+		t.Errorf("push invalid name = %d, want 400", code)
+	}
+
+	// 超过一页的记录分多次拉取
+	var records []Record
+	for i := 0; i < pullPageSize+10; i++ {
+		records = append(records, Record{Name: strconv.Itoa(i) + ".json", Data: []byte(strconv.Itoa(i))})
+	}
+	if code := push(t, server, "alice", "alice-token", PushRequest{Device: "a", Records: records}); code != http.StatusNoContent {
+		t.Fatalf("push = %d", code)
+	}
+	// 重复上传同名记录时保留最初的内容
+	if code := push(t, server, "alice", "alice-token", PushRequest{Device: "a", Records: []Record{{Name: "0.json", Data: []byte("changed")}}}); code != http.StatusNoContent {
+		t.Fatalf("push again = %d", code)
+	}
+	if code := push(t, server, "alice", "alice-token", PushRequest{Device: "b", Records: []Record{{Name: "1.json", Data: []byte("from b")}}}); code != http.StatusNoContent {
+		t.Fatalf("push from b = %d", code)
+	}
+
+	var got []Record
+	cursor := "0"
+	for pages := 0; ; pages++ {
+		page, code := pull(t, server, "alice", "alice-token", "b", cursor)
+		if code != http.StatusOK {
+			t.Fatalf("pull = %d", code)
+		}
+		got = append(got, page.Records...)
+		cursor = page.Cursor
+		if !page.More {
+			if pages != 1 {
+				t.Errorf("pulled in %d pages, want 2", pages+1)
+			}
+			break
+		}
+	}
+	if len(got) != len(records) {
+		t.Fatalf("pulled %d records, want %d", len(got), len(records))
+	}
+	for i, record := range got {
+		if record.Device != "a" || record.Name != records[i].Name || string(record.Data) != string(records[i].Data) {
+			t.Fatalf("record %d = %+v, want %+v", i, record, records[i])
+		}
+	}
+
+	// 游标之后没有新记录，其他用户看不到 alice 的记录
+	if page, _ := pull(t, server, "alice", "alice-token", "b", cursor); len(page.Records) != 0 || page.More {
+		t.Errorf("pull after cursor = %+v", page)
+	}
+	var fromB []Record
+	for cursor, more := "0", true; more; {
+		page, _ := pull(t, server, "alice", "alice-token", "a", cursor)
+		fromB = append(fromB, page.Records...)
+		cursor, more = page.Cursor, page.More
+	}
+	if len(fromB) != 1 || fromB[0].Device != "b" || string(fromB[0].Data) != "from b" {
+		t.Errorf("device a pulled %+v, want only the record from b", fromB)
+	}
+	if page, _ := pull(t, server, "bob", "bob-token", "c", "0"); len(page.Records) != 0 {
+		t.Errorf("bob pulled %d records", len(page.Records))
+	}
+}
+
+func TestPushReplacesRecordsWithSameKey(t *testing.T) {
+	server := newTestServer(t, "join-me")
+	if code := register(t, server, RegisterRequest{User: "alice", Token: "t", Invite: "join-me"}); code != http.StatusNoContent {
+		t.Fatalf("register = %d", code)
+	}
+
+	for _, records := range [][]Record{
+		{{Name: "x-1.rec", Key: "x", Data: []byte("x1")}, {Name: "y-1.rec", Key: "y", Data: []byte("y1")}},
+		{{Name: "x-2.rec", Key: "x", Data: []byte("x2")}},
+		// 早期版本的客户端不带 Key，记录不会被删除
+		{{Name: "old.rec", Data: []byte("old")}},
+	} {
+		if code := push(t, server, "alice", "t", PushRequest{Device: "a", Records: records}); code != http.StatusNoContent {
+			t.Fatalf("push = %d", code)
+		}
+	}
+	// 其他设备上 Key 相同的记录不受影响
+	if code := push(t, server, "alice", "t", PushRequest{Device: "b", Records: []Record{{Name: "x-3.rec", Key: "x", Data: []byte("x3")}}}); code != http.StatusNoContent {
+		t.Fatalf("push from b = %d", code)
+	}
+
+	page, code := pull(t, server, "alice", "t", "c", "0")
+	if code != http.StatusOK {
+		t.Fatalf("pull = %d", code)
+	}
+	var names []string
+	for _, record := range page.Records {
+		names = append(names, record.Device+"/"+record.Name)
+	}
+	if got, want := strings.Join(names, ","), "a/y-1.rec,a/x-2.rec,a/old.rec,b/x-3.rec"; got != want {
+		t.Errorf("records = %s, want %s", got, want)
+	}
+}
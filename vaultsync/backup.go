@@ -0,0 +1,53 @@
+package vaultsync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/storage"
+)
+
+// UploadBackup 将加密的密码库快照上传到存储后端
+// 使用上次上传返回的 ETag 作为条件，远端备份被其他程序修改时拒绝覆盖
+func UploadBackup(db *database.DB, backend storage.Backend) error {
+	device, err := db.DeviceID()
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp("", "password_tool-backup-*.db")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	if err := db.Snapshot(tmpPath); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	etagKey := "backup_etag:" + backend.Name()
+	etag, err := db.GetSetting(etagKey)
+	if err != nil {
+		return err
+	}
+
+	name := path.Join("backups", "passwords-"+device+".db")
+	newETag, err := backend.Put(name, data, etag)
+	if errors.Is(err, storage.ErrPreconditionFailed) {
+		return fmt.Errorf("远端备份 %s 已被修改，请确认后删除该文件再重试", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	return db.SetSetting(etagKey, newETag)
+}
@@ -0,0 +1,127 @@
+package vaultsync
+
+import (
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/storage"
+)
+
+// 保存同步配置的设置项
+const (
+	FolderSetting     = "sync_folder"
+	ServerURLSetting  = "sync_server_url"
+	ServerUserSetting = "sync_server_user"
+	// ServerInviteSetting 首次注册同步服务器时使用的邀请码，使用主密钥加密保存
+	ServerInviteSetting = "sync_server_invite"
+	WebDAVURLSetting    = "webdav_url"
+	WebDAVUserSetting   = "webdav_user"
+	// WebDAVPasswordSetting 使用主密钥加密保存
+	WebDAVPasswordSetting = "webdav_password"
+)
+
+// ConfiguredRemotes 根据已保存的设置创建所有交换位置
+func ConfiguredRemotes(db *database.DB) ([]Remote, error) {
+	var remotes []Remote
+
+	folder, err := db.GetSetting(FolderSetting)
+	if err != nil {
+		return nil, err
+	}
+	if folder != "" {
+		remotes = append(remotes, NewFolder(folder))
+	}
+
+	serverURL, err := db.GetSetting(ServerURLSetting)
+	if err != nil {
+		return nil, err
+	}
+	serverUser, err := db.GetSetting(ServerUserSetting)
+	if err != nil {
+		return nil, err
+	}
+	if serverURL != "" && serverUser != "" {
+		invite, err := db.GetSecretSetting(ServerInviteSetting)
+		if err != nil {
+			return nil, err
+		}
+		server, err := NewServerRemote(serverURL, serverUser, invite, db.GetKey())
+		if err != nil {
+			return nil, err
+		}
+		remotes = append(remotes, server)
+	}
+
+	backend, err := ConfiguredWebDAV(db)
+	if err != nil {
+		return nil, err
+	}
+	if backend != nil {
+		remotes = append(remotes, NewBackendRemote(backend))
+	}
+
+	return remotes, nil
+}
+
+// ConfiguredWebDAV 根据已保存的设置创建 WebDAV 存储，未配置时返回 nil
+func ConfiguredWebDAV(db *database.DB) (*storage.WebDAV, error) {
+	webdavURL, err := db.GetSetting(WebDAVURLSetting)
+	if err != nil || webdavURL == "" {
+		return nil, err
+	}
+	webdavUser, err := db.GetSetting(WebDAVUserSetting)
+	if err != nil {
+		return nil, err
+	}
+	webdavPassword, err := db.GetSecretSetting(WebDAVPasswordSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewWebDAV(webdavURL, webdavUser, webdavPassword)
+}
+
+// SyncAll 依次与所有交换位置同步，并汇总结果
+func SyncAll(db *database.DB, remotes []Remote) (*Result, error) {
+	total := &Result{}
+	for _, remote := range remotes {
+		if server, ok := remote.(*ServerRemote); ok {
+			if err := server.Register(); err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := NewEngine(db, remote).Sync()
+		if err != nil {
+			return nil, err
+		}
+		total.Pushed += result.Pushed
+		total.Applied += result.Applied
+		total.Conflicts += result.Conflicts
+		total.Undecryptable += result.Undecryptable
+	}
+	return total, nil
+}
+
+// RekeyHook 返回重新生成密码库密钥前调用的函数，将已配置的同步服务器上
+// 由旧密钥派生的令牌换成由新密钥派生的令牌，否则重新生成密钥后无法再登录同步服务器
+func RekeyHook(db *database.DB) func(oldKey, newKey []byte) error {
+	return func(oldKey, newKey []byte) error {
+		serverURL, err := db.GetSetting(ServerURLSetting)
+		if err != nil {
+			return err
+		}
+		serverUser, err := db.GetSetting(ServerUserSetting)
+		if err != nil || serverURL == "" || serverUser == "" {
+			return err
+		}
+		invite, err := db.GetSecretSetting(ServerInviteSetting)
+		if err != nil {
+			return err
+		}
+
+		server, err := NewServerRemote(serverURL, serverUser, invite, newKey)
+		if err != nil {
+			return err
+		}
+		return server.ReplaceToken(oldKey)
+	}
+}
@@ -0,0 +1,362 @@
+package vaultsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// 变更记录文件的后缀
+const recordSuffix = ".rec"
+
+// recordName 返回变更记录的名称，格式为 UID-修订.rec，同一条目的记录以 UID 为 Key
+func recordName(record *models.SyncRecord) string {
+	return record.UID + "-" + record.Revision() + recordSuffix
+}
+
+// recordKey 从记录名称中取出条目 UID，不是变更记录时返回空字符串
+func recordKey(name string) string {
+	if !strings.HasSuffix(name, recordSuffix) {
+		return ""
+	}
+	uid, _, ok := strings.Cut(name, "-")
+	if !ok {
+		return ""
+	}
+	return uid
+}
+
+// seenGroup 返回拉取到的记录（设备/UID-修订.rec）所属的组，同一设备同一条目的记录属于同一组
+func seenGroup(name string) string {
+	key := recordKey(path.Base(name))
+	if key == "" {
+		return ""
+	}
+	return path.Dir(name) + "/" + key + "-"
+}
+
+// Result 一次同步的统计结果
+type Result struct {
+	Pushed    int // 上传的本机变更数
+	Applied   int // 合并的其他设备变更数
+	Conflicts int // 产生冲突的条目数
+	// Undecryptable 无法解密而跳过的记录数，来自使用其他密码库密钥的设备，
+	// 例如本机或其他设备重新生成密码库密钥后尚未复制新的密码库
+	Undecryptable int
+}
+
+// Engine 在本机数据库和交换位置之间同步条目变更
+//
+// 每个条目有固定的 UID 和一组修订历史。合并其他设备的记录时：
+// 记录的当前修订已在本机历史中则忽略；本机当前修订在记录历史中则直接采用记录；
+// 否则视为冲突，以最后修改时间较新者为准（未删除的一方优先），
+// 落败的版本保存为冲突副本，副本的标识由落败修订推导，各设备生成的副本相同。
+type Engine struct {
+	db     *database.DB
+	remote Remote
+}
+
+// NewEngine 创建同步引擎
+func NewEngine(db *database.DB, remote Remote) *Engine {
+	return &Engine{db: db, remote: remote}
+}
+
+// Sync 先上传本机变更，再合并其他设备的变更
+func (e *Engine) Sync() (*Result, error) {
+	key := e.db.GetKey()
+	if key == nil {
+		return nil, fmt.Errorf("master key not set")
+	}
+
+	device, err := e.db.DeviceID()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	if result.Pushed, err = e.push(device, key); err != nil {
+		return nil, err
+	}
+	if err := e.pull(device, key, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// push 加密并上传本机尚未导出的变更
+func (e *Engine) push(device string, key []byte) (int, error) {
+	records, err := e.db.GetPendingSyncRecords(device)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	blobs := make([]Blob, 0, len(records))
+	for _, record := range records {
+		data, err := encryptRecord(record, key)
+		if err != nil {
+			return 0, err
+		}
+		blobs = append(blobs, Blob{
+			Name: recordName(record),
+			Key:  record.UID,
+			Data: data,
+		})
+	}
+
+	if err := e.remote.Push(device, blobs); err != nil {
+		return 0, err
+	}
+
+	for _, record := range records {
+		if err := e.db.MarkSyncRecordExported(record); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(records), nil
+}
+
+// pull 拉取并按修改时间顺序合并其他设备的变更，最后删除其他设备上已移走的文件夹
+func (e *Engine) pull(device string, key []byte, result *Result) error {
+	cursorKey := "sync_cursor:" + e.remote.Name()
+	cursor, err := e.db.GetSetting(cursorKey)
+	if err != nil {
+		return err
+	}
+
+	// 已处理的记录无需再次下载
+	var seenErr error
+	skip := func(name string) bool {
+		seen, err := e.db.IsSyncRecordSeen(name)
+		if err != nil && seenErr == nil {
+			seenErr = err
+		}
+		return seen
+	}
+
+	blobs, nextCursor, err := e.remote.Pull(device, cursor, skip)
+	if err != nil {
+		return err
+	}
+	if seenErr != nil {
+		return seenErr
+	}
+
+	type pulled struct {
+		name   string
+		record *models.SyncRecord
+	}
+	var records []pulled
+	for _, blob := range blobs {
+		seen, err := e.db.IsSyncRecordSeen(blob.Name)
+		if err != nil {
+			return err
+		}
+		if seen {
+			continue
+		}
+
+		// 无法解密的记录标记为已处理，不阻止合并其他记录；同一条目的新记录仍会被拉取
+		record, err := decryptRecord(blob.Data, key)
+		if err != nil {
+			result.Undecryptable++
+			if err := e.db.MarkSyncRecordSeen(blob.Name, seenGroup(blob.Name)); err != nil {
+				return err
+			}
+			continue
+		}
+		records = append(records, pulled{name: blob.Name, record: record})
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].record.UpdatedAt.Before(records[j].record.UpdatedAt)
+	})
+
+	var movedFrom []string
+	for _, p := range records {
+		applied, conflict, err := e.merge(device, p.record)
+		if err != nil {
+			return err
+		}
+		if applied {
+			result.Applied++
+			if p.record.MovedFrom != "" && !p.record.Deleted {
+				movedFrom = append(movedFrom, p.record.MovedFrom)
+			}
+		}
+		if conflict {
+			result.Conflicts++
+		}
+		if err := e.db.MarkSyncRecordSeen(p.name, seenGroup(p.name)); err != nil {
+			return err
+		}
+	}
+
+	if len(movedFrom) > 0 {
+		if err := e.db.PruneMovedCategories(movedFrom); err != nil {
+			return err
+		}
+	}
+	return e.db.SetSetting(cursorKey, nextCursor)
+}
+
+// merge 合并一条其他设备的变更记录
+func (e *Engine) merge(device string, remote *models.SyncRecord) (applied, conflict bool, err error) {
+	local, err := e.db.GetLocalSyncRecord(remote.UID, device)
+	if err != nil {
+		return false, false, err
+	}
+
+	switch {
+	case local == nil:
+		return true, false, e.db.ApplySyncRecord(remote)
+	case local.HasRevision(remote.Revision()):
+		// 已有该修订或更新的修订
+		return false, false, nil
+	case remote.HasRevision(local.Revision()):
+		// 本机修订是记录的祖先，直接采用
+		return true, false, e.db.ApplySyncRecord(remote)
+	}
+
+	// 双方在共同祖先之后各自修改，产生冲突
+	winner, loser := remote, local
+	if newer(local, remote) {
+		winner, loser = local, remote
+	}
+
+	// 先保存落败版本，本机版本落败时会被覆盖
+	if !loser.Deleted {
+		if err := e.saveConflictCopy(loser, local); err != nil {
+			return false, true, err
+		}
+	}
+
+	merged := mergeRevisions(loser.Revisions, winner.Revisions)
+	if winner == remote {
+		remote.Revisions = merged
+		return true, true, e.db.ApplySyncRecord(remote)
+	}
+	return false, true, e.db.SetSyncRevisions(local.UID, merged)
+}
+
+// saveConflictCopy 将冲突中落败的版本保存为新条目
+func (e *Engine) saveConflictCopy(loser, local *models.SyncRecord) error {
+	copyUID := derivedID(loser.UID, loser.Revision())
+	existing, err := e.db.GetLocalSyncRecord(copyUID, loser.Device)
+	if err != nil || existing != nil {
+		return err
+	}
+
+	entry := loser.Entry
+	if loser == local {
+		// 本机版本只有同步状态，需要读取完整条目
+		if entry, err = e.findLocalEntry(loser.UID); err != nil {
+			return err
+		}
+	}
+	if entry == nil {
+		return nil
+	}
+
+	copied := *entry
+	copied.ID = 0
+	copied.UID = copyUID
+	copied.Title = fmt.Sprintf("%s (冲突副本 %s)", entry.Title, loser.UpdatedAt.Local().Format("2006-01-02 15:04"))
+
+	return e.db.ApplySyncRecord(&models.SyncRecord{
+		UID:       copyUID,
+		Revisions: []string{derivedID("conflict", loser.Revision())},
+		Device:    loser.Device,
+		UpdatedAt: loser.UpdatedAt,
+		Folder:    copied.Category,
+		Entry:     &copied,
+	})
+}
+
+// findLocalEntry 按 UID 查找本机条目
+func (e *Engine) findLocalEntry(uid string) (*models.PasswordEntry, error) {
+	entries, err := e.db.GetPasswordEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.UID == uid {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// newer 判断冲突中 a 是否应胜出：未删除的一方优先，其次比较修改时间，最后比较设备标识
+func newer(a, b *models.SyncRecord) bool {
+	if a.Deleted != b.Deleted {
+		return !a.Deleted
+	}
+	if !a.UpdatedAt.Equal(b.UpdatedAt) {
+		return a.UpdatedAt.After(b.UpdatedAt)
+	}
+	return a.Device > b.Device
+}
+
+// mergeRevisions 合并双方的修订历史，胜出方的修订排在最后以保持当前修订不变
+func mergeRevisions(loser, winner []string) []string {
+	inWinner := make(map[string]bool, len(winner))
+	for _, rev := range winner {
+		inWinner[rev] = true
+	}
+
+	var merged []string
+	for _, rev := range loser {
+		if !inWinner[rev] {
+			merged = append(merged, rev)
+		}
+	}
+	return append(merged, winner...)
+}
+
+// derivedID 由已有标识推导出确定的新标识
+func derivedID(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// encryptRecord 使用主密钥加密变更记录
+func encryptRecord(record *models.SyncRecord, key []byte) ([]byte, error) {
+	plaintext, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := crypto.Encrypt(plaintext, key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(ciphertext), nil
+}
+
+// decryptRecord 解密变更记录
+func decryptRecord(data, key []byte) (*models.SyncRecord, error) {
+	plaintext, err := crypto.Decrypt(string(data), key)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.SyncRecord{}
+	if err := json.Unmarshal(plaintext, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
@@ -0,0 +1,290 @@
+package vaultsync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/models"
+)
+
+// newDevice 在独立的主目录中创建一台设备的密码库，key 不为空时使用同一个密码库密钥
+func newDevice(t *testing.T, key []byte) *database.DB {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	db, err := database.NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.SetMasterPassword("pw"); err != nil {
+		t.Fatal(err)
+	}
+	if key != nil {
+		db.SetMasterKey(key)
+	}
+	return db
+}
+
+// newDevicePair 创建共用一个密码库密钥和共享目录的两台设备
+func newDevicePair(t *testing.T) (a, b *database.DB, dir string) {
+	t.Helper()
+	a = newDevice(t, nil)
+	b = newDevice(t, a.GetKey())
+	return a, b, t.TempDir()
+}
+
+func mustSync(t *testing.T, db *database.DB, dir string) *Result {
+	t.Helper()
+	result, err := NewEngine(db, NewFolder(dir)).Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func mustAddEntry(t *testing.T, db *database.DB, title, category string) *models.PasswordEntry {
+	t.Helper()
+	entry := &models.PasswordEntry{Title: title, Username: "user", Password: "secret", Category: category}
+	if err := db.AddPasswordEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+// entriesByTitle 返回条目标题到条目的映射
+func entriesByTitle(t *testing.T, db *database.DB) map[string]*models.PasswordEntry {
+	t.Helper()
+	entries, err := db.GetPasswordEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byTitle := map[string]*models.PasswordEntry{}
+	for _, entry := range entries {
+		byTitle[entry.Title] = entry
+	}
+	return byTitle
+}
+
+func categoryNames(t *testing.T, db *database.DB) string {
+	t.Helper()
+	categories, err := db.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range categories {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestSyncAppliesEditsAndDeletes(t *testing.T) {
+	a, b, dir := newDevicePair(t)
+	mustAddEntry(t, a, "github", "工作")
+
+	if result := mustSync(t, a, dir); result.Pushed != 1 {
+		t.Errorf("Pushed = %d, want 1", result.Pushed)
+	}
+	if result := mustSync(t, b, dir); result.Applied != 1 || result.Conflicts != 0 {
+		t.Errorf("result = %+v, want 1 applied", result)
+	}
+	entry := entriesByTitle(t, b)["github"]
+	if entry == nil || entry.Password != "secret" || entry.Category != "工作" {
+		t.Fatalf("entry on b = %+v", entry)
+	}
+
+	// b 的修改以 a 的修订为祖先，直接采用
+	entry.Username = "octocat"
+	if err := b.UpdatePasswordEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	mustSync(t, b, dir)
+	if result := mustSync(t, a, dir); result.Applied != 1 || result.Conflicts != 0 {
+		t.Errorf("result = %+v, want 1 applied", result)
+	}
+	if got := entriesByTitle(t, a)["github"]; got == nil || got.Username != "octocat" {
+		t.Fatalf("entry on a = %+v", got)
+	}
+
+	// 删除的条目在其他设备上移入回收站
+	if err := a.DeletePasswordEntry(entriesByTitle(t, a)["github"].ID); err != nil {
+		t.Fatal(err)
+	}
+	mustSync(t, a, dir)
+	mustSync(t, b, dir)
+	if entries := entriesByTitle(t, b); len(entries) != 0 {
+		t.Errorf("entries on b after delete = %v", entries)
+	}
+	if trash, err := b.GetDeletedPasswordEntries(); err != nil || len(trash) != 1 {
+		t.Errorf("GetDeletedPasswordEntries() on b = %d, %v", len(trash), err)
+	}
+
+	// 再次同步没有新的变更
+	if result := mustSync(t, b, dir); *result != (Result{}) {
+		t.Errorf("second sync = %+v", result)
+	}
+}
+
+func TestSyncConflictKeepsCopy(t *testing.T) {
+	a, b, dir := newDevicePair(t)
+	mustAddEntry(t, a, "github", "")
+	mustSync(t, a, dir)
+	mustSync(t, b, dir)
+
+	// 双方在同步之间各自修改同一条目，b 的修改较新
+	entry := entriesByTitle(t, a)["github"]
+	entry.Password = "from-a"
+	if err := a.UpdatePasswordEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	entry = entriesByTitle(t, b)["github"]
+	entry.Password = "from-b"
+	if err := b.UpdatePasswordEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	mustSync(t, a, dir)
+	if result := mustSync(t, b, dir); result.Conflicts != 1 {
+		t.Errorf("conflicts on b = %d, want 1", result.Conflicts)
+	}
+	if result := mustSync(t, a, dir); result.Conflicts != 1 {
+		t.Errorf("conflicts on a = %d, want 1", result.Conflicts)
+	}
+
+	// 两台设备都以较新的版本为准，并得到相同的冲突副本
+	for name, db := range map[string]*database.DB{"a": a, "b": b} {
+		entries, err := db.GetPasswordEntries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("%s: %d entries, want 2", name, len(entries))
+		}
+		passwords := map[string]string{}
+		for _, entry := range entries {
+			if strings.Contains(entry.Title, "冲突副本") {
+				passwords["copy"] = entry.Password
+			} else {
+				passwords[entry.Title] = entry.Password
+			}
+		}
+		if passwords["github"] != "from-b" || passwords["copy"] != "from-a" {
+			t.Errorf("%s: passwords = %v", name, passwords)
+		}
+	}
+	if entriesByTitle(t, a)["github"].UID != entriesByTitle(t, b)["github"].UID {
+		t.Error("winner UID differs between devices")
+	}
+
+	// 合并后双方不再冲突
+	mustSync(t, b, dir)
+	if result := mustSync(t, a, dir); result.Conflicts != 0 {
+		t.Errorf("conflicts after merge = %d", result.Conflicts)
+	}
+}
+
+func TestSyncFolderMove(t *testing.T) {
+	a, b, dir := newDevicePair(t)
+	mustAddEntry(t, a, "aws", "工作/云")
+	mustAddEntry(t, a, "gcp", "工作/云/gcp")
+	mustSync(t, a, dir)
+	mustSync(t, b, dir)
+	// 只在 b 上使用的文件夹不受影响
+	if err := b.AddCategory("个人"); err != nil {
+		t.Fatal(err)
+	}
+
+	categories, err := a.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range categories {
+		if c.Name == "工作/云" {
+			if err := a.MoveCategory(c.ID, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	mustSync(t, a, dir)
+	mustSync(t, b, dir)
+
+	entries := entriesByTitle(t, b)
+	if entries["aws"].Category != "云" || entries["gcp"].Category != "云/gcp" {
+		t.Errorf("categories on b = %q, %q", entries["aws"].Category, entries["gcp"].Category)
+	}
+	// 移走后变空的 工作/云 被删除，上级文件夹保留
+	if got := categoryNames(t, a); got != "云,云/gcp,工作" {
+		t.Errorf("folders on a = %s", got)
+	}
+	if got := categoryNames(t, b); got != "个人,云,云/gcp,工作" {
+		t.Errorf("folders on b = %s", got)
+	}
+}
+
+func TestSyncCompactsRecords(t *testing.T) {
+	a, b, dir := newDevicePair(t)
+	mustAddEntry(t, a, "github", "")
+	mustAddEntry(t, a, "aws", "")
+	deviceA, err := a.DeviceID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lastSeen []string
+	for i := 0; i < 3; i++ {
+		entry := entriesByTitle(t, a)["github"]
+		entry.Notes = strings.Repeat("x", i)
+		if err := a.UpdatePasswordEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+		mustSync(t, a, dir)
+		mustSync(t, b, dir)
+
+		files, err := os.ReadDir(filepath.Join(dir, folderName, deviceA))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 每个条目只保留最新的一条记录
+		if len(files) != 2 {
+			t.Fatalf("round %d: %d records on remote, want 2", i, len(files))
+		}
+
+		var names []string
+		for _, file := range files {
+			names = append(names, deviceA+"/"+file.Name())
+		}
+		for _, name := range names {
+			if seen, err := b.IsSyncRecordSeen(name); err != nil || !seen {
+				t.Errorf("round %d: IsSyncRecordSeen(%s) = %v, %v", i, name, seen, err)
+			}
+		}
+		// 被取代的记录名称已从 b 的记录中删除
+		for _, name := range lastSeen {
+			if contains(names, name) {
+				continue
+			}
+			if seen, err := b.IsSyncRecordSeen(name); err != nil || seen {
+				t.Errorf("round %d: superseded %s still seen: %v, %v", i, name, seen, err)
+			}
+		}
+		lastSeen = names
+	}
+
+	if got := entriesByTitle(t, b)["github"]; got == nil || got.Notes != "xx" {
+		t.Errorf("entry on b = %+v", got)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package vaultsync
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"hank.com/password_tool/storage"
+)
+
+// Blob 表示一条加密后的变更记录
+// 同一设备上 Key 相同的记录中只有最新的一条有效，上传后删除被取代的旧记录，避免远端记录无限增长
+type Blob struct {
+	Name string
+	Key  string
+	Data []byte
+}
+
+// Remote 变更记录的交换位置
+type Remote interface {
+	// Name 返回交换位置的唯一名称，用于保存拉取游标
+	Name() string
+	// Push 上传本机产生的变更记录，并删除本机上传过的被取代的记录
+	Push(device string, blobs []Blob) error
+	// Pull 获取其他设备产生的变更记录，cursor 为上次返回的游标，
+	// skip 返回 true 的记录无需下载
+	Pull(device, cursor string, skip func(name string) bool) ([]Blob, string, error)
+}
+
+// 远端存储中存放变更记录的子目录
+const folderName = "password_tool-sync"
+
+// BackendRemote 通过存储后端（共享目录、WebDAV 等）交换变更记录
+// 每台设备只写入自己的子目录，避免同步工具产生文件冲突
+type BackendRemote struct {
+	backend storage.Backend
+}
+
+// NewBackendRemote 创建基于存储后端的交换位置
+func NewBackendRemote(backend storage.Backend) *BackendRemote {
+	return &BackendRemote{backend: backend}
+}
+
+// NewFolder 创建基于共享目录（Syncthing、NAS、U盘等）的交换位置
+func NewFolder(dir string) *BackendRemote {
+	return NewBackendRemote(storage.NewLocal(dir))
+}
+
+// Name 返回存储后端的名称
+func (r *BackendRemote) Name() string {
+	return r.backend.Name()
+}
+
+// Push 将变更记录写入本机的子目录，记录内容不可变，已存在时跳过
+// 写入后删除子目录中 Key 相同的旧记录
+func (r *BackendRemote) Push(device string, blobs []Blob) error {
+	pushed := make(map[string]bool, len(blobs))
+	keys := make(map[string]bool)
+	for _, blob := range blobs {
+		_, err := r.backend.Put(path.Join(folderName, device, blob.Name), blob.Data, "")
+		if err != nil && !errors.Is(err, storage.ErrPreconditionFailed) {
+			return err
+		}
+		pushed[blob.Name] = true
+		if blob.Key != "" {
+			keys[blob.Key] = true
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	files, err := r.backend.List(path.Join(folderName, device))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir || pushed[file.Name] || !keys[recordKey(file.Name)] {
+			continue
+		}
+		if err := r.backend.Delete(path.Join(folderName, device, file.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pull 读取其他设备子目录中尚未处理的变更记录
+func (r *BackendRemote) Pull(device, cursor string, skip func(name string) bool) ([]Blob, string, error) {
+	deviceDirs, err := r.backend.List(folderName)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	var blobs []Blob
+	for _, deviceDir := range deviceDirs {
+		if !deviceDir.IsDir || deviceDir.Name == device {
+			continue
+		}
+
+		files, err := r.backend.List(path.Join(folderName, deviceDir.Name))
+		if err != nil {
+			return nil, cursor, err
+		}
+		for _, file := range files {
+			if file.IsDir || !strings.HasSuffix(file.Name, recordSuffix) {
+				continue
+			}
+
+			name := deviceDir.Name + "/" + file.Name
+			if skip(name) {
+				continue
+			}
+
+			data, _, err := r.backend.Get(path.Join(folderName, deviceDir.Name, file.Name))
+			if err != nil {
+				return nil, cursor, err
+			}
+			blobs = append(blobs, Blob{Name: name, Data: data})
+		}
+	}
+
+	return blobs, cursor, nil
+}
@@ -0,0 +1,159 @@
+package vaultsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/syncserver"
+)
+
+// ServerRemote 通过 password_tool serve 同步服务器交换变更记录
+// 服务器只保存密文，认证令牌由主密钥按服务器地址和用户名单独派生，与加密密钥无关
+type ServerRemote struct {
+	baseURL string
+	user    string
+	token   string
+	invite  string
+	client  *http.Client
+}
+
+// NewServerRemote 创建同步服务器客户端，invite 为首次注册时使用的服务器邀请码
+func NewServerRemote(baseURL, user, invite string, key []byte) (*ServerRemote, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	token, err := crypto.DeriveSyncToken(key, baseURL, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServerRemote{
+		baseURL: baseURL,
+		user:    user,
+		token:   token,
+		invite:  invite,
+		client:  &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// Name 返回服务器地址和用户
+func (s *ServerRemote) Name() string {
+	return "server:" + s.user + "@" + s.baseURL
+}
+
+// Register 在服务器上注册用户，已使用相同令牌注册时视为成功
+func (s *ServerRemote) Register() error {
+	return s.register(syncserver.RegisterRequest{User: s.user, Token: s.token, Invite: s.invite})
+}
+
+// ReplaceToken 将服务器上由 previousKey 派生的令牌换成当前令牌，用于重新生成密码库密钥
+// 尚未在服务器上注册时使用邀请码注册
+func (s *ServerRemote) ReplaceToken(previousKey []byte) error {
+	previous, err := crypto.DeriveSyncToken(previousKey, s.baseURL, s.user)
+	if err != nil {
+		return err
+	}
+	return s.register(syncserver.RegisterRequest{User: s.user, Token: s.token, PreviousToken: previous, Invite: s.invite})
+}
+
+// register 发送一次注册请求
+func (s *ServerRemote) register(register syncserver.RegisterRequest) error {
+	body, err := json.Marshal(register)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.baseURL+"/v1/register", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Push 上传本机产生的变更记录
+func (s *ServerRemote) Push(device string, blobs []Blob) error {
+	push := syncserver.PushRequest{Device: device}
+	for _, blob := range blobs {
+		push.Records = append(push.Records, syncserver.Record{Name: blob.Name, Key: blob.Key, Data: blob.Data})
+	}
+
+	body, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.baseURL+"/v1/records", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(s.user, s.token)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Pull 按游标分页拉取其他设备的变更记录，服务器按游标增量返回，无需跳过
+func (s *ServerRemote) Pull(device, cursor string, skip func(name string) bool) ([]Blob, string, error) {
+	var blobs []Blob
+	for {
+		query := url.Values{"since": {cursor}, "device": {device}}
+		req, err := http.NewRequest(http.MethodGet, s.baseURL+"/v1/records?"+query.Encode(), nil)
+		if err != nil {
+			return nil, "", err
+		}
+		req.SetBasicAuth(s.user, s.token)
+
+		resp, err := s.do(req)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var page syncserver.PullResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, record := range page.Records {
+			blobs = append(blobs, Blob{Name: record.Device + "/" + record.Name, Data: record.Data})
+		}
+		cursor = page.Cursor
+
+		if !page.More {
+			return blobs, cursor, nil
+		}
+	}
+}
+
+// do 发送请求，非 2xx 响应转换为错误
+func (s *ServerRemote) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("sync server: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,189 @@
+package vaultsync
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hank.com/password_tool/crypto"
+	"hank.com/password_tool/database"
+	"hank.com/password_tool/syncserver"
+)
+
+func newSyncServer(t *testing.T, invite string) *httptest.Server {
+	t.Helper()
+	s, err := syncserver.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	s.SetInvite(invite)
+
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestServerRemote(t *testing.T, baseURL, user, invite string, key []byte) *ServerRemote {
+	t.Helper()
+	remote, err := NewServerRemote(baseURL, user, invite, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return remote
+}
+
+func TestServerRemoteRoundTrip(t *testing.T) {
+	server := newSyncServer(t, "join-me")
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := newTestServerRemote(t, server.URL, "alice", "", key).Register(); err == nil {
+		t.Fatal("Register() without invite succeeded")
+	}
+
+	a := newTestServerRemote(t, server.URL+"/", "alice", "join-me", key)
+	if err := a.Register(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Push("device-a", []Blob{{Name: "1.rec", Data: []byte("one")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 另一台设备不需要邀请码，令牌由相同的密钥、地址和用户名派生
+	b := newTestServerRemote(t, server.URL, "alice", "", key)
+	if err := b.Register(); err != nil {
+		t.Fatal(err)
+	}
+	blobs, cursor, err := b.Pull("device-b", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 1 || blobs[0].Name != "device-a/1.rec" || string(blobs[0].Data) != "one" {
+		t.Fatalf("Pull() = %+v", blobs)
+	}
+	if blobs, _, err := b.Pull("device-b", cursor, nil); err != nil || len(blobs) != 0 {
+		t.Errorf("Pull() after cursor = %+v, %v", blobs, err)
+	}
+
+	// 其他密钥派生的令牌无法访问
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := newTestServerRemote(t, server.URL, "alice", "", other).Pull("device-c", "", nil); err == nil {
+		t.Error("Pull() with another vault's token succeeded")
+	}
+}
+
+func TestSyncTokenBoundToServerAndUser(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := map[string]bool{}
+	for _, target := range [][2]string{
+		{"https://a.example", "alice"},
+		{"https://b.example", "alice"},
+		{"https://a.example", "bob"},
+	} {
+		token, err := crypto.DeriveSyncToken(key, target[0], target[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tokens[token] {
+			t.Errorf("duplicate token for %v", target)
+		}
+		tokens[token] = true
+	}
+}
+
+// configureSync 为设备配置同步文件夹和同步服务器
+func configureSync(t *testing.T, db *database.DB, dir, serverURL string) {
+	t.Helper()
+	settings := map[string]string{FolderSetting: dir, ServerURLSetting: serverURL, ServerUserSetting: "alice"}
+	for key, value := range settings {
+		if err := db.SetSetting(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SetSecretSetting(ServerInviteSetting, "join-me"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustSyncAll(t *testing.T, db *database.DB) *Result {
+	t.Helper()
+	remotes, err := ConfiguredRemotes(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := SyncAll(db, remotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestSyncAcrossRekey(t *testing.T) {
+	server := newSyncServer(t, "join-me")
+	a, b, dir := newDevicePair(t)
+	configureSync(t, a, dir, server.URL)
+	configureSync(t, b, dir, server.URL)
+	a.SetRekeyHook(RekeyHook(a))
+
+	mustAddEntry(t, a, "github", "")
+	mustAddEntry(t, b, "aws", "")
+	mustSyncAll(t, a)
+	mustSyncAll(t, b)
+	mustSyncAll(t, a)
+
+	// 启用密钥文件后密码库密钥重新生成
+	data, err := crypto.GenerateKeyFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := crypto.ParseKeyFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ChangeMasterPassword(database.Credentials{Password: "pw", KeyFile: keyFile}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 仍使用旧密钥的设备的记录被跳过，不再中止同步；服务器令牌已经换成新密钥派生的令牌
+	mustAddEntry(t, b, "gcp", "")
+	mustSync(t, b, dir)
+	result := mustSyncAll(t, a)
+	if result.Pushed != 2 || result.Undecryptable == 0 {
+		t.Errorf("sync after rekey = %+v, want 2 entries re-exported and undecryptable records", result)
+	}
+	if result := mustSyncAll(t, a); *result != (Result{}) {
+		t.Errorf("second sync after rekey = %+v", result)
+	}
+
+	// 交换位置上用旧密钥加密的记录已被取代
+	deviceA, err := a.DeviceID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := os.ReadDir(filepath.Join(dir, folderName, deviceA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("%d records from a on the folder, want 2", len(files))
+	}
+
+	// 使用新密钥的设备可以从两个交换位置取得全部条目
+	c := newDevice(t, a.GetKey())
+	configureSync(t, c, dir, server.URL)
+	mustSyncAll(t, c)
+	entries := entriesByTitle(t, c)
+	if entries["github"] == nil || entries["aws"] == nil || entries["aws"].Password != "secret" {
+		t.Errorf("entries on c = %v", entries)
+	}
+}